@@ -1,17 +1,75 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"moon-cost/assert"
 	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/tools/env"
+	"net"
+	"net/http"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
 )
 
 type Config struct {
-	Port int
+	Port int `env:"PORT" default:"8080"`
+
+	ReadTimeout     time.Duration `env:"READ_TIMEOUT" default:"10s"`
+	WriteTimeout    time.Duration `env:"WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout     time.Duration `env:"IDLE_TIMEOUT" default:"2m"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	DatabaseURL string `env:"DATABASE_URL" default:"file:./local.db"`
+	// Blank runs the migrations embedded in the binary
+	MigrationsDir string `env:"MIGRATIONS_DIR"`
+	RunMigrations bool   `env:"RUN_MIGRATIONS" default:"false"`
+}
+
+// Env files loaded by LoadConfig. Later files take precedence and the process
+// environment takes precedence over all of them
+var ConfigFiles = []string{".env", ".env.local"}
+
+func LoadConfig() (Config, error) {
+	var config Config
+
+	vars, err := env.Layer(ConfigFiles...)
+
+	if err != nil {
+		return config, err
+	}
+
+	err = vars.Decode(&config)
+
+	return config, err
 }
 
+// ShutdownHook is run after the server has stopped accepting requests and
+// in-flight requests have drained
+type ShutdownHook func(context.Context) error
+
 type API struct {
 	Server *router.Server
 	Config Config
+	Logger *slog.Logger
+
+	// Middleware applied to every route created with PrivateRoute
+	Private []router.Middleware
+
+	// Resolves organization memberships for RequireRole
+	Members MemberResolver
+
+	mu         sync.Mutex
+	httpServer *http.Server
+	listener   net.Listener
+	serveErr   chan error
+	hooks      []ShutdownHook
 }
 
 func New(config Config) *API {
@@ -20,9 +78,145 @@ func New(config Config) *API {
 	return &API{
 		Server: server,
 		Config: config,
+		Logger: slog.Default(),
 	}
 }
 
+// PrivateRoute creates a route protected by the API's Private middleware
+func (a *API) PrivateRoute(path string) *router.Route {
+	return a.Server.Route(path).Use(a.Private...)
+}
+
+// RequireRole returns middleware requiring role in the route's organization
+func (a *API) RequireRole(role organization.Role) router.Middleware {
+	assert.Ensure(a.Members, "API.Members is required for role based routes")
+
+	return RequireRole(a.Members, role, a.Logger)
+}
+
 func (a *API) Port() string {
 	return fmt.Sprintf(":%d", a.Config.Port)
 }
+
+// Addr returns the address the server is listening on once started
+func (a *API) Addr() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.listener == nil {
+		return ""
+	}
+
+	return a.listener.Addr().String()
+}
+
+// Registers a hook to run on Shutdown. Hooks run in reverse order of
+// registration, the same as deferred calls
+func (a *API) OnShutdown(hook ShutdownHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.hooks = append(a.hooks, hook)
+}
+
+// Start begins listening on the configured port and serves requests in the
+// background. Errors binding the port are returned immediately
+func (a *API) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	assert.Ok(a.httpServer == nil, "API already started")
+
+	listener, err := net.Listen("tcp", a.Port())
+
+	if err != nil {
+		return err
+	}
+
+	a.listener = listener
+	a.serveErr = make(chan error, 1)
+	a.httpServer = &http.Server{
+		Handler:      a.Server.Mux,
+		ReadTimeout:  a.Config.ReadTimeout,
+		WriteTimeout: a.Config.WriteTimeout,
+		IdleTimeout:  a.Config.IdleTimeout,
+	}
+
+	go func() {
+		err := a.httpServer.Serve(listener)
+
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+
+		a.serveErr <- err
+	}()
+
+	a.Logger.Info("Server started", "addr", listener.Addr().String())
+
+	return nil
+}
+
+// Shutdown stops accepting new connections, waits for in-flight requests to
+// finish until ctx is done and then runs every registered ShutdownHook. Hooks
+// run even when draining fails and all errors are returned joined
+func (a *API) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	httpServer := a.httpServer
+	hooks := slices.Clone(a.hooks)
+	a.mu.Unlock()
+
+	var errs error
+
+	if httpServer != nil {
+		a.Logger.Info("Draining in-flight requests")
+
+		if err := httpServer.Shutdown(ctx); err != nil {
+			a.Logger.Error("Error draining requests", "error", err)
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			a.Logger.Error("Error running shutdown hook", "error", err)
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	a.Logger.Info("Server stopped")
+
+	return errs
+}
+
+// Run starts the server and blocks until ctx is cancelled, SIGINT or SIGTERM
+// is received or the server fails. The server is then shut down with
+// Config.ShutdownTimeout to drain in-flight requests. The shutdown hooks also
+// run when the server fails to start
+func (a *API) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.Start(); err != nil {
+		a.Logger.Error("Error starting server", "error", err)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+		defer cancel()
+
+		return errors.Join(err, a.Shutdown(shutdownCtx))
+	}
+
+	var serveErr error
+
+	select {
+	case <-ctx.Done():
+		a.Logger.Info("Shutdown signal received")
+	case serveErr = <-a.serveErr:
+		a.Logger.Error("Server error", "error", serveErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(serveErr, a.Shutdown(shutdownCtx))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestAPI() *API {
+	api := New(Config{ShutdownTimeout: time.Second})
+	api.Logger = slog.New(slog.DiscardHandler)
+
+	return api
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	api := newTestAPI()
+
+	started := make(chan struct{})
+
+	api.Server.Route("").Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(time.Millisecond * 100)
+		w.Write([]byte("done"))
+	})
+
+	var calls []int
+
+	api.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, 1)
+		return nil
+	})
+
+	api.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, 2)
+		return nil
+	})
+
+	if err := api.Start(); err != nil {
+		t.Fatalf("api.Start() = %s. want nil", err)
+	}
+
+	type result struct {
+		body string
+		err  error
+	}
+
+	results := make(chan result, 1)
+
+	go func() {
+		res, err := http.Get("http://" + api.Addr() + "/slow")
+
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+
+		defer res.Body.Close()
+
+		body, err := io.ReadAll(res.Body)
+		results <- result{body: string(body), err: err}
+	}()
+
+	<-started
+
+	if err := api.Shutdown(context.Background()); err != nil {
+		t.Fatalf("api.Shutdown() = %s. want nil", err)
+	}
+
+	res := <-results
+
+	if res.err != nil {
+		t.Fatalf("in-flight request failed: %s", res.err)
+	}
+
+	if res.body != "done" {
+		t.Errorf("in-flight response = %s. want %s", res.body, "done")
+	}
+
+	if !slices.Equal(calls, []int{2, 1}) {
+		t.Errorf("shutdown hooks called in order %v. want %v", calls, []int{2, 1})
+	}
+
+	if _, err := http.Get("http://" + api.Addr() + "/slow"); err == nil {
+		t.Errorf("Expected request after shutdown to fail")
+	}
+}
+
+func TestRunShutsDownOnCancel(t *testing.T) {
+	api := newTestAPI()
+
+	hookCalled := make(chan struct{})
+
+	api.OnShutdown(func(ctx context.Context) error {
+		close(hookCalled)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- api.Run(ctx)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("api.Run() = %s. want nil", err)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("api.Run() did not return after context was cancelled")
+	}
+
+	select {
+	case <-hookCalled:
+	default:
+		t.Errorf("Expected shutdown hook to be called")
+	}
+}
+
+func TestRunShutsDownWhenStartFails(t *testing.T) {
+	taken, err := net.Listen("tcp", ":0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer taken.Close()
+
+	api := newTestAPI()
+	api.Config.Port = taken.Addr().(*net.TCPAddr).Port
+
+	hookCalled := false
+
+	api.OnShutdown(func(ctx context.Context) error {
+		hookCalled = true
+		return nil
+	})
+
+	if err := api.Run(context.Background()); err == nil {
+		t.Fatal("api.Run() = nil. want the listen error")
+	}
+
+	if !hookCalled {
+		t.Errorf("Expected shutdown hook to be called")
+	}
+}
@@ -1,13 +1,15 @@
 package api
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"moon-cost/router"
 	"moon-cost/services/auth"
 	"net/http"
+	"time"
 )
 
+const SessionCookieName = "moon_session"
+
 type AuthController struct {
 	Route *router.Route
 	Auth  *auth.Service
@@ -16,24 +18,160 @@ type AuthController struct {
 func (a *AuthController) Init(api *API) {
 	a.Route = api.Server.Route("/auth")
 
-	a.Route.Post("/signup/{id}", a.Signup)
+	a.Route.Post("/signup", a.Signup)
+	a.Route.Post("/login", a.Login)
+	a.Route.Post("/token", a.Token)
+
+	api.PrivateRoute("/auth").Get("/me", a.Me)
+}
+
+type SignupRequest struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
 }
 
-type Response struct {
-	Hello string `json:"hello"`
+type SignupResponse struct {
+	User    auth.User    `json:"user"`
+	Account auth.Account `json:"account"`
 }
 
 func (a *AuthController) Signup(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
+	var input SignupRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, a.Auth.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := a.Auth.Signup(r.Context(), auth.Signup{
+		Email:     input.Email,
+		Password:  input.Password,
+		Firstname: input.Firstname,
+		Lastname:  input.Lastname,
+	})
+
+	if errors.Is(err, auth.InvalidSignupError) {
+		writeError(w, a.Auth.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if errors.Is(err, auth.SignupAccountExistsError) {
+		writeError(w, a.Auth.Logger, http.StatusConflict, err.Error())
+		return
+	}
 
-	hello := Response{
-		Hello: id,
+	if err != nil {
+		a.Auth.Logger.Error("Error signing up", "error", err)
+		writeError(w, a.Auth.Logger, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(201)
+	writeJSON(w, a.Auth.Logger, http.StatusCreated, SignupResponse{
+		User:    result.User,
+		Account: result.Account,
+	})
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	User    auth.User    `json:"user"`
+	Account auth.Account `json:"account"`
+	Session auth.Session `json:"session"`
+}
+
+// Login issues a session to browsers through an HttpOnly cookie. The token is
+// left out of the body so scripts on the page cannot read it
+func (a *AuthController) Login(w http.ResponseWriter, r *http.Request) {
+	result, ok := a.login(w, r)
 
-	if err := json.NewEncoder(w).Encode(hello); err != nil {
-		fmt.Printf("err: %s\n", err)
+	if !ok {
+		return
 	}
+
+	setSessionCookie(w, result.Session.Token, result.Session.ExpiresAt)
+
+	writeJSON(w, a.Auth.Logger, http.StatusOK, LoginResponse{
+		User:    result.User,
+		Account: result.Account,
+		Session: result.Session,
+	})
+}
+
+type TokenResponse struct {
+	LoginResponse
+	Token string `json:"token"`
+}
+
+// Token issues a session to clients that send it as an Authorization bearer
+// header. No cookie is set
+func (a *AuthController) Token(w http.ResponseWriter, r *http.Request) {
+	result, ok := a.login(w, r)
+
+	if !ok {
+		return
+	}
+
+	writeJSON(w, a.Auth.Logger, http.StatusOK, TokenResponse{
+		LoginResponse: LoginResponse{
+			User:    result.User,
+			Account: result.Account,
+			Session: result.Session,
+		},
+		Token: result.Session.Token,
+	})
+}
+
+// Logs in with the credentials in the request body. The error response has
+// been written when ok is false
+func (a *AuthController) login(w http.ResponseWriter, r *http.Request) (auth.LoginResult, bool) {
+	var input LoginRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, a.Auth.Logger, http.StatusBadRequest, err.Error())
+		return auth.LoginResult{}, false
+	}
+
+	result, err := a.Auth.Login(r.Context(), input.Email, input.Password)
+
+	if errors.Is(err, auth.InvalidCredentialsError) {
+		writeError(w, a.Auth.Logger, http.StatusUnauthorized, err.Error())
+		return result, false
+	}
+
+	if err != nil {
+		a.Auth.Logger.Error("Error logging in", "error", err)
+		writeError(w, a.Auth.Logger, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return result, false
+	}
+
+	return result, true
+}
+
+func setSessionCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (a *AuthController) Me(w http.ResponseWriter, r *http.Request) {
+	identity, ok := auth.IdentityFromContext(r.Context())
+
+	if !ok {
+		writeError(w, a.Auth.Logger, http.StatusUnauthorized, MissingCredentialsError.Error())
+		return
+	}
+
+	writeJSON(w, a.Auth.Logger, http.StatusOK, identity)
 }
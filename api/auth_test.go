@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"moon-cost/moontest"
+	"moon-cost/services/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoginKeepsTokenOutOfBody(t *testing.T) {
+	api := newTestAPI()
+	service := auth.NewService(auth.NewSQLiteRepo(moontest.LoadTestDB(t)), api.Logger)
+
+	controller := &AuthController{Auth: service}
+	controller.Init(api)
+
+	if _, err := service.Signup(context.Background(), auth.Signup{Email: "test@test.com", Password: "password"}); err != nil {
+		t.Fatalf("service.Signup() = _, %s. want nil", err)
+	}
+
+	login := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"email":"test@test.com","password":"password"}`))
+		rec := httptest.NewRecorder()
+		api.Server.Mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s status = %d. want %d", path, rec.Code, http.StatusOK)
+		}
+
+		return rec
+	}
+
+	rec := login("/auth/login")
+	cookies := rec.Result().Cookies()
+
+	if len(cookies) != 1 || cookies[0].Name != SessionCookieName || !cookies[0].HttpOnly {
+		t.Fatalf("POST /auth/login cookies = %v. want an HttpOnly %s cookie", cookies, SessionCookieName)
+	}
+
+	if strings.Contains(rec.Body.String(), cookies[0].Value) || strings.Contains(rec.Body.String(), `"token"`) {
+		t.Errorf("POST /auth/login body = %s. want no session token", rec.Body.String())
+	}
+
+	rec = login("/auth/token")
+
+	if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("POST /auth/token cookies = %v. want none", cookies)
+	}
+
+	var body TokenResponse
+
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Authenticate(context.Background(), body.Token); err != nil {
+		t.Errorf("service.Authenticate(token) = _, %s. want nil", err)
+	}
+}
@@ -0,0 +1,438 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"moon-cost/router"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/tag"
+	"net/http"
+	"time"
+)
+
+type IngredientController struct {
+	Route       *router.Route
+	Ingredients *ingredient.Service
+}
+
+var ingredientErrors = errorStatuses{
+	{ingredient.IngredientNotFoundError, http.StatusNotFound},
+	{tag.TagNotFoundError, http.StatusNotFound},
+	{ingredient.IngredientInUseError, http.StatusConflict},
+	{ingredient.InvalidIngredientError, http.StatusBadRequest},
+	{ingredient.InvalidImportError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (i *IngredientController) Init(api *API) {
+	i.Route = api.PrivateRoute("/orgs/{orgId}/ingredients")
+
+	viewer := i.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := i.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", i.List)
+	manager.Post("", i.Create)
+	manager.Post("/import", i.Import)
+	viewer.Get("/{ingredientId}", i.Get)
+	manager.Put("/{ingredientId}", i.Update)
+	manager.Delete("/{ingredientId}", i.Delete)
+	viewer.Get("/{ingredientId}/prices", i.Prices)
+	manager.Post("/{ingredientId}/prices", i.RecordPrice)
+	viewer.Get("/{ingredientId}/tags", i.ListTags)
+	manager.Put("/{ingredientId}/tags", i.SetTags)
+}
+
+func (i *IngredientController) writeError(w http.ResponseWriter, err error) {
+	ingredientErrors.write(w, i.Ingredients.Logger, err)
+}
+
+// Prices are in cents. YieldPercent defaults to 100
+type IngredientRequest struct {
+	Name          string  `json:"name"`
+	Brand         string  `json:"brand"`
+	Vendor        string  `json:"vendor"`
+	Category      string  `json:"category"`
+	BrandId       *int    `json:"brandId"`
+	VendorId      *int    `json:"vendorId"`
+	Unit          string  `json:"unit"`
+	UnitCount     float64 `json:"unitCount"`
+	PurchasePrice int64   `json:"purchasePrice"`
+	YieldPercent  float64 `json:"yieldPercent"`
+}
+
+func (i IngredientRequest) input() ingredient.IngredientInput {
+	return ingredient.IngredientInput{
+		Name:          i.Name,
+		Brand:         i.Brand,
+		Vendor:        i.Vendor,
+		Category:      i.Category,
+		BrandId:       i.BrandId,
+		VendorId:      i.VendorId,
+		Unit:          i.Unit,
+		UnitCount:     i.UnitCount,
+		PurchasePrice: i.PurchasePrice,
+		YieldPercent:  i.YieldPercent,
+	}
+}
+
+// Reads the vendorId, brandId and tagIds query params
+func ingredientFilter(r *http.Request) (ingredient.Filter, error) {
+	var filter ingredient.Filter
+	var err error
+
+	if filter.VendorId, err = queryInt(r, "vendorId"); err != nil {
+		return filter, err
+	}
+
+	if filter.BrandId, err = queryInt(r, "brandId"); err != nil {
+		return filter, err
+	}
+
+	filter.TagIds, err = queryInts(r, "tagIds")
+
+	return filter, err
+}
+
+func (i *IngredientController) List(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	filter, err := ingredientFilter(r)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredients, err := i.Ingredients.ListIngredients(r.Context(), orgId, filter)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, ingredients)
+}
+
+func (i *IngredientController) Create(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input IngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Ingredients.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := i.Ingredients.CreateIngredient(r.Context(), orgId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusCreated, created)
+}
+
+func (i *IngredientController) Get(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	found, err := i.Ingredients.GetIngredient(r.Context(), orgId, ingredientId)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, found)
+}
+
+func (i *IngredientController) Update(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input IngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Ingredients.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := i.Ingredients.UpdateIngredient(r.Context(), orgId, ingredientId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, updated)
+}
+
+func (i *IngredientController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	if err := i.Ingredients.DeleteIngredient(r.Context(), orgId, ingredientId); err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unit and unitCount default to the ingredient's current purchase and
+// effectiveAt defaults to now. Prices are in cents
+type PriceRequest struct {
+	PurchasePrice int64     `json:"purchasePrice"`
+	UnitCount     float64   `json:"unitCount"`
+	Unit          string    `json:"unit"`
+	Source        string    `json:"source"`
+	EffectiveAt   time.Time `json:"effectiveAt"`
+}
+
+func (i *IngredientController) Prices(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	prices, err := i.Ingredients.Prices(r.Context(), orgId, ingredientId)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, prices)
+}
+
+func (i *IngredientController) RecordPrice(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input PriceRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Ingredients.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	price, err := i.Ingredients.RecordPrice(r.Context(), orgId, ingredientId, ingredient.PriceInput{
+		PurchasePrice: input.PurchasePrice,
+		UnitCount:     input.UnitCount,
+		Unit:          input.Unit,
+		Source:        input.Source,
+		EffectiveAt:   input.EffectiveAt,
+	})
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusCreated, price)
+}
+
+// Largest CSV file accepted by Import
+const maxImportSize = 10 << 20
+
+// Reads the import options from the kind, mapping, source and dryRun query
+// params. Kind defaults to a catalog import
+func importInput(r *http.Request) (ingredient.ImportInput, error) {
+	query := r.URL.Query()
+
+	input := ingredient.ImportInput{
+		Kind:   ingredient.ImportKind(query.Get("kind")),
+		Source: query.Get("source"),
+	}
+
+	if input.Kind == "" {
+		input.Kind = ingredient.ImportCatalog
+	}
+
+	mapping, err := ingredient.ParseMapping(query.Get("mapping"))
+
+	if err != nil {
+		return input, err
+	}
+
+	input.Mapping = mapping
+	input.DryRun, err = queryBool(r, "dryRun")
+
+	return input, err
+}
+
+// Imports a CSV file sent as the request body or as the file field of a
+// multipart form. Responds with the row errors and nothing saved when any row
+// is invalid
+func (i *IngredientController) Import(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	input, err := importInput(r)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var file io.Reader = r.Body
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		formFile, _, err := r.FormFile("file")
+
+		if err != nil {
+			writeError(w, i.Ingredients.Logger, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %s", err))
+			return
+		}
+
+		defer formFile.Close()
+
+		file = formFile
+	}
+
+	result, err := i.Ingredients.Import(r.Context(), orgId, input, file)
+
+	if errors.Is(err, ingredient.ImportRowsError) {
+		writeJSON(w, i.Ingredients.Logger, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, result)
+}
+
+func (i *IngredientController) ListTags(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	tagIds, err := i.Ingredients.ListIngredientTags(r.Context(), orgId, ingredientId)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, TagIdsResponse{TagIds: tagIds})
+}
+
+// Replaces the tags of the ingredient
+func (i *IngredientController) SetTags(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input TagIdsRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Ingredients.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tagIds, err := i.Ingredients.SetIngredientTags(r.Context(), orgId, ingredientId, input.TagIds)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Ingredients.Logger, http.StatusOK, TagIdsResponse{TagIds: tagIds})
+}
@@ -0,0 +1,485 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/inventory"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/units"
+	"net/http"
+	"time"
+)
+
+type InventoryController struct {
+	Route     *router.Route
+	Inventory *inventory.Service
+}
+
+var inventoryErrors = errorStatuses{
+	{inventory.CountNotFoundError, http.StatusNotFound},
+	{inventory.CountItemNotFoundError, http.StatusNotFound},
+	{inventory.PurchaseNotFoundError, http.StatusNotFound},
+	{inventory.SaleNotFoundError, http.StatusNotFound},
+	{organization.LocationNotFoundError, http.StatusNotFound},
+	{ingredient.IngredientNotFoundError, http.StatusNotFound},
+	{product.ProductNotFoundError, http.StatusNotFound},
+	{product.PrepRecipeNotFoundError, http.StatusNotFound},
+	{product.PrepRecipeCycleError, http.StatusUnprocessableEntity},
+	{inventory.InvalidCountError, http.StatusBadRequest},
+	{inventory.InvalidPurchaseError, http.StatusBadRequest},
+	{inventory.InvalidSaleError, http.StatusBadRequest},
+	{inventory.InvalidVarianceError, http.StatusBadRequest},
+	{units.UnknownUnitError, http.StatusUnprocessableEntity},
+	{units.IncompatibleUnitError, http.StatusUnprocessableEntity},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (i *InventoryController) Init(api *API) {
+	i.Route = api.PrivateRoute("/orgs/{orgId}/inventory")
+
+	viewer := i.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := i.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("/counts", i.ListCounts)
+	manager.Post("/counts", i.CreateCount)
+	viewer.Get("/counts/{countId}", i.GetCount)
+	manager.Delete("/counts/{countId}", i.DeleteCount)
+	manager.Put("/counts/{countId}/items/{ingredientId}", i.SetCountItem)
+	manager.Delete("/counts/{countId}/items/{ingredientId}", i.RemoveCountItem)
+
+	viewer.Get("/purchases", i.ListPurchases)
+	manager.Post("/purchases", i.RecordPurchase)
+	manager.Delete("/purchases/{purchaseId}", i.DeletePurchase)
+
+	viewer.Get("/sales", i.ListSales)
+	manager.Post("/sales", i.RecordSale)
+	manager.Delete("/sales/{saleId}", i.DeleteSale)
+
+	viewer.Get("/variance", i.Variance)
+}
+
+func (i *InventoryController) writeError(w http.ResponseWriter, err error) {
+	inventoryErrors.write(w, i.Inventory.Logger, err)
+}
+
+// Returns the orgId path param and the id path param called name
+func (i *InventoryController) idParams(r *http.Request, name string) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	id, err := pathInt(r, name)
+
+	return orgId, id, err
+}
+
+// Reads the locationId, from and to query params. Dates in from start at the
+// beginning of the day and dates in to end at the end of the day
+func inventoryFilter(r *http.Request) (inventory.Filter, error) {
+	var filter inventory.Filter
+	var err error
+
+	if filter.LocationId, err = queryInt(r, "locationId"); err != nil {
+		return filter, err
+	}
+
+	if filter.From, err = queryStartTime(r, "from"); err != nil {
+		return filter, err
+	}
+
+	filter.To, err = queryTime(r, "to")
+
+	return filter, err
+}
+
+// Unit defaults to the unit the ingredient is purchased in
+type CountItemRequest struct {
+	IngredientId int     `json:"ingredientId"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+func (c CountItemRequest) input() inventory.CountItemInput {
+	return inventory.CountItemInput{
+		IngredientId: c.IngredientId,
+		Quantity:     c.Quantity,
+		Unit:         c.Unit,
+	}
+}
+
+// countedAt defaults to now
+type CountRequest struct {
+	LocationId int                `json:"locationId"`
+	CountedAt  time.Time          `json:"countedAt"`
+	Notes      string             `json:"notes"`
+	Items      []CountItemRequest `json:"items"`
+}
+
+func (c CountRequest) input() inventory.CountInput {
+	input := inventory.CountInput{
+		LocationId: c.LocationId,
+		CountedAt:  c.CountedAt,
+		Notes:      c.Notes,
+	}
+
+	for _, item := range c.Items {
+		input.Items = append(input.Items, item.input())
+	}
+
+	return input
+}
+
+func (i *InventoryController) ListCounts(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	filter, err := inventoryFilter(r)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	counts, err := i.Inventory.ListCounts(r.Context(), orgId, filter)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, counts)
+}
+
+func (i *InventoryController) CreateCount(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input CountRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Inventory.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	count, err := i.Inventory.CreateCount(r.Context(), orgId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusCreated, count)
+}
+
+func (i *InventoryController) GetCount(w http.ResponseWriter, r *http.Request) {
+	orgId, countId, err := i.idParams(r, "countId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	count, err := i.Inventory.GetCount(r.Context(), orgId, countId)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, count)
+}
+
+func (i *InventoryController) DeleteCount(w http.ResponseWriter, r *http.Request) {
+	orgId, countId, err := i.idParams(r, "countId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	if err := i.Inventory.DeleteCount(r.Context(), orgId, countId); err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// The ingredient comes from the path so ingredientId is ignored
+func (i *InventoryController) SetCountItem(w http.ResponseWriter, r *http.Request) {
+	orgId, countId, err := i.idParams(r, "countId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input CountItemRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Inventory.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	input.IngredientId = ingredientId
+
+	item, err := i.Inventory.SetCountItem(r.Context(), orgId, countId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, item)
+}
+
+func (i *InventoryController) RemoveCountItem(w http.ResponseWriter, r *http.Request) {
+	orgId, countId, err := i.idParams(r, "countId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	ingredientId, err := pathInt(r, "ingredientId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	if err := i.Inventory.RemoveCountItem(r.Context(), orgId, countId, ingredientId); err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unit defaults to the unit the ingredient is purchased in and purchasedAt
+// defaults to now
+type PurchaseRequest struct {
+	LocationId   int       `json:"locationId"`
+	IngredientId int       `json:"ingredientId"`
+	VendorId     int       `json:"vendorId"`
+	Quantity     float64   `json:"quantity"`
+	Unit         string    `json:"unit"`
+	Price        int64     `json:"price"`
+	Source       string    `json:"source"`
+	PurchasedAt  time.Time `json:"purchasedAt"`
+}
+
+func (p PurchaseRequest) input() inventory.PurchaseInput {
+	return inventory.PurchaseInput{
+		LocationId:   p.LocationId,
+		IngredientId: p.IngredientId,
+		VendorId:     p.VendorId,
+		Quantity:     p.Quantity,
+		Unit:         p.Unit,
+		Price:        p.Price,
+		Source:       p.Source,
+		PurchasedAt:  p.PurchasedAt,
+	}
+}
+
+func (i *InventoryController) ListPurchases(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	filter, err := inventoryFilter(r)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	purchases, err := i.Inventory.ListPurchases(r.Context(), orgId, filter)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, purchases)
+}
+
+func (i *InventoryController) RecordPurchase(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input PurchaseRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Inventory.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	purchase, err := i.Inventory.RecordPurchase(r.Context(), orgId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusCreated, purchase)
+}
+
+func (i *InventoryController) DeletePurchase(w http.ResponseWriter, r *http.Request) {
+	orgId, purchaseId, err := i.idParams(r, "purchaseId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	if err := i.Inventory.DeletePurchase(r.Context(), orgId, purchaseId); err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// soldAt defaults to now
+type SaleRequest struct {
+	LocationId int       `json:"locationId"`
+	ProductId  int       `json:"productId"`
+	Quantity   int       `json:"quantity"`
+	SoldAt     time.Time `json:"soldAt"`
+}
+
+func (s SaleRequest) input() inventory.SaleInput {
+	return inventory.SaleInput{
+		LocationId: s.LocationId,
+		ProductId:  s.ProductId,
+		Quantity:   s.Quantity,
+		SoldAt:     s.SoldAt,
+	}
+}
+
+func (i *InventoryController) ListSales(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	filter, err := inventoryFilter(r)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	sales, err := i.Inventory.ListSales(r.Context(), orgId, filter)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, sales)
+}
+
+func (i *InventoryController) RecordSale(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	var input SaleRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, i.Inventory.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sale, err := i.Inventory.RecordSale(r.Context(), orgId, input.input())
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusCreated, sale)
+}
+
+func (i *InventoryController) DeleteSale(w http.ResponseWriter, r *http.Request) {
+	orgId, saleId, err := i.idParams(r, "saleId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	if err := i.Inventory.DeleteSale(r.Context(), orgId, saleId); err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Compares actual and theoretical usage between the openingCountId and
+// closingCountId query params
+func (i *InventoryController) Variance(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	openingCountId, err := queryInt(r, "openingCountId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	closingCountId, err := queryInt(r, "closingCountId")
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	variance, err := i.Inventory.Variance(r.Context(), orgId, openingCountId, closingCountId)
+
+	if err != nil {
+		i.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, i.Inventory.Logger, http.StatusOK, variance)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Writes data as the json body of the response. The status is already sent
+// when encoding fails so the error can only be logged
+func writeJSON(w http.ResponseWriter, logger *slog.Logger, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Error("Error writing response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, logger *slog.Logger, status int, message string) {
+	writeJSON(w, logger, status, ErrorResponse{Error: message})
+}
+
+func readJSON(r *http.Request, data any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(data); err != nil {
+		return fmt.Errorf("Invalid request body: %w", err)
+	}
+
+	return nil
+}
+
+type errorStatus struct {
+	err    error
+	status int
+}
+
+// Maps errors returned by services to response statuses. The first matching
+// error wins so wrapped errors can be listed before the errors they wrap
+type errorStatuses []errorStatus
+
+// Writes the status mapped to err. Errors without a mapped status are logged
+// and written as internal server errors so their details are not leaked
+func (e errorStatuses) write(w http.ResponseWriter, logger *slog.Logger, err error) {
+	for _, mapped := range e {
+		if errors.Is(err, mapped.err) {
+			writeError(w, logger, mapped.status, err.Error())
+			return
+		}
+	}
+
+	logger.Error("Unhandled error", "error", err)
+	writeError(w, logger, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+var (
+	InvalidPathParamError  = errors.New("Invalid path parameter")
+	InvalidQueryParamError = errors.New("Invalid query parameter")
+)
+
+func pathInt(r *http.Request, name string) (int, error) {
+	value := r.PathValue(name)
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w %s: %s", InvalidPathParamError, name, value)
+	}
+
+	return parsed, nil
+}
+
+// Returns 0 when the query param is not set
+func queryInt(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w %s: %s", InvalidQueryParamError, name, value)
+	}
+
+	return parsed, nil
+}
+
+// Reads an RFC 3339 timestamp or a YYYY-MM-DD date from a query param. Dates
+// are read as the end of that day in UTC so the whole day is included. Returns
+// the zero time when the query param is not set
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %s: %s", InvalidQueryParamError, name, value)
+	}
+
+	return parsed.AddDate(0, 0, 1).Add(-time.Millisecond), nil
+}
+
+// Reads a query param like queryTime but reads dates as the start of that day,
+// for the lower bound of a range
+func queryStartTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+
+	return queryTime(r, name)
+}
+
+// Returns false when the query param is not set
+func queryBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+
+	if err != nil {
+		return false, fmt.Errorf("%w %s: %s", InvalidQueryParamError, name, value)
+	}
+
+	return parsed, nil
+}
+
+// Reads a comma separated list of ids, like tagIds=1,2,3. Returns nil when the
+// query param is not set
+func queryInts(r *http.Request, name string) ([]int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []int
+
+	for _, part := range strings.Split(value, ",") {
+		parsed, err := strconv.Atoi(strings.TrimSpace(part))
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %s", InvalidQueryParamError, name, value)
+		}
+
+		ids = append(ids, parsed)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"moon-cost/router"
+	"moon-cost/services/auth"
+	"moon-cost/services/organization"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	MissingCredentialsError = errors.New("Authentication required")
+	InsufficientRoleError   = errors.New("Insufficient organization role")
+)
+
+// Authenticator resolves a session token into the identity it belongs to.
+// auth.Service implements it
+type Authenticator interface {
+	Authenticate(context.Context, string) (auth.Identity, error)
+}
+
+var authErrors = errorStatuses{
+	{MissingCredentialsError, http.StatusUnauthorized},
+	{auth.InvalidSessionError, http.StatusUnauthorized},
+}
+
+// RequireAuth rejects requests without a valid session with a 401. The token
+// is read from an Authorization bearer header or the session cookie and the
+// resolved identity is available to handlers through auth.IdentityFromContext
+func RequireAuth(authenticator Authenticator, logger *slog.Logger) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := requestToken(r)
+
+			if token == "" {
+				authErrors.write(w, logger, MissingCredentialsError)
+				return
+			}
+
+			identity, err := authenticator.Authenticate(r.Context(), token)
+
+			if err != nil {
+				authErrors.write(w, logger, err)
+				return
+			}
+
+			ctx := auth.WithIdentity(r.Context(), identity)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+
+		return ""
+	}
+
+	cookie, err := r.Cookie(SessionCookieName)
+
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// identityUserId returns the id of the authenticated user of the request
+func identityUserId(r *http.Request) (int, error) {
+	user, ok := auth.UserFromContext(r.Context())
+
+	if !ok {
+		return 0, MissingCredentialsError
+	}
+
+	userId, err := strconv.Atoi(user.Id)
+
+	if err != nil {
+		return 0, MissingCredentialsError
+	}
+
+	return userId, nil
+}
+
+// MemberResolver looks up a user's membership in an organization.
+// organization.Service implements it
+type MemberResolver interface {
+	GetMember(ctx context.Context, organizationId int, userId int) (organization.Member, error)
+}
+
+var roleErrors = errorStatuses{
+	{MissingCredentialsError, http.StatusUnauthorized},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{organization.OrganizationNotFoundError, http.StatusNotFound},
+	{InsufficientRoleError, http.StatusForbidden},
+}
+
+// RequireRole rejects requests from users without at least role in the
+// organization identified by the {orgId} path param. It must run after
+// RequireAuth. Users who are not members get a 404 so the existence of other
+// organizations is not revealed
+func RequireRole(members MemberResolver, role organization.Role, logger *slog.Logger) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, err := identityUserId(r)
+
+			if err != nil {
+				roleErrors.write(w, logger, err)
+				return
+			}
+
+			orgId, err := pathInt(r, "orgId")
+
+			if err != nil {
+				roleErrors.write(w, logger, err)
+				return
+			}
+
+			member, err := members.GetMember(r.Context(), orgId, userId)
+
+			if errors.Is(err, organization.MemberNotFoundError) {
+				roleErrors.write(w, logger, organization.OrganizationNotFoundError)
+				return
+			}
+
+			if err != nil {
+				roleErrors.write(w, logger, err)
+				return
+			}
+
+			if !member.Role.Allows(role) {
+				roleErrors.write(w, logger, InsufficientRoleError)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"moon-cost/services/auth"
+	"moon-cost/services/organization"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testAuthenticator map[string]auth.Identity
+
+func (t testAuthenticator) Authenticate(ctx context.Context, token string) (auth.Identity, error) {
+	identity, ok := t[token]
+
+	if !ok {
+		return identity, auth.InvalidSessionError
+	}
+
+	return identity, nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	authenticator := testAuthenticator{
+		"token": {Account: auth.Account{Id: "1", Email: "test@test.com"}},
+	}
+
+	api := newTestAPI()
+	api.Private = append(api.Private, RequireAuth(authenticator, api.Logger))
+
+	api.PrivateRoute("/private").Get("", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := auth.AccountFromContext(r.Context())
+
+		if !ok {
+			t.Error("auth.AccountFromContext() = _, false. want true")
+		}
+
+		writeJSON(w, api.Logger, http.StatusOK, account)
+	})
+
+	tests := []struct {
+		test   string
+		header string
+		cookie string
+		status int
+		error  string
+	}{
+		{test: "bearer token", header: "Bearer token", status: http.StatusOK},
+		{test: "session cookie", cookie: "token", status: http.StatusOK},
+		{test: "missing credentials", status: http.StatusUnauthorized, error: MissingCredentialsError.Error()},
+		{test: "invalid bearer token", header: "Bearer nope", status: http.StatusUnauthorized, error: auth.InvalidSessionError.Error()},
+		{test: "invalid cookie", cookie: "nope", status: http.StatusUnauthorized, error: auth.InvalidSessionError.Error()},
+		{test: "unsupported scheme", header: "Basic token", status: http.StatusUnauthorized, error: MissingCredentialsError.Error()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/private", nil)
+
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: test.cookie})
+			}
+
+			rec := httptest.NewRecorder()
+			api.Server.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Fatalf("status = %d. want %d", rec.Code, test.status)
+			}
+
+			if test.error == "" {
+				return
+			}
+
+			var body ErrorResponse
+
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Error != test.error {
+				t.Errorf("body.Error = %s. want %s", body.Error, test.error)
+			}
+		})
+	}
+}
+
+// members keyed by orgId then userId
+type testMembers map[int]map[int]organization.Role
+
+func (t testMembers) GetMember(ctx context.Context, orgId int, userId int) (organization.Member, error) {
+	role, ok := t[orgId][userId]
+
+	if !ok {
+		return organization.Member{}, organization.MemberNotFoundError
+	}
+
+	return organization.Member{OrganizationId: orgId, UserId: userId, Role: role}, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	authenticator := testAuthenticator{
+		"owner":   {User: auth.User{Id: "1"}},
+		"viewer":  {User: auth.User{Id: "2"}},
+		"invalid": {User: auth.User{Id: "nope"}},
+	}
+
+	api := newTestAPI()
+	api.Private = append(api.Private, RequireAuth(authenticator, api.Logger))
+	api.Members = testMembers{
+		1: {1: organization.RoleOwner, 2: organization.RoleViewer},
+	}
+
+	route := api.PrivateRoute("/orgs/{orgId}")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	route.With(api.RequireRole(organization.RoleViewer)).Get("", handler)
+	route.With(api.RequireRole(organization.RoleManager)).Put("", handler)
+
+	tests := []struct {
+		test   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{test: "owner can read", method: http.MethodGet, path: "/orgs/1", token: "owner", status: http.StatusNoContent},
+		{test: "owner can write", method: http.MethodPut, path: "/orgs/1", token: "owner", status: http.StatusNoContent},
+		{test: "viewer can read", method: http.MethodGet, path: "/orgs/1", token: "viewer", status: http.StatusNoContent},
+		{test: "viewer cannot write", method: http.MethodPut, path: "/orgs/1", token: "viewer", status: http.StatusForbidden},
+		{test: "non member", method: http.MethodGet, path: "/orgs/2", token: "owner", status: http.StatusNotFound},
+		{test: "invalid org id", method: http.MethodGet, path: "/orgs/abc", token: "owner", status: http.StatusBadRequest},
+		{test: "invalid user id", method: http.MethodGet, path: "/orgs/1", token: "invalid", status: http.StatusUnauthorized},
+		{test: "unauthenticated", method: http.MethodGet, path: "/orgs/1", status: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			rec := httptest.NewRecorder()
+			api.Server.Mux.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Errorf("status = %d. want %d", rec.Code, test.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,412 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"net/http"
+)
+
+type OrganizationController struct {
+	Route         *router.Route
+	Organizations *organization.Service
+}
+
+var organizationErrors = errorStatuses{
+	{organization.OrganizationNotFoundError, http.StatusNotFound},
+	{organization.LocationNotFoundError, http.StatusNotFound},
+	{organization.InvalidOrganizationError, http.StatusBadRequest},
+	{organization.MemberNotFoundError, http.StatusNotFound},
+	{organization.MemberAccountNotFoundError, http.StatusNotFound},
+	{organization.MemberExistsError, http.StatusConflict},
+	{organization.LastOwnerError, http.StatusConflict},
+	{organization.LocationInUseError, http.StatusConflict},
+	{organization.InvalidMemberError, http.StatusBadRequest},
+	{MissingCredentialsError, http.StatusUnauthorized},
+	{InvalidPathParamError, http.StatusBadRequest},
+}
+
+func (o *OrganizationController) Init(api *API) {
+	o.Route = api.PrivateRoute("/orgs")
+
+	o.Route.Get("", o.List)
+	o.Route.Post("", o.Create)
+
+	viewer := o.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := o.Route.With(api.RequireRole(organization.RoleManager))
+	owner := o.Route.With(api.RequireRole(organization.RoleOwner))
+
+	viewer.Get("/{orgId}", o.Get)
+	owner.Put("/{orgId}", o.Update)
+	owner.Delete("/{orgId}", o.Delete)
+
+	viewer.Get("/{orgId}/locations", o.ListLocations)
+	manager.Post("/{orgId}/locations", o.CreateLocation)
+	viewer.Get("/{orgId}/locations/{locationId}", o.GetLocation)
+	manager.Put("/{orgId}/locations/{locationId}", o.UpdateLocation)
+	manager.Delete("/{orgId}/locations/{locationId}", o.DeleteLocation)
+
+	viewer.Get("/{orgId}/members", o.ListMembers)
+	owner.Post("/{orgId}/members", o.InviteMember)
+	owner.Put("/{orgId}/members/{userId}", o.UpdateMember)
+	owner.Delete("/{orgId}/members/{userId}", o.RemoveMember)
+}
+
+func (o *OrganizationController) writeError(w http.ResponseWriter, err error) {
+	organizationErrors.write(w, o.Organizations.Logger, err)
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+func (o *OrganizationController) List(w http.ResponseWriter, r *http.Request) {
+	userId, err := identityUserId(r)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	organizations, err := o.Organizations.ListOrganizations(r.Context(), userId)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, organizations)
+}
+
+func (o *OrganizationController) Create(w http.ResponseWriter, r *http.Request) {
+	userId, err := identityUserId(r)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input OrganizationRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := o.Organizations.CreateOrganization(r.Context(), userId, organization.OrganizationInput{
+		Name: input.Name,
+	})
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusCreated, created)
+}
+
+func (o *OrganizationController) Get(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	org, err := o.Organizations.GetOrganization(r.Context(), orgId)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, org)
+}
+
+func (o *OrganizationController) Update(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input OrganizationRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := o.Organizations.UpdateOrganization(r.Context(), orgId, organization.OrganizationInput{
+		Name: input.Name,
+	})
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, updated)
+}
+
+func (o *OrganizationController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	if err := o.Organizations.DeleteOrganization(r.Context(), orgId); err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type LocationRequest struct {
+	Address string `json:"address"`
+	City    string `json:"city"`
+	State   string `json:"state"`
+}
+
+func (l LocationRequest) input() organization.LocationInput {
+	return organization.LocationInput{
+		Address: l.Address,
+		City:    l.City,
+		State:   l.State,
+	}
+}
+
+func (o *OrganizationController) ListLocations(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	locations, err := o.Organizations.ListLocations(r.Context(), orgId)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, locations)
+}
+
+func (o *OrganizationController) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input LocationRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	location, err := o.Organizations.CreateLocation(r.Context(), orgId, input.input())
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusCreated, location)
+}
+
+func (o *OrganizationController) GetLocation(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	locationId, err := pathInt(r, "locationId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	location, err := o.Organizations.GetLocation(r.Context(), orgId, locationId)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, location)
+}
+
+func (o *OrganizationController) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	locationId, err := pathInt(r, "locationId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input LocationRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	location, err := o.Organizations.UpdateLocation(r.Context(), orgId, locationId, input.input())
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, location)
+}
+
+func (o *OrganizationController) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	locationId, err := pathInt(r, "locationId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	if err := o.Organizations.DeleteLocation(r.Context(), orgId, locationId); err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Returns the orgId and userId path params
+func (o *OrganizationController) memberParams(r *http.Request) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userId, err := pathInt(r, "userId")
+
+	return orgId, userId, err
+}
+
+type MemberRequest struct {
+	Email string            `json:"email"`
+	Role  organization.Role `json:"role"`
+}
+
+type MemberRoleRequest struct {
+	Role organization.Role `json:"role"`
+}
+
+func (o *OrganizationController) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	members, err := o.Organizations.ListMembers(r.Context(), orgId)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, members)
+}
+
+func (o *OrganizationController) InviteMember(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input MemberRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	member, err := o.Organizations.InviteMember(r.Context(), orgId, organization.MemberInput{
+		Email: input.Email,
+		Role:  input.Role,
+	})
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusCreated, member)
+}
+
+func (o *OrganizationController) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	orgId, userId, err := o.memberParams(r)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	var input MemberRoleRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, o.Organizations.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	member, err := o.Organizations.UpdateMemberRole(r.Context(), orgId, userId, input.Role)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, o.Organizations.Logger, http.StatusOK, member)
+}
+
+func (o *OrganizationController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgId, userId, err := o.memberParams(r)
+
+	if err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	if err := o.Organizations.RemoveMember(r.Context(), orgId, userId); err != nil {
+		o.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
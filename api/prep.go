@@ -0,0 +1,327 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/units"
+	"net/http"
+)
+
+type PrepController struct {
+	Route    *router.Route
+	Products *product.Service
+}
+
+var prepErrors = errorStatuses{
+	{product.PrepRecipeNotFoundError, http.StatusNotFound},
+	{product.PrepIngredientNotFoundError, http.StatusNotFound},
+	{ingredient.IngredientNotFoundError, http.StatusNotFound},
+	{product.PrepRecipeInUseError, http.StatusConflict},
+	{product.PrepRecipeCycleError, http.StatusUnprocessableEntity},
+	{product.InvalidPrepRecipeError, http.StatusBadRequest},
+	{product.InvalidPrepIngredientError, http.StatusBadRequest},
+	{units.UnknownUnitError, http.StatusUnprocessableEntity},
+	{units.IncompatibleUnitError, http.StatusUnprocessableEntity},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (p *PrepController) Init(api *API) {
+	p.Route = api.PrivateRoute("/orgs/{orgId}/preps")
+
+	viewer := p.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := p.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", p.List)
+	manager.Post("", p.Create)
+	viewer.Get("/{prepId}", p.Get)
+	manager.Put("/{prepId}", p.Update)
+	manager.Delete("/{prepId}", p.Delete)
+	viewer.Get("/{prepId}/cost", p.Cost)
+
+	viewer.Get("/{prepId}/ingredients", p.ListIngredients)
+	manager.Post("/{prepId}/ingredients", p.AddIngredient)
+	manager.Put("/{prepId}/ingredients/{prepIngredientId}", p.UpdateIngredient)
+	manager.Delete("/{prepId}/ingredients/{prepIngredientId}", p.RemoveIngredient)
+}
+
+func (p *PrepController) writeError(w http.ResponseWriter, err error) {
+	prepErrors.write(w, p.Products.Logger, err)
+}
+
+// Returns the orgId and prepId path params
+func (p *PrepController) prepParams(r *http.Request) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	prepId, err := pathInt(r, "prepId")
+
+	return orgId, prepId, err
+}
+
+// A batch of the prep recipe yields yieldAmount of yieldUnit
+type PrepRecipeRequest struct {
+	Name        string  `json:"name"`
+	YieldAmount float64 `json:"yieldAmount"`
+	YieldUnit   string  `json:"yieldUnit"`
+}
+
+func (p PrepRecipeRequest) input() product.PrepRecipeInput {
+	return product.PrepRecipeInput{
+		Name:        p.Name,
+		YieldAmount: p.YieldAmount,
+		YieldUnit:   p.YieldUnit,
+	}
+}
+
+func (p *PrepController) List(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	recipes, err := p.Products.ListPrepRecipes(r.Context(), orgId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, recipes)
+}
+
+func (p *PrepController) Create(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input PrepRecipeRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := p.Products.CreatePrepRecipe(r.Context(), orgId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusCreated, created)
+}
+
+func (p *PrepController) Get(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	found, err := p.Products.GetPrepRecipe(r.Context(), orgId, prepId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, found)
+}
+
+func (p *PrepController) Update(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input PrepRecipeRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := p.Products.UpdatePrepRecipe(r.Context(), orgId, prepId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, updated)
+}
+
+func (p *PrepController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	if err := p.Products.DeletePrepRecipe(r.Context(), orgId, prepId); err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Costs a batch of the prep recipe with current prices or the prices in
+// effect at the asOf query param
+func (p *PrepController) Cost(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	asOf, err := queryTime(r, "asOf")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	cost, err := p.Products.PrepCost(r.Context(), orgId, prepId, asOf)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, cost)
+}
+
+// Only one of ingredientId and prepRecipeId may be set. Unit defaults to the
+// unit the ingredient is purchased in or the prep recipe yields
+type PrepIngredientRequest struct {
+	IngredientId int     `json:"ingredientId"`
+	PrepRecipeId int     `json:"prepRecipeId"`
+	Amount       float64 `json:"amount"`
+	Unit         string  `json:"unit"`
+	WastePercent float64 `json:"wastePercent"`
+}
+
+func (p PrepIngredientRequest) input() product.PrepIngredientInput {
+	return product.PrepIngredientInput{
+		IngredientId: p.IngredientId,
+		PrepRecipeId: p.PrepRecipeId,
+		Amount:       p.Amount,
+		Unit:         p.Unit,
+		WastePercent: p.WastePercent,
+	}
+}
+
+func (p *PrepController) ListIngredients(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	ingredients, err := p.Products.ListPrepIngredients(r.Context(), orgId, prepId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, ingredients)
+}
+
+func (p *PrepController) AddIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input PrepIngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	added, err := p.Products.AddPrepIngredient(r.Context(), orgId, prepId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusCreated, added)
+}
+
+func (p *PrepController) UpdateIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	prepIngredientId, err := pathInt(r, "prepIngredientId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input PrepIngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := p.Products.UpdatePrepIngredient(r.Context(), orgId, prepId, prepIngredientId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, updated)
+}
+
+func (p *PrepController) RemoveIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, prepId, err := p.prepParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	prepIngredientId, err := pathInt(r, "prepIngredientId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	if err := p.Products.RemovePrepIngredient(r.Context(), orgId, prepId, prepIngredientId); err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
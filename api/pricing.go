@@ -0,0 +1,153 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/services/pricing"
+	"net/http"
+)
+
+type PricingController struct {
+	Route   *router.Route
+	Pricing *pricing.Service
+}
+
+var pricingErrors = errorStatuses{
+	{pricing.TargetNotFoundError, http.StatusNotFound},
+	{pricing.InvalidTargetError, http.StatusBadRequest},
+	{pricing.InvalidRoundingError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (p *PricingController) Init(api *API) {
+	p.Route = api.PrivateRoute("/orgs/{orgId}/pricing")
+
+	viewer := p.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := p.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("/targets", p.ListTargets)
+	manager.Put("/targets", p.SetTarget)
+	manager.Delete("/targets", p.DeleteTarget)
+	viewer.Get("/suggestions", p.Suggestions)
+}
+
+func (p *PricingController) writeError(w http.ResponseWriter, err error) {
+	pricingErrors.write(w, p.Pricing.Logger, err)
+}
+
+// A blank category sets the default target of the organization
+type TargetRequest struct {
+	Category         string  `json:"category"`
+	TargetPercent    float64 `json:"targetPercent"`
+	TolerancePercent float64 `json:"tolerancePercent"`
+}
+
+func (t TargetRequest) input() pricing.TargetInput {
+	return pricing.TargetInput{
+		Category:         t.Category,
+		TargetPercent:    t.TargetPercent,
+		TolerancePercent: t.TolerancePercent,
+	}
+}
+
+func (p *PricingController) ListTargets(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	targets, err := p.Pricing.ListTargets(r.Context(), orgId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Pricing.Logger, http.StatusOK, targets)
+}
+
+func (p *PricingController) SetTarget(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input TargetRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Pricing.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	target, err := p.Pricing.SetTarget(r.Context(), orgId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Pricing.Logger, http.StatusOK, target)
+}
+
+// Deletes the target of the category query param, or the default target when
+// it is not set
+func (p *PricingController) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	if err := p.Pricing.DeleteTarget(r.Context(), orgId, r.URL.Query().Get("category")); err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Suggests menu prices for every product. Query params are rounding (none,
+// charm or quarter), tagIds and outsideOnly
+func (p *PricingController) Suggestions(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	tagIds, err := queryInts(r, "tagIds")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	outsideOnly, err := queryBool(r, "outsideOnly")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	input := pricing.SuggestionInput{
+		Rounding:    pricing.Rounding(r.URL.Query().Get("rounding")),
+		TagIds:      tagIds,
+		OutsideOnly: outsideOnly,
+	}
+
+	suggestions, err := p.Pricing.Suggestions(r.Context(), orgId, input)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Pricing.Logger, http.StatusOK, suggestions)
+}
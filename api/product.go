@@ -0,0 +1,440 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/services/tag"
+	"moon-cost/units"
+	"net/http"
+)
+
+type ProductController struct {
+	Route    *router.Route
+	Products *product.Service
+}
+
+var productErrors = errorStatuses{
+	{product.ProductNotFoundError, http.StatusNotFound},
+	{product.ProductIngredientNotFoundError, http.StatusNotFound},
+	{product.PrepRecipeNotFoundError, http.StatusNotFound},
+	{ingredient.IngredientNotFoundError, http.StatusNotFound},
+	{organization.LocationNotFoundError, http.StatusNotFound},
+	{tag.TagNotFoundError, http.StatusNotFound},
+	{product.ProductInUseError, http.StatusConflict},
+	{product.PrepRecipeCycleError, http.StatusUnprocessableEntity},
+	{product.InvalidProductError, http.StatusBadRequest},
+	{product.InvalidProductIngredientError, http.StatusBadRequest},
+	{units.UnknownUnitError, http.StatusUnprocessableEntity},
+	{units.IncompatibleUnitError, http.StatusUnprocessableEntity},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (p *ProductController) Init(api *API) {
+	p.Route = api.PrivateRoute("/orgs/{orgId}/products")
+
+	viewer := p.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := p.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", p.List)
+	manager.Post("", p.Create)
+	viewer.Get("/{productId}", p.Get)
+	manager.Put("/{productId}", p.Update)
+	manager.Delete("/{productId}", p.Delete)
+	viewer.Get("/{productId}/cost", p.Cost)
+	viewer.Get("/{productId}/locations", p.ListLocations)
+	manager.Put("/{productId}/locations", p.SetLocations)
+	viewer.Get("/{productId}/tags", p.ListTags)
+	manager.Put("/{productId}/tags", p.SetTags)
+
+	viewer.Get("/{productId}/ingredients", p.ListIngredients)
+	manager.Post("/{productId}/ingredients", p.AddIngredient)
+	manager.Put("/{productId}/ingredients/{productIngredientId}", p.UpdateIngredient)
+	manager.Delete("/{productId}/ingredients/{productIngredientId}", p.RemoveIngredient)
+}
+
+func (p *ProductController) writeError(w http.ResponseWriter, err error) {
+	productErrors.write(w, p.Products.Logger, err)
+}
+
+// Returns the orgId and productId path params
+func (p *ProductController) productParams(r *http.Request) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productId, err := pathInt(r, "productId")
+
+	return orgId, productId, err
+}
+
+// MenuPrice is in cents
+type ProductRequest struct {
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	MenuPrice int64  `json:"menuPrice"`
+	Servings  int    `json:"servings"`
+}
+
+func (p ProductRequest) input() product.ProductInput {
+	return product.ProductInput{
+		Name:      p.Name,
+		Category:  p.Category,
+		MenuPrice: p.MenuPrice,
+		Servings:  p.Servings,
+	}
+}
+
+func (p *ProductController) List(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	tagIds, err := queryInts(r, "tagIds")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	products, err := p.Products.ListProducts(r.Context(), orgId, product.Filter{TagIds: tagIds})
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, products)
+}
+
+func (p *ProductController) Create(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input ProductRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := p.Products.CreateProduct(r.Context(), orgId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusCreated, created)
+}
+
+func (p *ProductController) Get(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	found, err := p.Products.GetProduct(r.Context(), orgId, productId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, found)
+}
+
+func (p *ProductController) Update(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input ProductRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := p.Products.UpdateProduct(r.Context(), orgId, productId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, updated)
+}
+
+func (p *ProductController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	if err := p.Products.DeleteProduct(r.Context(), orgId, productId); err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Costs the product with current prices or the prices in effect at the asOf
+// query param
+func (p *ProductController) Cost(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	asOf, err := queryTime(r, "asOf")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	breakdown, err := p.Products.CostAsOf(r.Context(), orgId, productId, asOf)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, breakdown)
+}
+
+type ProductLocationsRequest struct {
+	LocationIds []int `json:"locationIds"`
+}
+
+type ProductLocationsResponse struct {
+	LocationIds []int `json:"locationIds"`
+}
+
+func (p *ProductController) ListLocations(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	locationIds, err := p.Products.ListProductLocations(r.Context(), orgId, productId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, ProductLocationsResponse{LocationIds: locationIds})
+}
+
+// Replaces the locations that sell the product
+func (p *ProductController) SetLocations(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input ProductLocationsRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	locationIds, err := p.Products.SetProductLocations(r.Context(), orgId, productId, input.LocationIds)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, ProductLocationsResponse{LocationIds: locationIds})
+}
+
+func (p *ProductController) ListTags(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	tagIds, err := p.Products.ListProductTags(r.Context(), orgId, productId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, TagIdsResponse{TagIds: tagIds})
+}
+
+// Replaces the tags of the product
+func (p *ProductController) SetTags(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input TagIdsRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tagIds, err := p.Products.SetProductTags(r.Context(), orgId, productId, input.TagIds)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, TagIdsResponse{TagIds: tagIds})
+}
+
+// Only one of ingredientId and prepRecipeId may be set. Unit defaults to the
+// unit the ingredient is purchased in or the prep recipe yields
+type ProductIngredientRequest struct {
+	IngredientId int     `json:"ingredientId"`
+	PrepRecipeId int     `json:"prepRecipeId"`
+	Amount       float64 `json:"amount"`
+	Unit         string  `json:"unit"`
+	WastePercent float64 `json:"wastePercent"`
+}
+
+func (p ProductIngredientRequest) input() product.ProductIngredientInput {
+	return product.ProductIngredientInput{
+		IngredientId: p.IngredientId,
+		PrepRecipeId: p.PrepRecipeId,
+		Amount:       p.Amount,
+		Unit:         p.Unit,
+		WastePercent: p.WastePercent,
+	}
+}
+
+func (p *ProductController) ListIngredients(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	ingredients, err := p.Products.ListProductIngredients(r.Context(), orgId, productId)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, ingredients)
+}
+
+func (p *ProductController) AddIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input ProductIngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	added, err := p.Products.AddProductIngredient(r.Context(), orgId, productId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusCreated, added)
+}
+
+func (p *ProductController) UpdateIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	productIngredientId, err := pathInt(r, "productIngredientId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	var input ProductIngredientRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, p.Products.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := p.Products.UpdateProductIngredient(r.Context(), orgId, productId, productIngredientId, input.input())
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, p.Products.Logger, http.StatusOK, updated)
+}
+
+func (p *ProductController) RemoveIngredient(w http.ResponseWriter, r *http.Request) {
+	orgId, productId, err := p.productParams(r)
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	productIngredientId, err := pathInt(r, "productIngredientId")
+
+	if err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	if err := p.Products.RemoveProductIngredient(r.Context(), orgId, productId, productIngredientId); err != nil {
+		p.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/services/report"
+	"net/http"
+)
+
+type ReportController struct {
+	Route   *router.Route
+	Reports *report.Service
+}
+
+var reportErrors = errorStatuses{
+	{report.InvalidReportError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (c *ReportController) Init(api *API) {
+	c.Route = api.PrivateRoute("/orgs/{orgId}/reports")
+
+	viewer := c.Route.With(api.RequireRole(organization.RoleViewer))
+
+	viewer.Get("/menu", c.Menu)
+}
+
+func (c *ReportController) writeError(w http.ResponseWriter, err error) {
+	reportErrors.write(w, c.Reports.Logger, err)
+}
+
+// Renders the menu profitability report as json, csv or html. Query params
+// are format, groupBy (category, location or tag), asOf and tagIds
+func (c *ReportController) Menu(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	format, err := report.ParseFormat(r.URL.Query().Get("format"))
+
+	if err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	asOf, err := queryTime(r, "asOf")
+
+	if err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	tagIds, err := queryInts(r, "tagIds")
+
+	if err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	input := report.MenuInput{
+		GroupBy: report.GroupBy(r.URL.Query().Get("groupBy")),
+		AsOf:    asOf,
+		TagIds:  tagIds,
+	}
+
+	menu, err := c.Reports.Menu(r.Context(), orgId, input)
+
+	if err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	// rendered before writing so a failure can still be sent as an error
+	var body bytes.Buffer
+
+	if err := report.Render(&body, menu, format); err != nil {
+		c.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+
+	if format == report.FormatCSV {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="menu-report-%d.csv"`, orgId))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/services/tag"
+	"net/http"
+)
+
+type TagController struct {
+	Route *router.Route
+	Tags  *tag.Service
+}
+
+var tagErrors = errorStatuses{
+	{tag.TagNotFoundError, http.StatusNotFound},
+	{tag.TagExistsError, http.StatusConflict},
+	{tag.InvalidTagError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+}
+
+func (t *TagController) Init(api *API) {
+	t.Route = api.PrivateRoute("/orgs/{orgId}/tags")
+
+	viewer := t.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := t.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", t.List)
+	manager.Post("", t.Create)
+	viewer.Get("/{tagId}", t.Get)
+	manager.Put("/{tagId}", t.Update)
+	manager.Delete("/{tagId}", t.Delete)
+}
+
+func (t *TagController) writeError(w http.ResponseWriter, err error) {
+	tagErrors.write(w, t.Tags.Logger, err)
+}
+
+// Returns the orgId and tagId path params
+func (t *TagController) tagParams(r *http.Request) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tagId, err := pathInt(r, "tagId")
+
+	return orgId, tagId, err
+}
+
+// Type is ingredient or product and is ignored when updating a tag
+type TagRequest struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (t TagRequest) input() tag.TagInput {
+	return tag.TagInput{
+		Name: t.Name,
+		Type: tag.Type(t.Type),
+	}
+}
+
+// Assigns tags to an ingredient or product
+type TagIdsRequest struct {
+	TagIds []int `json:"tagIds"`
+}
+
+type TagIdsResponse struct {
+	TagIds []int `json:"tagIds"`
+}
+
+// Lists every tag of the organization or only the tags of the type query param
+func (t *TagController) List(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	filter := tag.Filter{Type: tag.Type(r.URL.Query().Get("type"))}
+
+	tags, err := t.Tags.ListTags(r.Context(), orgId, filter)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, t.Tags.Logger, http.StatusOK, tags)
+}
+
+func (t *TagController) Create(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	var input TagRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, t.Tags.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := t.Tags.CreateTag(r.Context(), orgId, input.input())
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, t.Tags.Logger, http.StatusCreated, created)
+}
+
+func (t *TagController) Get(w http.ResponseWriter, r *http.Request) {
+	orgId, tagId, err := t.tagParams(r)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	found, err := t.Tags.GetTag(r.Context(), orgId, tagId)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, t.Tags.Logger, http.StatusOK, found)
+}
+
+func (t *TagController) Update(w http.ResponseWriter, r *http.Request) {
+	orgId, tagId, err := t.tagParams(r)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	var input TagRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, t.Tags.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := t.Tags.UpdateTag(r.Context(), orgId, tagId, input.Name)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, t.Tags.Logger, http.StatusOK, updated)
+}
+
+func (t *TagController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, tagId, err := t.tagParams(r)
+
+	if err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	if err := t.Tags.DeleteTag(r.Context(), orgId, tagId); err != nil {
+		t.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
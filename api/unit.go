@@ -0,0 +1,130 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/services/unit"
+	"moon-cost/units"
+	"net/http"
+)
+
+type UnitController struct {
+	Route *router.Route
+	Units *unit.Service
+}
+
+var unitErrors = errorStatuses{
+	{unit.UnitNotFoundError, http.StatusNotFound},
+	{unit.UnitInUseError, http.StatusConflict},
+	{units.InvalidUnitError, http.StatusBadRequest},
+	{units.UnknownUnitError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+}
+
+func (u *UnitController) Init(api *API) {
+	u.Route = api.PrivateRoute("/orgs/{orgId}/units")
+
+	viewer := u.Route.With(api.RequireRole(organization.RoleViewer))
+	manager := u.Route.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", u.List)
+	manager.Post("", u.Create)
+	manager.Delete("/{unitId}", u.Delete)
+}
+
+func (u *UnitController) writeError(w http.ResponseWriter, err error) {
+	unitErrors.write(w, u.Units.Logger, err)
+}
+
+// Available lists every unit the organization can use, including the units it
+// has defined. Defined only lists the units it has defined
+type UnitsResponse struct {
+	Available []units.Unit `json:"available"`
+	Defined   []unit.Unit  `json:"defined"`
+}
+
+func (u *UnitController) List(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	registry, err := u.Units.Registry(r.Context(), orgId)
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	defined, err := u.Units.ListUnits(r.Context(), orgId)
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, u.Units.Logger, http.StatusOK, UnitsResponse{
+		Available: registry.Units(),
+		Defined:   defined,
+	})
+}
+
+type UnitRequest struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+func (u *UnitController) Create(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	var input UnitRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, u.Units.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := u.Units.CreateUnit(r.Context(), orgId, unit.UnitInput{
+		Name:   input.Name,
+		Amount: input.Amount,
+		Unit:   input.Unit,
+	})
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, u.Units.Logger, http.StatusCreated, created)
+}
+
+func (u *UnitController) Delete(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	unitId, err := pathInt(r, "unitId")
+
+	if err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	if err := u.Units.DeleteUnit(r.Context(), orgId, unitId); err != nil {
+		u.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"moon-cost/router"
+	"moon-cost/services/organization"
+	"moon-cost/services/vendors"
+	"net/http"
+)
+
+// VendorController serves the vendors and brands shared by every organization
+// along with each organization's vendor accounts. Shared vendors and brands
+// are only created by an organization's managers and can not be edited or
+// deleted since other organizations may reference them
+type VendorController struct {
+	Route   *router.Route
+	Vendors *vendors.Service
+}
+
+var vendorErrors = errorStatuses{
+	{vendors.VendorNotFoundError, http.StatusNotFound},
+	{vendors.BrandNotFoundError, http.StatusNotFound},
+	{vendors.OrganizationVendorNotFoundError, http.StatusNotFound},
+	{vendors.VendorExistsError, http.StatusConflict},
+	{vendors.BrandExistsError, http.StatusConflict},
+	{vendors.InvalidVendorError, http.StatusBadRequest},
+	{vendors.InvalidBrandError, http.StatusBadRequest},
+	{InvalidPathParamError, http.StatusBadRequest},
+	{InvalidQueryParamError, http.StatusBadRequest},
+}
+
+func (v *VendorController) Init(api *API) {
+	v.Route = api.PrivateRoute("/vendors")
+
+	v.Route.Get("", v.List)
+	v.Route.Get("/{vendorId}", v.Get)
+
+	brands := api.PrivateRoute("/brands")
+
+	brands.Get("", v.ListBrands)
+	brands.Get("/{brandId}", v.GetBrand)
+
+	orgBrands := api.PrivateRoute("/orgs/{orgId}/brands")
+	orgBrands.With(api.RequireRole(organization.RoleManager)).Post("", v.CreateBrand)
+
+	orgVendors := api.PrivateRoute("/orgs/{orgId}/vendors")
+	viewer := orgVendors.With(api.RequireRole(organization.RoleViewer))
+	manager := orgVendors.With(api.RequireRole(organization.RoleManager))
+
+	viewer.Get("", v.ListOrganizationVendors)
+	manager.Post("", v.CreateOrganizationVendor)
+	viewer.Get("/spend", v.Spend)
+	viewer.Get("/{vendorId}", v.GetOrganizationVendor)
+	manager.Put("/{vendorId}", v.SaveOrganizationVendor)
+	manager.Delete("/{vendorId}", v.RemoveOrganizationVendor)
+}
+
+func (v *VendorController) writeError(w http.ResponseWriter, err error) {
+	vendorErrors.write(w, v.Vendors.Logger, err)
+}
+
+type VendorRequest struct {
+	Name    string `json:"name"`
+	Website string `json:"website"`
+}
+
+func (v VendorRequest) input() vendors.VendorInput {
+	return vendors.VendorInput{
+		Name:    v.Name,
+		Website: v.Website,
+	}
+}
+
+func (v *VendorController) List(w http.ResponseWriter, r *http.Request) {
+	list, err := v.Vendors.ListVendors(r.Context())
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, list)
+}
+
+func (v *VendorController) Get(w http.ResponseWriter, r *http.Request) {
+	vendorId, err := pathInt(r, "vendorId")
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	vendor, err := v.Vendors.GetVendor(r.Context(), vendorId)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, vendor)
+}
+
+type BrandRequest struct {
+	Name string `json:"name"`
+}
+
+func (v *VendorController) ListBrands(w http.ResponseWriter, r *http.Request) {
+	brands, err := v.Vendors.ListBrands(r.Context())
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, brands)
+}
+
+// Creates a shared brand. It is routed under an organization so only its
+// managers can add brands every organization sees
+func (v *VendorController) CreateBrand(w http.ResponseWriter, r *http.Request) {
+	var input BrandRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, v.Vendors.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	brand, err := v.Vendors.CreateBrand(r.Context(), vendors.BrandInput{Name: input.Name})
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusCreated, brand)
+}
+
+func (v *VendorController) GetBrand(w http.ResponseWriter, r *http.Request) {
+	brandId, err := pathInt(r, "brandId")
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	brand, err := v.Vendors.GetBrand(r.Context(), brandId)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, brand)
+}
+
+// Returns the orgId and vendorId path params
+func (v *VendorController) vendorParams(r *http.Request) (int, int, error) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	vendorId, err := pathInt(r, "vendorId")
+
+	return orgId, vendorId, err
+}
+
+type OrganizationVendorRequest struct {
+	AccountNumber string   `json:"accountNumber"`
+	ContactName   string   `json:"contactName"`
+	ContactEmail  string   `json:"contactEmail"`
+	ContactPhone  string   `json:"contactPhone"`
+	DeliveryDays  []string `json:"deliveryDays"`
+	Notes         string   `json:"notes"`
+}
+
+func (o OrganizationVendorRequest) input() vendors.OrganizationVendorInput {
+	return vendors.OrganizationVendorInput{
+		AccountNumber: o.AccountNumber,
+		ContactName:   o.ContactName,
+		ContactEmail:  o.ContactEmail,
+		ContactPhone:  o.ContactPhone,
+		DeliveryDays:  o.DeliveryDays,
+		Notes:         o.Notes,
+	}
+}
+
+func (v *VendorController) ListOrganizationVendors(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	list, err := v.Vendors.ListOrganizationVendors(r.Context(), orgId)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, list)
+}
+
+// CreateOrganizationVendorRequest names a new shared vendor along with the
+// organization's account with it
+type CreateOrganizationVendorRequest struct {
+	VendorRequest
+	OrganizationVendorRequest
+}
+
+// Creates a shared vendor and links it to the organization
+func (v *VendorController) CreateOrganizationVendor(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	var input CreateOrganizationVendorRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, v.Vendors.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	vendor, err := v.Vendors.CreateOrganizationVendor(r.Context(), orgId, input.VendorRequest.input(), input.OrganizationVendorRequest.input())
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusCreated, vendor)
+}
+
+func (v *VendorController) GetOrganizationVendor(w http.ResponseWriter, r *http.Request) {
+	orgId, vendorId, err := v.vendorParams(r)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	vendor, err := v.Vendors.GetOrganizationVendor(r.Context(), orgId, vendorId)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, vendor)
+}
+
+// Links the vendor to the organization or updates the existing link
+func (v *VendorController) SaveOrganizationVendor(w http.ResponseWriter, r *http.Request) {
+	orgId, vendorId, err := v.vendorParams(r)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	var input OrganizationVendorRequest
+
+	if err := readJSON(r, &input); err != nil {
+		writeError(w, v.Vendors.Logger, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	vendor, err := v.Vendors.SaveOrganizationVendor(r.Context(), orgId, vendorId, input.input())
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, vendor)
+}
+
+func (v *VendorController) RemoveOrganizationVendor(w http.ResponseWriter, r *http.Request) {
+	orgId, vendorId, err := v.vendorParams(r)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	if err := v.Vendors.RemoveOrganizationVendor(r.Context(), orgId, vendorId); err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Totals what the organization paid each vendor for its recorded purchases.
+// The from and to query params limit the purchases by when they were made
+func (v *VendorController) Spend(w http.ResponseWriter, r *http.Request) {
+	orgId, err := pathInt(r, "orgId")
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	var filter vendors.SpendFilter
+
+	if filter.From, err = queryStartTime(r, "from"); err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	if filter.To, err = queryTime(r, "to"); err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	spend, err := v.Vendors.Spend(r.Context(), orgId, filter)
+
+	if err != nil {
+		v.writeError(w, err)
+		return
+	}
+
+	writeJSON(w, v.Vendors.Logger, http.StatusOK, spend)
+}
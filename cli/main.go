@@ -4,7 +4,9 @@ import (
 	"context"
 	"log"
 	"moon-cost/tools/curl"
+	"moon-cost/tools/importer"
 	"moon-cost/tools/migration"
+	"moon-cost/tools/reporter"
 	"os"
 	"os/signal"
 )
@@ -15,14 +17,22 @@ func run(ctx context.Context) {
 	var curl curl.CurlCLI
 	curl.Out = os.Stdout
 	var migration migration.MigrationCLI
+	migration.Out = os.Stdout
+	var importer importer.ImportCLI
+	importer.Out = os.Stdout
+	var reporter reporter.ReportCLI
+	reporter.Out = os.Stdout
 
 	cli := New()
 	cli.Add("curl", &curl)
 	cli.Add("migration", &migration)
+	cli.Add("import", &importer)
+	cli.Add("report", &reporter)
 
 	args := os.Args[1:]
 
 	if err := cli.Run(ctx, args); err != nil {
+		log.Println(err)
 		os.Exit(1)
 	}
 }
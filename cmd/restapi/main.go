@@ -1,26 +1,101 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"moon-cost/api"
+	"moon-cost/migrations"
 	"moon-cost/services/auth"
-	"net/http"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/inventory"
+	"moon-cost/services/organization"
+	"moon-cost/services/pricing"
+	"moon-cost/services/product"
+	"moon-cost/services/report"
+	"moon-cost/services/tag"
+	"moon-cost/services/unit"
+	"moon-cost/services/vendors"
+	"moon-cost/tools/migration"
 	"os"
+
+	_ "github.com/tursodatabase/go-libsql"
 )
 
-func createAuth() *auth.Service {
-	repo := auth.NoopRepo{}
-	return auth.NewService(&repo)
+func openDB(cfg api.Config) (*sql.DB, error) {
+	db, err := sql.Open("libsql", cfg.DatabaseURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Error connecting to database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Runs the migrations embedded in the binary unless a migrations dir is set
+func runMigrations(ctx context.Context, cfg api.Config, db *sql.DB, logger *slog.Logger) error {
+	manager := migration.Manager{
+		Dir: cfg.MigrationsDir,
+		DB:  db,
+	}
+
+	if cfg.MigrationsDir == "" {
+		manager.FS = migrations.FS
+	}
+
+	manager.Init(migration.WithLogger(logger))
+
+	return manager.Run(ctx)
+}
+
+func createAuth(db *sql.DB, logger *slog.Logger) *auth.Service {
+	repo := auth.NewSQLiteRepo(db)
+	return auth.NewService(repo, logger)
 }
 
 func run() int {
-	cfg := api.Config{
-		Port: 8080,
+	ctx := context.Background()
+	logger := slog.Default()
+
+	cfg, err := api.LoadConfig()
+
+	if err != nil {
+		logger.Error("Error loading config", "error", err)
+		return 1
+	}
+
+	db, err := openDB(cfg)
+
+	if err != nil {
+		logger.Error("Error opening database", "error", err)
+		return 1
+	}
+
+	if cfg.RunMigrations {
+		if err := runMigrations(ctx, cfg, db, logger); err != nil {
+			logger.Error("Error running migrations", "error", err)
+			db.Close()
+			return 1
+		}
 	}
 
 	restApi := api.New(cfg)
+	restApi.Logger = logger
 
-	authSvc := createAuth()
+	restApi.OnShutdown(func(ctx context.Context) error {
+		logger.Info("Closing database")
+		return db.Close()
+	})
+
+	authSvc := createAuth(db, logger)
+
+	restApi.Private = append(restApi.Private, api.RequireAuth(authSvc, logger))
 
 	authController := api.AuthController{
 		Auth: authSvc,
@@ -28,8 +103,84 @@ func run() int {
 
 	authController.Init(restApi)
 
-	if err := http.ListenAndServe(restApi.Port(), restApi.Server.Mux); err != nil {
-		fmt.Printf("ERR")
+	organizationSvc := organization.NewService(organization.NewSQLiteRepo(db), logger)
+	restApi.Members = organizationSvc
+
+	organizationController := api.OrganizationController{
+		Organizations: organizationSvc,
+	}
+
+	organizationController.Init(restApi)
+
+	unitSvc := unit.NewService(unit.NewSQLiteRepo(db), logger)
+
+	ingredientSvc := ingredient.NewService(ingredient.NewSQLiteRepo(db), logger)
+	ingredientSvc.Units = unitSvc
+
+	ingredientController := api.IngredientController{
+		Ingredients: ingredientSvc,
+	}
+
+	ingredientController.Init(restApi)
+
+	vendorController := api.VendorController{
+		Vendors: vendors.NewService(vendors.NewSQLiteRepo(db), logger),
+	}
+
+	vendorController.Init(restApi)
+
+	unitController := api.UnitController{
+		Units: unitSvc,
+	}
+
+	unitController.Init(restApi)
+
+	productSvc := product.NewService(product.NewSQLiteRepo(db), logger)
+	productSvc.Units = unitSvc
+
+	productController := api.ProductController{
+		Products: productSvc,
+	}
+
+	productController.Init(restApi)
+
+	prepController := api.PrepController{
+		Products: productSvc,
+	}
+
+	prepController.Init(restApi)
+
+	tagSvc := tag.NewService(tag.NewSQLiteRepo(db), logger)
+
+	tagController := api.TagController{
+		Tags: tagSvc,
+	}
+
+	tagController.Init(restApi)
+
+	reportController := api.ReportController{
+		Reports: report.NewService(productSvc, organizationSvc, tagSvc, logger),
+	}
+
+	reportController.Init(restApi)
+
+	pricingController := api.PricingController{
+		Pricing: pricing.NewService(pricing.NewSQLiteRepo(db), productSvc, logger),
+	}
+
+	pricingController.Init(restApi)
+
+	inventorySvc := inventory.NewService(inventory.NewSQLiteRepo(db), productSvc, logger)
+	inventorySvc.Units = unitSvc
+
+	inventoryController := api.InventoryController{
+		Inventory: inventorySvc,
+	}
+
+	inventoryController.Init(restApi)
+
+	if err := restApi.Run(ctx); err != nil {
+		logger.Error("Server error", "error", err)
 		return 1
 	}
 
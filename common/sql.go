@@ -0,0 +1,40 @@
+package common
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Scanner is implemented by both *sql.Row and *sql.Rows so a single function
+// can scan a record from either
+type Scanner interface {
+	Scan(dest ...any) error
+}
+
+// Returns notFound when a write statement did not match any rows
+func ExpectAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// Returns the placeholders and args of an IN list of ids, like "?, ?, ?"
+func InList(ids []int) (string, []any) {
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	return strings.Join(placeholders, ", "), args
+}
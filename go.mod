@@ -2,11 +2,15 @@ module moon-cost
 
 go 1.24.1
 
-require github.com/tursodatabase/go-libsql v0.0.0-20241221181756-6121e81fbf92
+require (
+	github.com/tursodatabase/go-libsql v0.0.0-20241221181756-6121e81fbf92
+	golang.org/x/crypto v0.36.0
+)
 
 require (
 	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/libsql/sqlite-antlr4-parser v0.0.0-20240327125255-dbf53b6cbf06 // indirect
 	golang.org/x/exp v0.0.0-20230515195305-f3d0a9c9a5cc // indirect
+	golang.org/x/sys v0.31.0 // indirect
 )
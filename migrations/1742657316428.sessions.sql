@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sessions (
+  id INTEGER PRIMARY KEY,
+
+  token TEXT NOT NULL UNIQUE,
+  createdAt INTEGER NOT NULL,
+  expiresAt INTEGER NOT NULL,
+
+  accountId INTEGER NOT NULL,
+  FOREIGN KEY(accountId) REFERENCES accounts(id)
+);
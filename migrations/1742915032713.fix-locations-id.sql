@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS locations_new (
+  id INTEGER PRIMARY KEY,
+
+  address TEXT,
+  city TEXT,
+  state TEXT,
+
+  organizationId INTEGER NOT NULL,
+  FOREIGN KEY(organizationId) REFERENCES organizations(id)
+);
+
+INSERT INTO locations_new (id, address, city, state, organizationId)
+SELECT id, address, city, state, organizationId FROM locations;
+
+DROP TABLE locations;
+
+ALTER TABLE locations_new RENAME TO locations;
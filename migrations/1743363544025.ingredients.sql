@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS ingredients (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL,
+  brand TEXT,
+  vendor TEXT,
+  category TEXT,
+
+  unit TEXT NOT NULL,
+  unitCount REAL NOT NULL CHECK (unitCount > 0),
+  purchasePrice INTEGER NOT NULL CHECK (purchasePrice >= 0),
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  organizationId INTEGER NOT NULL,
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ingredients_organizationId ON ingredients(organizationId);
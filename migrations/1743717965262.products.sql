@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS products (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL,
+  menuPrice INTEGER NOT NULL CHECK (menuPrice >= 0),
+  servings INTEGER NOT NULL CHECK (servings > 0),
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  organizationId INTEGER NOT NULL,
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS products_organizationId ON products(organizationId);
+
+CREATE TABLE IF NOT EXISTS product_ingredients (
+  id INTEGER PRIMARY KEY,
+
+  amount REAL NOT NULL CHECK (amount > 0),
+
+  productId INTEGER NOT NULL,
+  ingredientId INTEGER NOT NULL,
+  FOREIGN KEY(productId) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS product_ingredients_productId ON product_ingredients(productId);
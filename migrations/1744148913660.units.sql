@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS units (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL,
+  amount REAL NOT NULL CHECK (amount > 0),
+  unit TEXT NOT NULL,
+
+  createdAt INTEGER NOT NULL,
+
+  organizationId INTEGER NOT NULL,
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  UNIQUE(organizationId, name)
+);
+
+ALTER TABLE product_ingredients ADD COLUMN unit TEXT;
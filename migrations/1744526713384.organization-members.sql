@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS organization_members (
+  organizationId INTEGER NOT NULL,
+  userId INTEGER NOT NULL,
+
+  role TEXT NOT NULL CHECK (role IN ('owner', 'manager', 'viewer')),
+  createdAt INTEGER NOT NULL,
+
+  PRIMARY KEY (organizationId, userId),
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user ON organization_members (userId);
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS vendors (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+  website TEXT,
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS brands (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_vendors (
+  organizationId INTEGER NOT NULL,
+  vendorId INTEGER NOT NULL,
+
+  accountNumber TEXT,
+  contactName TEXT,
+  contactEmail TEXT,
+  contactPhone TEXT,
+  deliveryDays TEXT,
+  notes TEXT,
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  PRIMARY KEY (organizationId, vendorId),
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY(vendorId) REFERENCES vendors(id) ON DELETE CASCADE
+);
+
+ALTER TABLE ingredients ADD COLUMN vendorId INTEGER REFERENCES vendors(id) ON DELETE SET NULL;
+
+ALTER TABLE ingredients ADD COLUMN brandId INTEGER REFERENCES brands(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS ingredients_vendorId ON ingredients(vendorId);
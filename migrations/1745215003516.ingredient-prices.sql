@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS ingredient_prices (
+  id INTEGER PRIMARY KEY,
+
+  unit TEXT NOT NULL,
+  unitCount REAL NOT NULL CHECK (unitCount > 0),
+  purchasePrice INTEGER NOT NULL CHECK (purchasePrice >= 0),
+
+  source TEXT NOT NULL,
+  effectiveAt INTEGER NOT NULL,
+  createdAt INTEGER NOT NULL,
+
+  ingredientId INTEGER NOT NULL,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ingredient_prices_ingredientId ON ingredient_prices(ingredientId, effectiveAt);
+
+INSERT INTO ingredient_prices (unit, unitCount, purchasePrice, source, effectiveAt, createdAt, ingredientId)
+SELECT unit, unitCount, purchasePrice, 'initial', createdAt, createdAt, id
+FROM ingredients;
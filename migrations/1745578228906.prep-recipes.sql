@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS prep_recipes (
+  id INTEGER PRIMARY KEY,
+
+  name TEXT NOT NULL,
+  yieldAmount REAL NOT NULL CHECK (yieldAmount > 0),
+  yieldUnit TEXT NOT NULL,
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  organizationId INTEGER NOT NULL,
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS prep_recipes_organizationId ON prep_recipes(organizationId);
+
+CREATE TABLE IF NOT EXISTS prep_recipe_ingredients (
+  id INTEGER PRIMARY KEY,
+
+  amount REAL NOT NULL CHECK (amount > 0),
+  unit TEXT,
+
+  recipeId INTEGER NOT NULL,
+  ingredientId INTEGER,
+  prepRecipeId INTEGER,
+  FOREIGN KEY(recipeId) REFERENCES prep_recipes(id) ON DELETE CASCADE,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE RESTRICT,
+  FOREIGN KEY(prepRecipeId) REFERENCES prep_recipes(id) ON DELETE RESTRICT,
+  CHECK ((ingredientId IS NULL) != (prepRecipeId IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS prep_recipe_ingredients_recipeId ON prep_recipe_ingredients(recipeId);
+
+CREATE TABLE IF NOT EXISTS product_ingredients_new (
+  id INTEGER PRIMARY KEY,
+
+  amount REAL NOT NULL CHECK (amount > 0),
+  unit TEXT,
+
+  productId INTEGER NOT NULL,
+  ingredientId INTEGER,
+  prepRecipeId INTEGER,
+  FOREIGN KEY(productId) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE RESTRICT,
+  FOREIGN KEY(prepRecipeId) REFERENCES prep_recipes(id) ON DELETE RESTRICT,
+  CHECK ((ingredientId IS NULL) != (prepRecipeId IS NULL))
+);
+
+INSERT INTO product_ingredients_new (id, amount, unit, productId, ingredientId)
+SELECT id, amount, unit, productId, ingredientId FROM product_ingredients;
+
+DROP TABLE product_ingredients;
+
+ALTER TABLE product_ingredients_new RENAME TO product_ingredients;
+
+CREATE INDEX IF NOT EXISTS product_ingredients_productId ON product_ingredients(productId);
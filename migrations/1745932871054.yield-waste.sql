@@ -0,0 +1,5 @@
+ALTER TABLE ingredients ADD COLUMN yieldPercent REAL NOT NULL DEFAULT 100 CHECK (yieldPercent > 0 AND yieldPercent <= 100);
+
+ALTER TABLE product_ingredients ADD COLUMN wastePercent REAL NOT NULL DEFAULT 0 CHECK (wastePercent >= 0 AND wastePercent < 100);
+
+ALTER TABLE prep_recipe_ingredients ADD COLUMN wastePercent REAL NOT NULL DEFAULT 0 CHECK (wastePercent >= 0 AND wastePercent < 100);
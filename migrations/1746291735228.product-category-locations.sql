@@ -0,0 +1,12 @@
+ALTER TABLE products ADD COLUMN category TEXT;
+
+CREATE TABLE IF NOT EXISTS product_locations (
+  productId INTEGER NOT NULL,
+  locationId INTEGER NOT NULL,
+
+  PRIMARY KEY(productId, locationId),
+  FOREIGN KEY(productId) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY(locationId) REFERENCES locations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS product_locations_locationId ON product_locations(locationId);
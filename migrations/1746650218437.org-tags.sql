@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS org_tags (
+  id INTEGER PRIMARY KEY,
+  organizationId INTEGER NOT NULL,
+
+  name TEXT NOT NULL COLLATE NOCASE,
+  type TEXT NOT NULL CHECK (type IN ('ingredient', 'product')),
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  UNIQUE(organizationId, type, name),
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS ingredient_tags (
+  ingredientId INTEGER NOT NULL,
+  tagId INTEGER NOT NULL,
+
+  PRIMARY KEY(ingredientId, tagId),
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE CASCADE,
+  FOREIGN KEY(tagId) REFERENCES org_tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ingredient_tags_tagId ON ingredient_tags(tagId);
+
+CREATE TABLE IF NOT EXISTS product_tags (
+  productId INTEGER NOT NULL,
+  tagId INTEGER NOT NULL,
+
+  PRIMARY KEY(productId, tagId),
+  FOREIGN KEY(productId) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY(tagId) REFERENCES org_tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS product_tags_tagId ON product_tags(tagId);
@@ -0,0 +1,66 @@
+CREATE TABLE IF NOT EXISTS inventory_counts (
+  id INTEGER PRIMARY KEY,
+  organizationId INTEGER NOT NULL,
+  locationId INTEGER NOT NULL,
+
+  countedAt INTEGER NOT NULL,
+  notes TEXT,
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY(locationId) REFERENCES locations(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS inventory_counts_locationId_countedAt ON inventory_counts(locationId, countedAt);
+
+CREATE TABLE IF NOT EXISTS inventory_count_items (
+  countId INTEGER NOT NULL,
+  ingredientId INTEGER NOT NULL,
+
+  quantity REAL NOT NULL CHECK (quantity >= 0),
+  unit TEXT NOT NULL,
+
+  PRIMARY KEY(countId, ingredientId),
+  FOREIGN KEY(countId) REFERENCES inventory_counts(id) ON DELETE CASCADE,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS inventory_purchases (
+  id INTEGER PRIMARY KEY,
+  organizationId INTEGER NOT NULL,
+  locationId INTEGER NOT NULL,
+  ingredientId INTEGER NOT NULL,
+
+  quantity REAL NOT NULL CHECK (quantity > 0),
+  unit TEXT NOT NULL,
+  source TEXT,
+  purchasedAt INTEGER NOT NULL,
+
+  createdAt INTEGER NOT NULL,
+
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY(locationId) REFERENCES locations(id) ON DELETE RESTRICT,
+  FOREIGN KEY(ingredientId) REFERENCES ingredients(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS inventory_purchases_locationId_purchasedAt ON inventory_purchases(locationId, purchasedAt);
+
+CREATE TABLE IF NOT EXISTS sales (
+  id INTEGER PRIMARY KEY,
+  organizationId INTEGER NOT NULL,
+  locationId INTEGER NOT NULL,
+  productId INTEGER NOT NULL,
+
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  soldAt INTEGER NOT NULL,
+
+  createdAt INTEGER NOT NULL,
+
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE,
+  FOREIGN KEY(locationId) REFERENCES locations(id) ON DELETE RESTRICT,
+  FOREIGN KEY(productId) REFERENCES products(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS sales_locationId_soldAt ON sales(locationId, soldAt);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS food_cost_targets (
+  organizationId INTEGER NOT NULL,
+
+  -- blank for the organization's default target
+  category TEXT NOT NULL COLLATE NOCASE,
+  targetPercent REAL NOT NULL CHECK (targetPercent > 0 AND targetPercent < 100),
+  tolerancePercent REAL NOT NULL CHECK (tolerancePercent >= 0),
+
+  createdAt INTEGER NOT NULL,
+  updatedAt INTEGER NOT NULL,
+
+  PRIMARY KEY(organizationId, category),
+  FOREIGN KEY(organizationId) REFERENCES organizations(id) ON DELETE CASCADE
+);
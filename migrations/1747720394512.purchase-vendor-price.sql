@@ -0,0 +1,8 @@
+-- Purchases keep the vendor they were bought from and the price paid so spend
+-- does not follow later changes to the ingredient's vendor or prices
+ALTER TABLE inventory_purchases ADD COLUMN vendorId INTEGER REFERENCES vendors(id) ON DELETE RESTRICT;
+
+ALTER TABLE inventory_purchases ADD COLUMN price INTEGER NOT NULL DEFAULT 0 CHECK (price >= 0);
+
+UPDATE inventory_purchases
+SET vendorId = (SELECT vendorId FROM ingredients WHERE ingredients.id = inventory_purchases.ingredientId);
@@ -0,0 +1,8 @@
+// Package migrations embeds the migration files so binaries can run them
+// without shipping the directory
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
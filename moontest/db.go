@@ -0,0 +1,113 @@
+package moontest
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"moon-cost/migrations"
+	"moon-cost/tools/migration"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// Opens a SQLite database in a temp dir with every embedded migration
+// applied. The database is closed on cleanup
+func LoadTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := sql.Open("libsql", "file:"+path)
+
+	if err != nil {
+		t.Fatalf("Could not open test db: %s", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	manager := migration.Manager{
+		FS: migrations.FS,
+		DB: db,
+	}
+
+	manager.Init(migration.WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(context.Background()); err != nil {
+		t.Fatalf("Could not migrate test db: %s", err)
+	}
+
+	return db
+}
+
+// Inserts an organization directly so tests of services that belong to an
+// organization can satisfy foreign keys without depending on its service
+func InsertTestOrganization(t *testing.T, db *sql.DB, name string) int {
+	t.Helper()
+
+	res, err := db.Exec(`INSERT INTO organizations (name, createdAt, updatedAt) VALUES (?, 0, 0)`, name)
+
+	if err != nil {
+		t.Fatalf("Could not insert test organization: %s", err)
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		t.Fatalf("Could not insert test organization: %s", err)
+	}
+
+	return int(id)
+}
+
+// Inserts a user with an account for email directly so tests can satisfy
+// foreign keys without depending on the auth service
+func InsertTestUser(t *testing.T, db *sql.DB, email string) int {
+	t.Helper()
+
+	res, err := db.Exec(`INSERT INTO users (firstname, lastname) VALUES (?, ?)`, "Test", email)
+
+	if err != nil {
+		t.Fatalf("Could not insert test user: %s", err)
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		t.Fatalf("Could not insert test user: %s", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO accounts (email, password, salt, active, userId) VALUES (?, '', '', 1, ?)`, email, id)
+
+	if err != nil {
+		t.Fatalf("Could not insert test account: %s", err)
+	}
+
+	return int(id)
+}
+
+// The database, logger and pair of organizations service tests are built on.
+// OtherOrgId belongs to a second organization for checking tenant isolation
+type TestEnv struct {
+	DB         *sql.DB
+	Logger     *slog.Logger
+	OrgId      int
+	OtherOrgId int
+}
+
+// Loads a migrated test db with two organizations and a discarding logger
+func NewTestEnv(t *testing.T) TestEnv {
+	t.Helper()
+
+	db := LoadTestDB(t)
+
+	return TestEnv{
+		DB:         db,
+		Logger:     slog.New(slog.DiscardHandler),
+		OrgId:      InsertTestOrganization(t, db, "Moon Cafe"),
+		OtherOrgId: InsertTestOrganization(t, db, "Other Cafe"),
+	}
+}
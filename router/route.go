@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 )
 
 type Route struct {
@@ -21,7 +22,7 @@ func (r *Route) Route(path string) *Route {
 
 	return &Route{
 		Path:       joinedPath,
-		Middleware: r.Middleware[:],
+		Middleware: slices.Clone(r.Middleware),
 		Server:     r.Server,
 	}
 }
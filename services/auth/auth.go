@@ -4,26 +4,46 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"moon-cost/common"
 	"moon-cost/logging"
+	"sync"
+	"time"
 )
 
 var (
 	SignupAccountExistsError = errors.New("Account already exists")
+	InvalidSignupError       = errors.New("Email and password are required")
+	AccountNotFoundError     = errors.New("Account not found")
+	InvalidCredentialsError  = errors.New("Invalid email or password")
+	InvalidSessionError      = errors.New("Invalid or expired session")
 )
 
-var defaultSalt RandomSalt
+const DefaultSessionDuration = time.Hour * 24 * 7
+
+var defaultSalt = RandomSalt{Length: 16}
+
+var defaultHasher = Argon2idHasher{Params: DefaultArgon2idParams}
 
 type Service struct {
-	Salt   Salt
-	Repo   Repo
-	Logger *slog.Logger
+	Salt            Salt
+	Hasher          PasswordHasher
+	Repo            Repo
+	Logger          *slog.Logger
+	Now             common.Now
+	SessionDuration time.Duration
+
+	dummyOnce sync.Once
+	dummyHash string
 }
 
 func NewService(repo Repo, logger *slog.Logger) *Service {
 	return &Service{
-		Repo:   repo,
-		Salt:   defaultSalt,
-		Logger: logging.Logger(logger, slog.String("service", "auth")),
+		Repo:            repo,
+		Salt:            defaultSalt,
+		Hasher:          defaultHasher,
+		Logger:          logging.Logger(logger, slog.String("service", "auth")),
+		Now:             common.TimeNow{},
+		SessionDuration: DefaultSessionDuration,
 	}
 }
 
@@ -40,14 +60,23 @@ type SignupResult struct {
 }
 
 func (s *Service) Signup(ctx context.Context, input Signup) (SignupResult, error) {
-	salt := s.Salt.Salt()
+	if input.Email == "" || input.Password == "" {
+		return SignupResult{}, InvalidSignupError
+	}
 
-	saltedPass := Sha256SaltedPassword{
-		Password: input.Password,
-		Salt:     salt,
+	_, err := s.Repo.GetAccountByEmail(ctx, input.Email)
+
+	if err == nil {
+		return SignupResult{}, SignupAccountExistsError
+	}
+
+	if !errors.Is(err, AccountNotFoundError) {
+		return SignupResult{}, err
 	}
 
-	password := saltedPass.SaltPassword()
+	salt := s.Salt.Salt()
+
+	password := s.Hasher.Hash(input.Password, salt).SaltPassword()
 
 	createAccountInput := signupAccount{
 		email:    input.Email,
@@ -62,5 +91,150 @@ func (s *Service) Signup(ctx context.Context, input Signup) (SignupResult, error
 
 	account, err := s.Repo.CreateAccount(ctx, createUserInput, createAccountInput)
 
+	// another signup can take the email between the lookup and the insert
+	if isUniqueEmailError(err) {
+		return SignupResult{}, SignupAccountExistsError
+	}
+
 	return account, err
 }
+
+type LoginResult struct {
+	User    User
+	Account Account
+	Session Session
+}
+
+// Login verifies the password of the account with the given email and issues
+// a new session for it. InvalidCredentialsError is returned when the account
+// does not exist, is inactive or the password does not match
+func (s *Service) Login(ctx context.Context, email string, password string) (LoginResult, error) {
+	var result LoginResult
+
+	if email == "" || password == "" {
+		return result, InvalidCredentialsError
+	}
+
+	credentials, err := s.Repo.GetAccountByEmail(ctx, email)
+
+	if errors.Is(err, AccountNotFoundError) {
+		s.Logger.Debug("Login attempted for unknown account")
+		s.verifyDummyPassword(password)
+		return result, InvalidCredentialsError
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	if !credentials.active {
+		s.Logger.Debug("Login attempted for inactive account", "account", credentials.account.Id)
+		s.verifyDummyPassword(password)
+		return result, InvalidCredentialsError
+	}
+
+	matches, err := VerifyPassword(credentials.password, credentials.salt, password)
+
+	if err != nil {
+		return result, err
+	}
+
+	if !matches {
+		s.Logger.Debug("Login attempted with invalid password", "account", credentials.account.Id)
+		return result, InvalidCredentialsError
+	}
+
+	if NeedsRehash(credentials.password, s.Hasher) {
+		s.rehashPassword(ctx, credentials.account, password)
+	}
+
+	session, err := s.createSession(ctx, credentials.account)
+
+	if err != nil {
+		return result, err
+	}
+
+	result.User = credentials.user
+	result.Account = credentials.account
+	result.Session = session
+
+	return result, nil
+}
+
+// Verifies password against a fixed hash made with the current Hasher so a
+// login for an unknown or inactive account takes as long as a wrong password
+// and response times do not reveal which emails have accounts
+func (s *Service) verifyDummyPassword(password string) {
+	s.dummyOnce.Do(func() {
+		s.dummyHash = s.Hasher.Hash("dummy password", s.Salt.Salt()).SaltPassword()
+	})
+
+	VerifyPassword(s.dummyHash, "", password)
+}
+
+func (s *Service) createSession(ctx context.Context, account Account) (Session, error) {
+	token := newSessionToken()
+	now := s.Now.Now()
+
+	input := createSession{
+		accountId: account.Id,
+		tokenHash: hashSessionToken(token),
+		createdAt: now,
+		expiresAt: now.Add(s.SessionDuration),
+	}
+
+	session, err := s.Repo.CreateSession(ctx, input)
+
+	if err != nil {
+		return session, err
+	}
+
+	session.Token = token
+
+	return session, nil
+}
+
+// Upgrades the stored hash of an account to the current Hasher. The password
+// has already been verified so a failed upgrade is logged and does not fail
+// the login
+func (s *Service) rehashPassword(ctx context.Context, account Account, password string) {
+	salt := s.Salt.Salt()
+
+	input := updatePassword{
+		accountId: account.Id,
+		salt:      salt,
+		password:  s.Hasher.Hash(password, salt).SaltPassword(),
+	}
+
+	if err := s.Repo.UpdatePassword(ctx, input); err != nil {
+		s.Logger.Error("Error upgrading password hash", "account", account.Id, "error", err)
+		return
+	}
+
+	s.Logger.Info("Upgraded password hash", "account", account.Id)
+}
+
+// Authenticate resolves a session token issued by Login into the identity it
+// belongs to. InvalidSessionError is returned when the session does not
+// exist, has expired or belongs to an inactive account
+func (s *Service) Authenticate(ctx context.Context, token string) (Identity, error) {
+	if token == "" {
+		return Identity{}, InvalidSessionError
+	}
+
+	credentials, err := s.Repo.GetSession(ctx, hashSessionToken(token))
+
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if !credentials.active {
+		return Identity{}, InvalidSessionError
+	}
+
+	if !s.Now.Now().Before(credentials.identity.Session.ExpiresAt) {
+		return Identity{}, InvalidSessionError
+	}
+
+	return credentials.identity, nil
+}
@@ -3,13 +3,27 @@ package auth
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type SQLiteRepo struct {
 	db *sql.DB
 }
 
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+// The driver only reports constraint failures in the error message
+func isUniqueEmailError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: accounts.email")
+}
+
 func (s *SQLiteRepo) CreateAccount(ctx context.Context, userInput signupUser, accountInput signupAccount) (SignupResult, error) {
 	signupResult := SignupResult{}
 
@@ -53,14 +67,14 @@ func (s *SQLiteRepo) CreateAccount(ctx context.Context, userInput signupUser, ac
 		return signupResult, err
 	}
 
-	// TODO: Get account
 	accountId, err := createAccountRes.LastInsertId()
 
 	if err != nil {
 		return signupResult, err
 	}
 
-	fmt.Printf("Account Id %d", accountId)
+	signupResult.Account.Id = strconv.FormatInt(accountId, 10)
+	signupResult.Account.Email = accountInput.email
 
 	signupResult.User, err = s.getUserTx(ctx, tx, int(userId))
 
@@ -68,6 +82,10 @@ func (s *SQLiteRepo) CreateAccount(ctx context.Context, userInput signupUser, ac
 		return signupResult, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return signupResult, err
+	}
+
 	return signupResult, nil
 }
 
@@ -88,3 +106,148 @@ func (s *SQLiteRepo) getUserTx(ctx context.Context, tx *sql.Tx, userId int) (Use
 
 	return user, err
 }
+
+const getAccountByEmailQuery = `
+SELECT
+  accounts.id,
+  accounts.email,
+  accounts.password,
+  accounts.salt,
+  accounts.active,
+  users.id,
+  users.firstname,
+  users.lastname
+FROM accounts
+JOIN users ON users.id = accounts.userId
+WHERE accounts.email = ?
+`
+
+func (s *SQLiteRepo) GetAccountByEmail(ctx context.Context, email string) (accountCredentials, error) {
+	var credentials accountCredentials
+	var active sql.NullInt64
+
+	err := s.db.QueryRowContext(
+		ctx,
+		getAccountByEmailQuery,
+		email,
+	).Scan(
+		&credentials.account.Id,
+		&credentials.account.Email,
+		&credentials.password,
+		&credentials.salt,
+		&active,
+		&credentials.user.Id,
+		&credentials.user.Firstname,
+		&credentials.user.Lastname,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return credentials, AccountNotFoundError
+	}
+
+	credentials.active = active.Valid && active.Int64 == 1
+
+	return credentials, err
+}
+
+const updatePasswordQuery = `UPDATE accounts SET password = ?, salt = ? WHERE id = ?`
+
+func (s *SQLiteRepo) UpdatePassword(ctx context.Context, input updatePassword) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		updatePasswordQuery,
+		input.password,
+		input.salt,
+		input.accountId,
+	)
+
+	return err
+}
+
+const createSessionQuery = `
+INSERT INTO sessions (token, createdAt, expiresAt, accountId)
+VALUES (?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateSession(ctx context.Context, input createSession) (Session, error) {
+	session := Session{
+		AccountId: input.accountId,
+		CreatedAt: input.createdAt,
+		ExpiresAt: input.expiresAt,
+	}
+
+	res, err := s.db.ExecContext(
+		ctx,
+		createSessionQuery,
+		input.tokenHash,
+		input.createdAt.UnixMilli(),
+		input.expiresAt.UnixMilli(),
+		input.accountId,
+	)
+
+	if err != nil {
+		return session, err
+	}
+
+	sessionId, err := res.LastInsertId()
+
+	if err != nil {
+		return session, err
+	}
+
+	session.Id = strconv.FormatInt(sessionId, 10)
+
+	return session, nil
+}
+
+const getSessionQuery = `
+SELECT
+  sessions.id,
+  sessions.createdAt,
+  sessions.expiresAt,
+  accounts.id,
+  accounts.email,
+  accounts.active,
+  users.id,
+  users.firstname,
+  users.lastname
+FROM sessions
+JOIN accounts ON accounts.id = sessions.accountId
+JOIN users ON users.id = accounts.userId
+WHERE sessions.token = ?
+`
+
+func (s *SQLiteRepo) GetSession(ctx context.Context, tokenHash string) (sessionCredentials, error) {
+	var credentials sessionCredentials
+	var createdAt, expiresAt int64
+	var active sql.NullInt64
+
+	identity := &credentials.identity
+
+	err := s.db.QueryRowContext(
+		ctx,
+		getSessionQuery,
+		tokenHash,
+	).Scan(
+		&identity.Session.Id,
+		&createdAt,
+		&expiresAt,
+		&identity.Account.Id,
+		&identity.Account.Email,
+		&active,
+		&identity.User.Id,
+		&identity.User.Firstname,
+		&identity.User.Lastname,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return credentials, InvalidSessionError
+	}
+
+	identity.Session.AccountId = identity.Account.Id
+	identity.Session.CreatedAt = time.UnixMilli(createdAt)
+	identity.Session.ExpiresAt = time.UnixMilli(expiresAt)
+	credentials.active = active.Valid && active.Int64 == 1
+
+	return credentials, err
+}
@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/moontest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRepo struct {
+	NoopRepo
+	accounts  map[string]accountCredentials
+	sessions  []createSession
+	passwords []updatePassword
+	active    map[string]sessionCredentials
+}
+
+func (t *testRepo) GetSession(ctx context.Context, tokenHash string) (sessionCredentials, error) {
+	credentials, ok := t.active[tokenHash]
+
+	if !ok {
+		return credentials, InvalidSessionError
+	}
+
+	return credentials, nil
+}
+
+func (t *testRepo) UpdatePassword(ctx context.Context, input updatePassword) error {
+	t.passwords = append(t.passwords, input)
+
+	return nil
+}
+
+func (t *testRepo) GetAccountByEmail(ctx context.Context, email string) (accountCredentials, error) {
+	credentials, ok := t.accounts[email]
+
+	if !ok {
+		return credentials, AccountNotFoundError
+	}
+
+	return credentials, nil
+}
+
+func (t *testRepo) CreateSession(ctx context.Context, input createSession) (Session, error) {
+	t.sessions = append(t.sessions, input)
+
+	return Session{
+		Id:        "1",
+		AccountId: input.accountId,
+		CreatedAt: input.createdAt,
+		ExpiresAt: input.expiresAt,
+	}, nil
+}
+
+func newTestService() (*Service, *testRepo) {
+	repo := &testRepo{
+		accounts: map[string]accountCredentials{
+			"active@test.com": {
+				account:  Account{Id: "1", Email: "active@test.com"},
+				user:     User{Id: "1", Firstname: "Active", Lastname: "User"},
+				password: testSha256SaltedPassString,
+				salt:     "salt",
+				active:   true,
+			},
+			"inactive@test.com": {
+				account:  Account{Id: "2", Email: "inactive@test.com"},
+				user:     User{Id: "2", Firstname: "Inactive", Lastname: "User"},
+				password: testSha256SaltedPassString,
+				salt:     "salt",
+				active:   false,
+			},
+		},
+	}
+
+	service := NewService(repo, slog.New(slog.DiscardHandler))
+	service.Now = common.TestNow{Time: time.UnixMilli(1742657316428)}
+	service.Hasher = testArgon2idHasher
+
+	return service, repo
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService()
+
+	result, err := service.Login(ctx, "active@test.com", "password")
+
+	if err != nil {
+		t.Fatalf("service.Login() = _, %s. want nil", err)
+	}
+
+	if result.Account.Id != "1" {
+		t.Errorf("service.Login().Account.Id = %s. want %s", result.Account.Id, "1")
+	}
+
+	if result.Session.Token == "" {
+		t.Errorf("service.Login().Session.Token is blank")
+	}
+
+	if len(repo.sessions) != 1 {
+		t.Fatalf("len(sessions) = %d. want 1", len(repo.sessions))
+	}
+
+	if repo.sessions[0].tokenHash != hashSessionToken(result.Session.Token) {
+		t.Errorf("stored session token is not the hash of the issued token")
+	}
+
+	expires := service.Now.Now().Add(DefaultSessionDuration)
+
+	if !result.Session.ExpiresAt.Equal(expires) {
+		t.Errorf("service.Login().Session.ExpiresAt = %s. want %s", result.Session.ExpiresAt, expires)
+	}
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService()
+
+	tests := []struct {
+		test     string
+		email    string
+		password string
+	}{
+		{test: "wrong password", email: "active@test.com", password: "notpassword"},
+		{test: "unknown account", email: "unknown@test.com", password: "password"},
+		{test: "inactive account", email: "inactive@test.com", password: "password"},
+		{test: "blank password", email: "active@test.com", password: ""},
+		{test: "blank email", email: "", password: "password"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := service.Login(ctx, test.email, test.password)
+
+			if !errors.Is(err, InvalidCredentialsError) {
+				t.Errorf("service.Login() = _, %s. want %s", err, InvalidCredentialsError)
+			}
+		})
+	}
+
+	if len(repo.sessions) != 0 {
+		t.Errorf("len(sessions) = %d. want 0", len(repo.sessions))
+	}
+
+	if len(repo.passwords) != 0 {
+		t.Errorf("len(passwords) = %d. want 0", len(repo.passwords))
+	}
+}
+
+type countingHasher struct {
+	PasswordHasher
+	hashes int
+}
+
+func (c *countingHasher) Hash(password string, salt string) PasswordSalter {
+	c.hashes++
+
+	return c.PasswordHasher.Hash(password, salt)
+}
+
+func TestLoginHashesUnknownAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	for _, email := range []string{"unknown@test.com", "inactive@test.com"} {
+		t.Run(email, func(t *testing.T) {
+			service, _ := newTestService()
+			hasher := &countingHasher{PasswordHasher: testArgon2idHasher}
+			service.Hasher = hasher
+
+			if _, err := service.Login(ctx, email, "password"); !errors.Is(err, InvalidCredentialsError) {
+				t.Fatalf("service.Login() = _, %v. want %s", err, InvalidCredentialsError)
+			}
+
+			if hasher.hashes != 1 {
+				t.Errorf("Hash() calls = %d. want 1 dummy hash", hasher.hashes)
+			}
+		})
+	}
+}
+
+func TestLoginUpgradesLegacyPassword(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService()
+
+	if _, err := service.Login(ctx, "active@test.com", "password"); err != nil {
+		t.Fatalf("service.Login() = _, %s. want nil", err)
+	}
+
+	if len(repo.passwords) != 1 {
+		t.Fatalf("len(passwords) = %d. want 1", len(repo.passwords))
+	}
+
+	upgraded := repo.passwords[0]
+
+	if !strings.HasPrefix(upgraded.password, testArgon2idHasher.Prefix()) {
+		t.Errorf("upgraded password = %s. want prefix %s", upgraded.password, testArgon2idHasher.Prefix())
+	}
+
+	matches, err := VerifyPassword(upgraded.password, upgraded.salt, "password")
+
+	if err != nil || !matches {
+		t.Errorf("VerifyPassword(upgraded) = %t, %v. want true, nil", matches, err)
+	}
+
+	// logging in with the upgraded hash should not upgrade again
+	credentials := repo.accounts["active@test.com"]
+	credentials.password = upgraded.password
+	credentials.salt = upgraded.salt
+	repo.accounts["active@test.com"] = credentials
+
+	if _, err := service.Login(ctx, "active@test.com", "password"); err != nil {
+		t.Fatalf("service.Login() = _, %s. want nil", err)
+	}
+
+	if len(repo.passwords) != 1 {
+		t.Errorf("len(passwords) = %d. want 1", len(repo.passwords))
+	}
+}
+
+func TestSignupErrors(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	tests := []struct {
+		test  string
+		input Signup
+		err   error
+	}{
+		{test: "existing account", input: Signup{Email: "active@test.com", Password: "password"}, err: SignupAccountExistsError},
+		{test: "blank email", input: Signup{Password: "password"}, err: InvalidSignupError},
+		{test: "blank password", input: Signup{Email: "new@test.com"}, err: InvalidSignupError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := service.Signup(ctx, test.input)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("service.Signup() = _, %v. want %s", err, test.err)
+			}
+		})
+	}
+}
+
+// Hides existing accounts from the lookup in Signup, as when another signup
+// inserts the same email between the lookup and the insert
+type racedRepo struct {
+	*SQLiteRepo
+}
+
+func (r racedRepo) GetAccountByEmail(ctx context.Context, email string) (accountCredentials, error) {
+	return accountCredentials{}, AccountNotFoundError
+}
+
+func TestSignupRacedAccountExists(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(racedRepo{NewSQLiteRepo(moontest.LoadTestDB(t))}, slog.New(slog.DiscardHandler))
+	service.Hasher = testArgon2idHasher
+
+	input := Signup{Email: "new@test.com", Password: "password"}
+
+	if _, err := service.Signup(ctx, input); err != nil {
+		t.Fatalf("service.Signup() = _, %s. want nil", err)
+	}
+
+	if _, err := service.Signup(ctx, input); !errors.Is(err, SignupAccountExistsError) {
+		t.Errorf("service.Signup(same email) = _, %v. want %s", err, SignupAccountExistsError)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService()
+	now := service.Now.Now()
+
+	session := func(token string, expiresAt time.Time, active bool) {
+		if repo.active == nil {
+			repo.active = map[string]sessionCredentials{}
+		}
+
+		repo.active[hashSessionToken(token)] = sessionCredentials{
+			identity: Identity{
+				User:    User{Id: "1"},
+				Account: Account{Id: "1", Email: "active@test.com"},
+				Session: Session{Id: "1", AccountId: "1", ExpiresAt: expiresAt},
+			},
+			active: active,
+		}
+	}
+
+	session("valid", now.Add(time.Hour), true)
+	session("expired", now, true)
+	session("inactive", now.Add(time.Hour), false)
+
+	tests := []struct {
+		test  string
+		token string
+		err   error
+	}{
+		{test: "valid session", token: "valid"},
+		{test: "unknown token", token: "unknown", err: InvalidSessionError},
+		{test: "blank token", token: "", err: InvalidSessionError},
+		{test: "expired session", token: "expired", err: InvalidSessionError},
+		{test: "inactive account", token: "inactive", err: InvalidSessionError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			identity, err := service.Authenticate(ctx, test.token)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("service.Authenticate() = _, %v. want %v", err, test.err)
+			}
+
+			if test.err == nil && identity.Account.Email != "active@test.com" {
+				t.Errorf("identity.Account.Email = %s. want %s", identity.Account.Email, "active@test.com")
+			}
+		})
+	}
+}
+
+func TestAuthenticateLoginSession(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService()
+
+	result, err := service.Login(ctx, "active@test.com", "password")
+
+	if err != nil {
+		t.Fatalf("service.Login() = _, %s. want nil", err)
+	}
+
+	created := repo.sessions[0]
+
+	repo.active = map[string]sessionCredentials{
+		created.tokenHash: {
+			identity: Identity{
+				User:    result.User,
+				Account: result.Account,
+				Session: Session{AccountId: created.accountId, ExpiresAt: created.expiresAt},
+			},
+			active: true,
+		},
+	}
+
+	identity, err := service.Authenticate(ctx, result.Session.Token)
+
+	if err != nil {
+		t.Fatalf("service.Authenticate() = _, %s. want nil", err)
+	}
+
+	if identity.User.Id != result.User.Id {
+		t.Errorf("identity.User.Id = %s. want %s", identity.User.Id, result.User.Id)
+	}
+}
@@ -0,0 +1,35 @@
+package auth
+
+import "context"
+
+// Identity is the authenticated user and account of a request along with the
+// session used to authenticate it
+type Identity struct {
+	User    User    `json:"user"`
+	Account Account `json:"account"`
+	Session Session `json:"session"`
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+
+	return identity, ok
+}
+
+func UserFromContext(ctx context.Context) (User, bool) {
+	identity, ok := IdentityFromContext(ctx)
+
+	return identity.User, ok
+}
+
+func AccountFromContext(ctx context.Context) (Account, bool) {
+	identity, ok := IdentityFromContext(ctx)
+
+	return identity.Account, ok
+}
@@ -1,5 +1,7 @@
 package auth
 
+import "time"
+
 type Account struct {
 	Id    string `json:"id"`
 	Email string `json:"email"`
@@ -10,3 +12,14 @@ type User struct {
 	Firstname string `json:"firstname"`
 	Lastname  string `json:"lastname"`
 }
+
+// Session is a server side session issued on login. Token is only populated
+// when the session is first created since only its hash is persisted. It is
+// never encoded so it only reaches clients where a handler hands it over
+type Session struct {
+	Id        string    `json:"-"`
+	Token     string    `json:"-"`
+	AccountId string    `json:"accountId"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"moon-cost/assert"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	InvalidPasswordHashError     = errors.New("Invalid password hash")
+	UnsupportedPasswordHashError = errors.New("Unsupported password hash algorithm")
+)
+
+const (
+	Argon2idAlgorithm = "argon2id"
+	Pbkdf2Algorithm   = "pbkdf2-sha256"
+)
+
+// The PasswordHasher interface creates the PasswordSalter used to hash newly
+// set passwords. Prefix returns the encoded algorithm and parameters shared by
+// every hash it produces so outdated hashes can be detected
+type PasswordHasher interface {
+	Hash(password string, salt string) PasswordSalter
+	Prefix() string
+}
+
+// Reports whether an encoded password hash was created with a different
+// algorithm or different parameters than hasher and should be replaced
+func NeedsRehash(encoded string, hasher PasswordHasher) bool {
+	return !strings.HasPrefix(encoded, hasher.Prefix())
+}
+
+// Builds the PasswordSalter that produced encoded using the algorithm,
+// parameters and salt recorded in it for the candidate password. Hashes that
+// are not PHC encoded are treated as legacy Sha256SaltedPassword hashes that
+// were salted with legacySalt
+func ParsePasswordSalter(encoded string, legacySalt string, password string) (PasswordSalter, error) {
+	if !strings.HasPrefix(encoded, "$") {
+		return Sha256SaltedPassword{Password: password, Salt: legacySalt}, nil
+	}
+
+	parts := strings.Split(encoded, "$")
+
+	if len(parts) < 2 {
+		return nil, InvalidPasswordHashError
+	}
+
+	switch parts[1] {
+	case Argon2idAlgorithm:
+		return parseArgon2id(parts, password)
+
+	case Pbkdf2Algorithm:
+		return parsePbkdf2(parts, password)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", UnsupportedPasswordHashError, parts[1])
+	}
+}
+
+// Verifies password against an encoded hash created by any supported
+// PasswordSalter
+func VerifyPassword(encoded string, legacySalt string, password string) (bool, error) {
+	salter, err := ParsePasswordSalter(encoded, legacySalt, password)
+
+	if err != nil {
+		return false, err
+	}
+
+	return ComparePasswords(BasicSaltedPassword(encoded), salter), nil
+}
+
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	KeyLength   uint32
+}
+
+// Follows the OWASP minimum recommendation for argon2id
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	KeyLength:   32,
+}
+
+// Hashes a password and salt with argon2id and encodes it in the PHC string
+// format $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idSaltedPassword struct {
+	Password string
+	Salt     string
+	Params   Argon2idParams
+}
+
+func (a Argon2idSaltedPassword) SaltPassword() string {
+	assert.Ok(a.Password != "", "Password must be populated and not blank")
+	assert.Ok(a.Salt != "", "Salt must be populated and not blank")
+
+	key := argon2.IDKey(
+		[]byte(a.Password),
+		[]byte(a.Salt),
+		a.Params.Iterations,
+		a.Params.Memory,
+		a.Params.Parallelism,
+		a.Params.KeyLength,
+	)
+
+	return fmt.Sprintf("%s%s$%s", argon2idPrefix(a.Params), encodeHashPart([]byte(a.Salt)), encodeHashPart(key))
+}
+
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func (a Argon2idHasher) Hash(password string, salt string) PasswordSalter {
+	return Argon2idSaltedPassword{Password: password, Salt: salt, Params: a.Params}
+}
+
+func (a Argon2idHasher) Prefix() string {
+	return argon2idPrefix(a.Params)
+}
+
+func argon2idPrefix(params Argon2idParams) string {
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$",
+		Argon2idAlgorithm,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+	)
+}
+
+func parseArgon2id(parts []string, password string) (PasswordSalter, error) {
+	// "", argon2id, v=19, m=65536,t=3,p=2, salt, hash
+	if len(parts) != 6 {
+		return nil, InvalidPasswordHashError
+	}
+
+	var version int
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("%w: %w", InvalidPasswordHashError, err)
+	}
+
+	if version != argon2.Version {
+		return nil, fmt.Errorf("%w: argon2 version %d", UnsupportedPasswordHashError, version)
+	}
+
+	var params Argon2idParams
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, fmt.Errorf("%w: %w", InvalidPasswordHashError, err)
+	}
+
+	salt, key, err := decodeSaltAndKey(parts[4], parts[5])
+
+	if err != nil {
+		return nil, err
+	}
+
+	params.KeyLength = uint32(len(key))
+
+	return Argon2idSaltedPassword{Password: password, Salt: salt, Params: params}, nil
+}
+
+type Pbkdf2Params struct {
+	Iterations int
+	KeyLength  int
+}
+
+// Follows the OWASP minimum recommendation for PBKDF2-HMAC-SHA256
+var DefaultPbkdf2Params = Pbkdf2Params{
+	Iterations: 600_000,
+	KeyLength:  32,
+}
+
+// Hashes a password and salt with PBKDF2-HMAC-SHA256 and encodes it in the PHC
+// string format $pbkdf2-sha256$i=<iterations>$<salt>$<hash>. Only depends on
+// the standard library
+type Pbkdf2SaltedPassword struct {
+	Password string
+	Salt     string
+	Params   Pbkdf2Params
+}
+
+func (p Pbkdf2SaltedPassword) SaltPassword() string {
+	assert.Ok(p.Password != "", "Password must be populated and not blank")
+	assert.Ok(p.Salt != "", "Salt must be populated and not blank")
+
+	key, err := pbkdf2.Key(sha256.New, p.Password, []byte(p.Salt), p.Params.Iterations, p.Params.KeyLength)
+
+	assert.Ok(err == nil, "Could not derive pbkdf2 key: %s", err)
+
+	return fmt.Sprintf("%s%s$%s", pbkdf2Prefix(p.Params), encodeHashPart([]byte(p.Salt)), encodeHashPart(key))
+}
+
+type Pbkdf2Hasher struct {
+	Params Pbkdf2Params
+}
+
+func (p Pbkdf2Hasher) Hash(password string, salt string) PasswordSalter {
+	return Pbkdf2SaltedPassword{Password: password, Salt: salt, Params: p.Params}
+}
+
+func (p Pbkdf2Hasher) Prefix() string {
+	return pbkdf2Prefix(p.Params)
+}
+
+func pbkdf2Prefix(params Pbkdf2Params) string {
+	return fmt.Sprintf("$%s$i=%d$", Pbkdf2Algorithm, params.Iterations)
+}
+
+func parsePbkdf2(parts []string, password string) (PasswordSalter, error) {
+	// "", pbkdf2-sha256, i=600000, salt, hash
+	if len(parts) != 5 {
+		return nil, InvalidPasswordHashError
+	}
+
+	var params Pbkdf2Params
+
+	if _, err := fmt.Sscanf(parts[2], "i=%d", &params.Iterations); err != nil {
+		return nil, fmt.Errorf("%w: %w", InvalidPasswordHashError, err)
+	}
+
+	salt, key, err := decodeSaltAndKey(parts[3], parts[4])
+
+	if err != nil {
+		return nil, err
+	}
+
+	params.KeyLength = len(key)
+
+	return Pbkdf2SaltedPassword{Password: password, Salt: salt, Params: params}, nil
+}
+
+func encodeHashPart(data []byte) string {
+	return base64.RawStdEncoding.EncodeToString(data)
+}
+
+func decodeSaltAndKey(encodedSalt string, encodedKey string) (string, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", InvalidPasswordHashError, err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", InvalidPasswordHashError, err)
+	}
+
+	if len(salt) == 0 || len(key) == 0 {
+		return "", nil, InvalidPasswordHashError
+	}
+
+	return string(salt), key, nil
+}
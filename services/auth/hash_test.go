@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testArgon2idHasher = Argon2idHasher{
+	Params: Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, KeyLength: 16},
+}
+
+var testPbkdf2Hasher = Pbkdf2Hasher{
+	Params: Pbkdf2Params{Iterations: 1000, KeyLength: 16},
+}
+
+func TestPasswordHasherEncoding(t *testing.T) {
+	tests := []struct {
+		hasher PasswordHasher
+		prefix string
+	}{
+		{hasher: testArgon2idHasher, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{hasher: testPbkdf2Hasher, prefix: "$pbkdf2-sha256$i=1000$"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			encoded := test.hasher.Hash("password", "salt").SaltPassword()
+
+			if !strings.HasPrefix(encoded, test.prefix) {
+				t.Errorf("Hash().SaltPassword() = %s. want prefix %s", encoded, test.prefix)
+			}
+
+			if test.hasher.Prefix() != test.prefix {
+				t.Errorf("Prefix() = %s. want %s", test.hasher.Prefix(), test.prefix)
+			}
+
+			matches, err := VerifyPassword(encoded, "", "password")
+
+			if err != nil || !matches {
+				t.Errorf("VerifyPassword(%s, password) = %t, %v. want true, nil", encoded, matches, err)
+			}
+
+			matches, err = VerifyPassword(encoded, "", "notpassword")
+
+			if err != nil || matches {
+				t.Errorf("VerifyPassword(%s, notpassword) = %t, %v. want false, nil", encoded, matches, err)
+			}
+
+			if NeedsRehash(encoded, test.hasher) {
+				t.Errorf("NeedsRehash(%s) = true. want false", encoded)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacyPassword(t *testing.T) {
+	matches, err := VerifyPassword(testSha256SaltedPassString, "salt", "password")
+
+	if err != nil || !matches {
+		t.Errorf("VerifyPassword() = %t, %v. want true, nil", matches, err)
+	}
+
+	if !NeedsRehash(testSha256SaltedPassString, testArgon2idHasher) {
+		t.Errorf("NeedsRehash(legacy) = false. want true")
+	}
+}
+
+func TestNeedsRehashOnParamChange(t *testing.T) {
+	encoded := testArgon2idHasher.Hash("password", "salt").SaltPassword()
+
+	stronger := Argon2idHasher{Params: testArgon2idHasher.Params}
+	stronger.Params.Iterations = 2
+
+	if !NeedsRehash(encoded, stronger) {
+		t.Errorf("NeedsRehash() = false with changed iterations. want true")
+	}
+
+	if !NeedsRehash(encoded, testPbkdf2Hasher) {
+		t.Errorf("NeedsRehash() = false with changed algorithm. want true")
+	}
+}
+
+func TestParsePasswordSalterErrors(t *testing.T) {
+	tests := []struct {
+		encoded string
+		err     error
+	}{
+		{encoded: "$bcrypt$whatever", err: UnsupportedPasswordHashError},
+		{encoded: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", err: InvalidPasswordHashError},
+		{encoded: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA", err: UnsupportedPasswordHashError},
+		{encoded: "$argon2id$v=19$bad$c2FsdA$aGFzaA", err: InvalidPasswordHashError},
+		{encoded: "$pbkdf2-sha256$i=1000$!!!$aGFzaA", err: InvalidPasswordHashError},
+		{encoded: "$pbkdf2-sha256$i=1000$$aGFzaA", err: InvalidPasswordHashError},
+	}
+
+	for _, test := range tests {
+		_, err := ParsePasswordSalter(test.encoded, "", "password")
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParsePasswordSalter(%s) = _, %v. want %s", test.encoded, err, test.err)
+		}
+	}
+}
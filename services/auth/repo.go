@@ -1,6 +1,9 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type signupAccount struct {
 	email    string
@@ -13,8 +16,38 @@ type signupUser struct {
 	Lastname  string
 }
 
+type accountCredentials struct {
+	account  Account
+	user     User
+	password string
+	salt     string
+	active   bool
+}
+
+type updatePassword struct {
+	accountId string
+	salt      string
+	password  string
+}
+
+type createSession struct {
+	accountId string
+	tokenHash string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type sessionCredentials struct {
+	identity Identity
+	active   bool
+}
+
 type Repo interface {
 	CreateAccount(context.Context, signupUser, signupAccount) (SignupResult, error)
+	GetAccountByEmail(context.Context, string) (accountCredentials, error)
+	UpdatePassword(context.Context, updatePassword) error
+	CreateSession(context.Context, createSession) (Session, error)
+	GetSession(context.Context, string) (sessionCredentials, error)
 }
 
 type NoopRepo struct{}
@@ -22,3 +55,19 @@ type NoopRepo struct{}
 func (n *NoopRepo) CreateAccount(context.Context, signupUser, signupAccount) (SignupResult, error) {
 	return SignupResult{}, nil
 }
+
+func (n *NoopRepo) GetAccountByEmail(context.Context, string) (accountCredentials, error) {
+	return accountCredentials{}, AccountNotFoundError
+}
+
+func (n *NoopRepo) UpdatePassword(context.Context, updatePassword) error {
+	return nil
+}
+
+func (n *NoopRepo) CreateSession(context.Context, createSession) (Session, error) {
+	return Session{}, nil
+}
+
+func (n *NoopRepo) GetSession(context.Context, string) (sessionCredentials, error) {
+	return sessionCredentials{}, InvalidSessionError
+}
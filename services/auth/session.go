@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+const sessionTokenLength = 32
+
+// Generates a cryptographically secure random token that is handed to the
+// client to identify its session
+func newSessionToken() string {
+	token := make([]byte, sessionTokenLength)
+
+	rand.Read(token)
+
+	return fmt.Sprintf("%x", token)
+}
+
+// Session tokens are stored hashed so a leaked sessions table can not be used
+// to hijack sessions
+func hashSessionToken(token string) string {
+	hashed := sha256.Sum256([]byte(token))
+
+	return fmt.Sprintf("%x", hashed)
+}
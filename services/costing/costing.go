@@ -0,0 +1,111 @@
+package costing
+
+// Line is an amount of an ingredient or prep recipe used by a recipe. Amount
+// is the edible portion in the same unit as CostPerUnit, the as-purchased
+// cost. YieldPercent is the part of a purchase left after trimming and
+// WastePercent the part of the edible portion lost while making the recipe.
+// A zero YieldPercent is treated as 100. Costs are in cents
+type Line struct {
+	IngredientId int
+	PrepRecipeId int
+	Name         string
+	Unit         string
+	Amount       float64
+	CostPerUnit  float64
+	YieldPercent float64
+	WastePercent float64
+}
+
+// Usable is the fraction of what is purchased that ends up in the recipe
+func (l Line) Usable() float64 {
+	yield := l.YieldPercent
+
+	if yield <= 0 {
+		yield = 100
+	}
+
+	return yield / 100 * (1 - l.WastePercent/100)
+}
+
+// AsPurchasedCost prices the amount as if all of a purchase is used. Cost
+// includes the trim and waste needed to get the amount
+type LineCost struct {
+	IngredientId      int     `json:"ingredientId,omitempty"`
+	PrepRecipeId      int     `json:"prepRecipeId,omitempty"`
+	Name              string  `json:"name"`
+	Unit              string  `json:"unit"`
+	Amount            float64 `json:"amount"`
+	CostPerUnit       float64 `json:"costPerUnit"`
+	YieldPercent      float64 `json:"yieldPercent"`
+	WastePercent      float64 `json:"wastePercent"`
+	EdibleCostPerUnit float64 `json:"edibleCostPerUnit"`
+	AsPurchasedCost   float64 `json:"asPurchasedCost"`
+	Cost              float64 `json:"cost"`
+}
+
+// Breakdown is the cost of a recipe that yields Servings servings, each sold
+// for MenuPrice. Costs and prices are in cents and percentages are 0-100. The
+// food cost and margins use the edible portion cost
+type Breakdown struct {
+	Lines                     []LineCost `json:"lines"`
+	AsPurchasedCost           float64    `json:"asPurchasedCost"`
+	Cost                      float64    `json:"cost"`
+	Servings                  int        `json:"servings"`
+	AsPurchasedCostPerServing float64    `json:"asPurchasedCostPerServing"`
+	CostPerServing            float64    `json:"costPerServing"`
+	MenuPrice                 int64      `json:"menuPrice"`
+	FoodCostPercent           float64    `json:"foodCostPercent"`
+	GrossMargin               float64    `json:"grossMargin"`
+	GrossMarginPercent        float64    `json:"grossMarginPercent"`
+}
+
+// Rolls up the cost of every line into the cost of the recipe, the cost of
+// each serving and how that cost compares to the menu price
+func Cost(menuPrice int64, servings int, lines []Line) Breakdown {
+	breakdown := Breakdown{
+		Lines:     make([]LineCost, len(lines)),
+		Servings:  servings,
+		MenuPrice: menuPrice,
+	}
+
+	for i, line := range lines {
+		lineCost := LineCost{
+			IngredientId:    line.IngredientId,
+			PrepRecipeId:    line.PrepRecipeId,
+			Name:            line.Name,
+			Unit:            line.Unit,
+			Amount:          line.Amount,
+			CostPerUnit:     line.CostPerUnit,
+			YieldPercent:    line.YieldPercent,
+			WastePercent:    line.WastePercent,
+			AsPurchasedCost: line.Amount * line.CostPerUnit,
+		}
+
+		if lineCost.YieldPercent <= 0 {
+			lineCost.YieldPercent = 100
+		}
+
+		if usable := line.Usable(); usable > 0 {
+			lineCost.EdibleCostPerUnit = line.CostPerUnit / usable
+			lineCost.Cost = line.Amount * lineCost.EdibleCostPerUnit
+		}
+
+		breakdown.Lines[i] = lineCost
+		breakdown.AsPurchasedCost += lineCost.AsPurchasedCost
+		breakdown.Cost += lineCost.Cost
+	}
+
+	if servings > 0 {
+		breakdown.AsPurchasedCostPerServing = breakdown.AsPurchasedCost / float64(servings)
+		breakdown.CostPerServing = breakdown.Cost / float64(servings)
+	}
+
+	breakdown.GrossMargin = float64(menuPrice) - breakdown.CostPerServing
+
+	if menuPrice > 0 {
+		breakdown.FoodCostPercent = breakdown.CostPerServing / float64(menuPrice) * 100
+		breakdown.GrossMarginPercent = breakdown.GrossMargin / float64(menuPrice) * 100
+	}
+
+	return breakdown
+}
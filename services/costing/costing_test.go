@@ -0,0 +1,96 @@
+package costing
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCost(t *testing.T) {
+	lines := []Line{
+		{IngredientId: 1, Name: "Flour", Unit: "g", Amount: 500, CostPerUnit: 0.2},
+		{IngredientId: 2, Name: "Tomato", Unit: "each", Amount: 4, CostPerUnit: 50},
+	}
+
+	breakdown := Cost(1200, 4, lines)
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"Lines[0].Cost", breakdown.Lines[0].Cost, 100},
+		{"Lines[1].Cost", breakdown.Lines[1].Cost, 200},
+		{"Cost", breakdown.Cost, 300},
+		{"CostPerServing", breakdown.CostPerServing, 75},
+		{"FoodCostPercent", breakdown.FoodCostPercent, 6.25},
+		{"GrossMargin", breakdown.GrossMargin, 1125},
+		{"GrossMarginPercent", breakdown.GrossMarginPercent, 93.75},
+	}
+
+	for _, test := range tests {
+		if !almostEqual(test.value, test.expected) {
+			t.Errorf("Cost().%s = %f. want %f", test.name, test.value, test.expected)
+		}
+	}
+}
+
+func TestCostWithoutPriceOrServings(t *testing.T) {
+	lines := []Line{
+		{IngredientId: 1, Name: "Flour", Unit: "g", Amount: 500, CostPerUnit: 0.2},
+	}
+
+	breakdown := Cost(0, 0, lines)
+
+	if breakdown.CostPerServing != 0 {
+		t.Errorf("Cost().CostPerServing = %f. want 0", breakdown.CostPerServing)
+	}
+
+	if breakdown.FoodCostPercent != 0 {
+		t.Errorf("Cost().FoodCostPercent = %f. want 0", breakdown.FoodCostPercent)
+	}
+
+	if breakdown.Cost != 100 {
+		t.Errorf("Cost().Cost = %f. want 100", breakdown.Cost)
+	}
+}
+
+func TestCostWithYieldAndWaste(t *testing.T) {
+	lines := []Line{
+		// 80% of a purchase is usable so 100g edible costs 25 cents
+		{IngredientId: 1, Name: "Onion", Unit: "g", Amount: 100, CostPerUnit: 0.2, YieldPercent: 80},
+		// half of what is left after trimming is lost while cooking
+		{IngredientId: 2, Name: "Spinach", Unit: "g", Amount: 100, CostPerUnit: 0.5, YieldPercent: 50, WastePercent: 50},
+		{IngredientId: 3, Name: "Salt", Unit: "g", Amount: 10, CostPerUnit: 0.1},
+	}
+
+	breakdown := Cost(1000, 2, lines)
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"Lines[0].AsPurchasedCost", breakdown.Lines[0].AsPurchasedCost, 20},
+		{"Lines[0].EdibleCostPerUnit", breakdown.Lines[0].EdibleCostPerUnit, 0.25},
+		{"Lines[0].Cost", breakdown.Lines[0].Cost, 25},
+		{"Lines[1].AsPurchasedCost", breakdown.Lines[1].AsPurchasedCost, 50},
+		{"Lines[1].Cost", breakdown.Lines[1].Cost, 200},
+		{"Lines[2].YieldPercent", breakdown.Lines[2].YieldPercent, 100},
+		{"Lines[2].Cost", breakdown.Lines[2].Cost, 1},
+		{"AsPurchasedCost", breakdown.AsPurchasedCost, 71},
+		{"Cost", breakdown.Cost, 226},
+		{"AsPurchasedCostPerServing", breakdown.AsPurchasedCostPerServing, 35.5},
+		{"CostPerServing", breakdown.CostPerServing, 113},
+		{"FoodCostPercent", breakdown.FoodCostPercent, 11.3},
+	}
+
+	for _, test := range tests {
+		if !almostEqual(test.value, test.expected) {
+			t.Errorf("Cost().%s = %f. want %f", test.name, test.value, test.expected)
+		}
+	}
+}
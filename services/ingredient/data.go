@@ -0,0 +1,60 @@
+package ingredient
+
+import "time"
+
+// Ingredient is something an organization purchases. A purchase is recorded
+// as UnitCount of Unit for PurchasePrice, e.g. a case of 1000 cups is a
+// UnitCount of 1000 cups. Prices are stored in cents. VendorId must reference
+// a vendor linked to the organization. YieldPercent is the part of a purchase
+// that is usable after trimming, e.g. 80 when a fifth of an onion is peeled
+type Ingredient struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	Name           string    `json:"name"`
+	Brand          string    `json:"brand"`
+	Vendor         string    `json:"vendor"`
+	BrandId        *int      `json:"brandId"`
+	VendorId       *int      `json:"vendorId"`
+	Category       string    `json:"category"`
+	Unit           string    `json:"unit"`
+	UnitCount      float64   `json:"unitCount"`
+	PurchasePrice  int64     `json:"purchasePrice"`
+	CostPerUnit    float64   `json:"costPerUnit"`
+	YieldPercent   float64   `json:"yieldPercent"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Cost in cents of a single unit of a purchase
+func UnitCost(purchasePrice int64, unitCount float64) float64 {
+	if unitCount <= 0 {
+		return 0
+	}
+
+	return float64(purchasePrice) / unitCount
+}
+
+// Price is a purchase price of an ingredient effective from EffectiveAt until
+// the next price. Change and ChangePercent compare CostPerUnit to the previous
+// price and are 0 for the first price or when the unit changed
+type Price struct {
+	Id            int       `json:"id"`
+	IngredientId  int       `json:"ingredientId"`
+	Unit          string    `json:"unit"`
+	UnitCount     float64   `json:"unitCount"`
+	PurchasePrice int64     `json:"purchasePrice"`
+	CostPerUnit   float64   `json:"costPerUnit"`
+	Source        string    `json:"source"`
+	EffectiveAt   time.Time `json:"effectiveAt"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Change        float64   `json:"change"`
+	ChangePercent float64   `json:"changePercent"`
+}
+
+// Filter narrows the ingredients returned by ListIngredients. Zero values
+// match every ingredient. TagIds matches ingredients with any of the tags
+type Filter struct {
+	VendorId int
+	BrandId  int
+	TagIds   []int
+}
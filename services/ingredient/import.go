@@ -0,0 +1,599 @@
+package ingredient
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"moon-cost/units"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidImportError = errors.New("Invalid import")
+	ImportRowsError    = errors.New("Import has invalid rows")
+)
+
+const PriceSourceImport = "import"
+
+// ImportKind is the kind of CSV file being imported
+type ImportKind string
+
+const (
+	// A catalog creates ingredients or updates the ingredient with the same name
+	ImportCatalog ImportKind = "catalog"
+	// An invoice records prices for existing ingredients matched by name
+	ImportInvoice ImportKind = "invoice"
+)
+
+// Actions taken for an imported row
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionPrice  = "price"
+)
+
+type importField struct {
+	name     string
+	required bool
+}
+
+// Fields read by each kind of import. Prices are in dollars
+var importFields = map[ImportKind][]importField{
+	ImportCatalog: {
+		{"name", true},
+		{"brand", false},
+		{"vendor", false},
+		{"category", false},
+		{"unit", true},
+		{"unitCount", true},
+		{"purchasePrice", true},
+		{"yieldPercent", false},
+	},
+	ImportInvoice: {
+		{"name", true},
+		{"purchasePrice", true},
+		{"unit", false},
+		{"unitCount", false},
+		{"effectiveAt", false},
+		{"source", false},
+	},
+}
+
+// Mapping maps import fields to CSV column headers. Fields that are not mapped
+// are read from the column named after the field
+type Mapping map[string]string
+
+// Parses a mapping like "name=Item,purchasePrice=Case Price"
+func ParseMapping(value string) (Mapping, error) {
+	mapping := Mapping{}
+
+	for pair := range strings.SplitSeq(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		field, column, ok := strings.Cut(pair, "=")
+
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("%w: mapping %q must be field=column", InvalidImportError, pair)
+		}
+
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+// Source is recorded on the prices created by the import and defaults to
+// PriceSourceImport. Nothing is saved when DryRun is set
+type ImportInput struct {
+	Kind    ImportKind
+	Mapping Mapping
+	Source  string
+	DryRun  bool
+}
+
+// Resolves the column index of every field in the header. Fields without a
+// column are left out
+func (i ImportInput) columns(header []string) (map[string]int, error) {
+	fields, ok := importFields[i.Kind]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kind %q", InvalidImportError, i.Kind)
+	}
+
+	known := map[string]bool{}
+
+	for _, field := range fields {
+		known[field.name] = true
+	}
+
+	for field := range i.Mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("%w: unknown field %q for %s imports", InvalidImportError, field, i.Kind)
+		}
+	}
+
+	columns := map[string]int{}
+
+	for _, field := range fields {
+		column, mapped := i.Mapping[field.name]
+
+		if !mapped {
+			column = field.name
+		}
+
+		index := headerIndex(header, column)
+
+		if index == -1 && (mapped || field.required) {
+			return nil, fmt.Errorf("%w: missing column %q for %s", InvalidImportError, column, field.name)
+		}
+
+		if index != -1 {
+			columns[field.name] = index
+		}
+	}
+
+	return columns, nil
+}
+
+func headerIndex(header []string, column string) int {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ImportRow is what a valid row of the file imports. Price is only set for
+// invoice rows
+type ImportRow struct {
+	Line       int        `json:"line"`
+	Action     string     `json:"action"`
+	Ingredient Ingredient `json:"ingredient"`
+	Price      *Price     `json:"price,omitempty"`
+}
+
+type ImportError struct {
+	Line    int    `json:"line"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportResult struct {
+	Kind    ImportKind    `json:"kind"`
+	DryRun  bool          `json:"dryRun"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Prices  int           `json:"prices"`
+	Rows    []ImportRow   `json:"rows"`
+	Errors  []ImportError `json:"errors"`
+}
+
+func (r *ImportResult) add(row ImportRow) {
+	switch row.Action {
+	case ImportActionCreate:
+		r.Created++
+	case ImportActionUpdate:
+		r.Updated++
+	case ImportActionPrice:
+		r.Prices++
+	}
+
+	r.Rows = append(r.Rows, row)
+}
+
+// A row of the file being imported
+type importRecord struct {
+	line    int
+	record  []string
+	columns map[string]int
+}
+
+// Returns the trimmed value of field, or "" when it has no column
+func (r importRecord) value(field string) string {
+	index, ok := r.columns[field]
+
+	if !ok || index >= len(r.record) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.record[index])
+}
+
+func (r importRecord) blank() bool {
+	for _, value := range r.record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r importRecord) error(field string, format string, args ...any) ImportError {
+	return ImportError{Line: r.line, Column: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func (r importRecord) float(field string, value *float64) *ImportError {
+	raw := r.value(field)
+
+	if raw == "" {
+		return nil
+	}
+
+	parsed, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+
+	if err != nil {
+		rowErr := r.error(field, "%q is not a number", raw)
+		return &rowErr
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+// Reads a price in dollars, like $1,250.50, as cents
+func (r importRecord) dollars(field string, value *int64) *ImportError {
+	raw := r.value(field)
+
+	if raw == "" {
+		return nil
+	}
+
+	cleaned := strings.NewReplacer("$", "", ",", "", " ", "").Replace(raw)
+	parsed, err := strconv.ParseFloat(cleaned, 64)
+
+	if err != nil {
+		rowErr := r.error(field, "%q is not a price", raw)
+		return &rowErr
+	}
+
+	*value = int64(math.Round(parsed * 100))
+
+	return nil
+}
+
+// Reads a date as the start of the day in UTC, or an RFC3339 time
+func (r importRecord) time(field string, value *time.Time) *ImportError {
+	raw := r.value(field)
+
+	if raw == "" {
+		return nil
+	}
+
+	if parsed, err := time.Parse(time.DateOnly, raw); err == nil {
+		*value = parsed
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+
+	if err != nil {
+		rowErr := r.error(field, "%q is not a date", raw)
+		return &rowErr
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+type importBatch struct {
+	organizationId int
+	source         string
+	rows           []ImportRow
+}
+
+// Imports a CSV file of ingredients or prices. Every row is validated before
+// anything is saved and the whole file is saved in a single transaction.
+// When any row is invalid the result lists the row errors and
+// ImportRowsError is returned
+func (s *Service) Import(ctx context.Context, organizationId int, input ImportInput, file io.Reader) (ImportResult, error) {
+	result := ImportResult{
+		Kind:   input.Kind,
+		DryRun: input.DryRun,
+		Rows:   []ImportRow{},
+		Errors: []ImportError{},
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return result, fmt.Errorf("%w: reading header: %w", InvalidImportError, err)
+	}
+
+	// spreadsheets often save a byte order mark before the first header
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := input.columns(header)
+
+	if err != nil {
+		return result, err
+	}
+
+	now := s.Now.Now()
+
+	existing, err := s.Repo.ListIngredients(ctx, organizationId, Filter{}, now)
+
+	if err != nil {
+		return result, err
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return result, err
+	}
+
+	importer := rowImporter{
+		organizationId: organizationId,
+		kind:           input.Kind,
+		source:         strings.TrimSpace(input.Source),
+		now:            now,
+		registry:       registry,
+		ingredients:    map[string]Ingredient{},
+		seen:           map[string]int{},
+	}
+
+	if importer.source == "" {
+		importer.source = PriceSourceImport
+	}
+
+	for _, ingredient := range existing {
+		importer.ingredients[strings.ToLower(ingredient.Name)] = ingredient
+	}
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		var parseErr *csv.ParseError
+
+		if errors.As(err, &parseErr) {
+			result.Errors = append(result.Errors, ImportError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+			continue
+		}
+
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", InvalidImportError, err)
+		}
+
+		row := importRecord{line: line, record: record, columns: columns}
+
+		if row.blank() {
+			continue
+		}
+
+		imported, rowErrors := importer.row(row)
+
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+
+		result.add(imported)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, ImportRowsError
+	}
+
+	if input.DryRun || len(result.Rows) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.Repo.Import(ctx, importBatch{
+		organizationId: organizationId,
+		source:         importer.source,
+		rows:           result.Rows,
+	})
+
+	if err != nil {
+		return result, err
+	}
+
+	result.Rows = rows
+
+	s.Logger.Info(
+		"Imported ingredients",
+		"kind", input.Kind,
+		"created", result.Created,
+		"updated", result.Updated,
+		"prices", result.Prices,
+	)
+
+	return result, nil
+}
+
+// Turns rows into ingredients and prices, tracking the names seen so far
+type rowImporter struct {
+	organizationId int
+	kind           ImportKind
+	source         string
+	now            time.Time
+	registry       *units.Registry
+	ingredients    map[string]Ingredient // existing ingredients by lowercase name
+	seen           map[string]int        // line each catalog name was first seen on
+}
+
+func (r *rowImporter) row(row importRecord) (ImportRow, []ImportError) {
+	if r.kind == ImportInvoice {
+		return r.price(row)
+	}
+
+	return r.ingredient(row)
+}
+
+// Blank cells keep the values of an existing ingredient
+func (r *rowImporter) ingredient(row importRecord) (ImportRow, []ImportError) {
+	name := row.value("name")
+	key := strings.ToLower(name)
+
+	if line, ok := r.seen[key]; ok && name != "" {
+		return ImportRow{}, []ImportError{row.error("name", "%q is already imported on line %d", name, line)}
+	}
+
+	r.seen[key] = row.line
+
+	existing, exists := r.ingredients[key]
+
+	input := IngredientInput{
+		Name:          name,
+		Brand:         existing.Brand,
+		Vendor:        existing.Vendor,
+		Category:      existing.Category,
+		BrandId:       existing.BrandId,
+		VendorId:      existing.VendorId,
+		Unit:          existing.Unit,
+		UnitCount:     existing.UnitCount,
+		PurchasePrice: existing.PurchasePrice,
+		YieldPercent:  existing.YieldPercent,
+	}
+
+	for field, value := range map[string]*string{"brand": &input.Brand, "vendor": &input.Vendor, "category": &input.Category, "unit": &input.Unit} {
+		if cell := row.value(field); cell != "" {
+			*value = cell
+		}
+	}
+
+	var rowErrors []ImportError
+
+	for _, err := range []*ImportError{
+		row.float("unitCount", &input.UnitCount),
+		row.dollars("purchasePrice", &input.PurchasePrice),
+		row.float("yieldPercent", &input.YieldPercent),
+	} {
+		if err != nil {
+			rowErrors = append(rowErrors, *err)
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return ImportRow{}, rowErrors
+	}
+
+	if err := input.validate(); err != nil {
+		return ImportRow{}, []ImportError{row.error("", "%s", err)}
+	}
+
+	if rowErr := r.unit(row, existing.Unit, input.Unit); rowErr != nil {
+		return ImportRow{}, []ImportError{*rowErr}
+	}
+
+	ingredient := input.ingredient(r.organizationId)
+	ingredient.UpdatedAt = r.now
+
+	if !exists {
+		ingredient.CreatedAt = r.now
+
+		return ImportRow{Line: row.line, Action: ImportActionCreate, Ingredient: ingredient}, nil
+	}
+
+	ingredient.Id = existing.Id
+	ingredient.CreatedAt = existing.CreatedAt
+
+	return ImportRow{Line: row.line, Action: ImportActionUpdate, Ingredient: ingredient}, nil
+}
+
+// Unit and unit count default to the ingredient's current purchase
+func (r *rowImporter) price(row importRecord) (ImportRow, []ImportError) {
+	name := row.value("name")
+	ingredient, ok := r.ingredients[strings.ToLower(name)]
+
+	if !ok {
+		return ImportRow{}, []ImportError{row.error("name", "no ingredient named %q", name)}
+	}
+
+	price := Price{
+		IngredientId:  ingredient.Id,
+		Unit:          ingredient.Unit,
+		UnitCount:     ingredient.UnitCount,
+		PurchasePrice: -1,
+		Source:        r.source,
+		EffectiveAt:   r.now,
+		CreatedAt:     r.now,
+	}
+
+	if unit := row.value("unit"); unit != "" {
+		price.Unit = unit
+	}
+
+	if source := row.value("source"); source != "" {
+		price.Source = source
+	}
+
+	var rowErrors []ImportError
+
+	for _, err := range []*ImportError{
+		row.dollars("purchasePrice", &price.PurchasePrice),
+		row.float("unitCount", &price.UnitCount),
+		row.time("effectiveAt", &price.EffectiveAt),
+	} {
+		if err != nil {
+			rowErrors = append(rowErrors, *err)
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return ImportRow{}, rowErrors
+	}
+
+	if price.PurchasePrice < 0 {
+		return ImportRow{}, []ImportError{row.error("purchasePrice", "purchase price is required and can not be negative")}
+	}
+
+	if price.UnitCount <= 0 {
+		return ImportRow{}, []ImportError{row.error("unitCount", "unit count must be greater than 0")}
+	}
+
+	if rowErr := r.unit(row, ingredient.Unit, price.Unit); rowErr != nil {
+		return ImportRow{}, []ImportError{*rowErr}
+	}
+
+	price.CostPerUnit = UnitCost(price.PurchasePrice, price.UnitCount)
+
+	return ImportRow{Line: row.line, Action: ImportActionPrice, Ingredient: ingredient, Price: &price}, nil
+}
+
+// Checks unit against the organization's units. A new ingredient's unit must
+// be known and an existing ingredient's unit must convert to and from its
+// current unit
+func (r *rowImporter) unit(row importRecord, current string, unit string) *ImportError {
+	var err error
+
+	if current == "" {
+		_, err = r.registry.Lookup(unit)
+	} else {
+		err = convertsUnit(r.registry, current, unit)
+	}
+
+	if err != nil {
+		rowErr := row.error("unit", "%s", err)
+		return &rowErr
+	}
+
+	return nil
+}
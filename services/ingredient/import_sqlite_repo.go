@@ -0,0 +1,45 @@
+package ingredient
+
+import (
+	"context"
+	"fmt"
+)
+
+// Saves every row in a single transaction
+func (s *SQLiteRepo) Import(ctx context.Context, batch importBatch) ([]ImportRow, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	rows := make([]ImportRow, len(batch.rows))
+
+	for i, row := range batch.rows {
+		switch row.Action {
+		case ImportActionCreate:
+			row.Ingredient, err = createIngredientTx(ctx, tx, row.Ingredient, batch.source)
+
+		case ImportActionUpdate:
+			err = updateIngredientTx(ctx, tx, row.Ingredient, batch.source)
+
+		case ImportActionPrice:
+			var price Price
+			price, err = recordPriceTx(ctx, tx, *row.Price)
+			row.Price = &price
+
+		default:
+			err = fmt.Errorf("unknown import action %q", row.Action)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+
+		rows[i] = row
+	}
+
+	return rows, tx.Commit()
+}
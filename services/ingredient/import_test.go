@@ -0,0 +1,243 @@
+package ingredient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping("name=Item, purchasePrice = Case Price,")
+
+	if err != nil {
+		t.Fatalf("ParseMapping() = _, %s. want nil", err)
+	}
+
+	if mapping["name"] != "Item" || mapping["purchasePrice"] != "Case Price" || len(mapping) != 2 {
+		t.Errorf("ParseMapping() = %v. want name and purchasePrice", mapping)
+	}
+
+	if _, err := ParseMapping("name"); !errors.Is(err, InvalidImportError) {
+		t.Errorf("ParseMapping(no column) = _, %v. want %s", err, InvalidImportError)
+	}
+}
+
+func TestImportCatalog(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, _ := newTestService(t)
+
+	flour, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Flour", Category: "Dry", Unit: "lb", UnitCount: 50, PurchasePrice: 2000})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	file := "Item,Unit,Qty,Case Price,Yield\n" +
+		"flour,lb,50,\"$25.00\",\n" +
+		"\n" +
+		"Onion,lb,10,$8.50,90%\n"
+
+	input := ImportInput{
+		Kind:    ImportCatalog,
+		Mapping: Mapping{"name": "Item", "unitCount": "Qty", "purchasePrice": "Case Price", "yieldPercent": "Yield"},
+	}
+
+	dryRun := input
+	dryRun.DryRun = true
+
+	preview, err := service.Import(ctx, orgId, dryRun, strings.NewReader(file))
+
+	if err != nil {
+		t.Fatalf("Import(dry run) = _, %s. want nil", err)
+	}
+
+	if preview.Created != 1 || preview.Updated != 1 {
+		t.Errorf("Import(dry run) created %d and updated %d. want 1 and 1", preview.Created, preview.Updated)
+	}
+
+	if ingredients, _ := service.ListIngredients(ctx, orgId, Filter{}); len(ingredients) != 1 {
+		t.Errorf("len(ListIngredients()) after dry run = %d. want 1", len(ingredients))
+	}
+
+	result, err := service.Import(ctx, orgId, input, strings.NewReader(file))
+
+	if err != nil {
+		t.Fatalf("Import() = _, %s. want nil", err)
+	}
+
+	if result.Rows[0].Line != 2 || result.Rows[1].Line != 4 {
+		t.Errorf("Import() lines = %d, %d. want 2, 4", result.Rows[0].Line, result.Rows[1].Line)
+	}
+
+	updated, err := service.GetIngredient(ctx, orgId, flour.Id)
+
+	if err != nil {
+		t.Fatalf("GetIngredient() = _, %s. want nil", err)
+	}
+
+	if updated.PurchasePrice != 2500 || updated.Category != "Dry" {
+		t.Errorf("GetIngredient() = %d in %q. want 2500 in %q", updated.PurchasePrice, updated.Category, "Dry")
+	}
+
+	prices, err := service.Prices(ctx, orgId, flour.Id)
+
+	if err != nil {
+		t.Fatalf("Prices() = _, %s. want nil", err)
+	}
+
+	if last := prices[len(prices)-1]; last.Source != PriceSourceImport {
+		t.Errorf("Prices()[last].Source = %q. want %q", last.Source, PriceSourceImport)
+	}
+
+	onion, err := service.GetIngredient(ctx, orgId, result.Rows[1].Ingredient.Id)
+
+	if err != nil {
+		t.Fatalf("GetIngredient(onion) = _, %s. want nil", err)
+	}
+
+	if onion.PurchasePrice != 850 || onion.YieldPercent != 90 {
+		t.Errorf("GetIngredient(onion) = %d at %f%%. want 850 at 90%%", onion.PurchasePrice, onion.YieldPercent)
+	}
+}
+
+func TestImportRowErrors(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, _ := newTestService(t)
+
+	file := "name,unit,unitCount,purchasePrice\n" +
+		"Flour,lb,50,20\n" +
+		",lb,1,1\n" +
+		"Sugar,lb,many,1\n" +
+		"flour,lb,25,10\n" +
+		"Salt,sack,1,1\n"
+
+	result, err := service.Import(ctx, orgId, ImportInput{Kind: ImportCatalog}, strings.NewReader(file))
+
+	if !errors.Is(err, ImportRowsError) {
+		t.Fatalf("Import() = _, %v. want %s", err, ImportRowsError)
+	}
+
+	expected := []ImportError{
+		{Line: 3},
+		{Line: 4, Column: "unitCount"},
+		{Line: 5, Column: "name"},
+		{Line: 6, Column: "unit"},
+	}
+
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Import().Errors = %v. want %d errors", result.Errors, len(expected))
+	}
+
+	for i, rowErr := range result.Errors {
+		if rowErr.Line != expected[i].Line || rowErr.Column != expected[i].Column {
+			t.Errorf("Import().Errors[%d] = %+v. want line %d column %q", i, rowErr, expected[i].Line, expected[i].Column)
+		}
+	}
+
+	if ingredients, _ := service.ListIngredients(ctx, orgId, Filter{}); len(ingredients) != 0 {
+		t.Errorf("len(ListIngredients()) = %d. want 0", len(ingredients))
+	}
+
+	tests := []struct {
+		test  string
+		input ImportInput
+		file  string
+	}{
+		{"unknown kind", ImportInput{Kind: "menu"}, "name\n"},
+		{"missing required column", ImportInput{Kind: ImportCatalog}, "name,unit\n"},
+		{"missing mapped column", ImportInput{Kind: ImportInvoice, Mapping: Mapping{"source": "Invoice"}}, "name,purchasePrice\n"},
+		{"unknown field", ImportInput{Kind: ImportInvoice, Mapping: Mapping{"color": "Color"}}, "name,purchasePrice,Color\n"},
+		{"empty file", ImportInput{Kind: ImportInvoice}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := service.Import(ctx, orgId, test.input, strings.NewReader(test.file))
+
+			if !errors.Is(err, InvalidImportError) {
+				t.Errorf("Import() = _, %v. want %s", err, InvalidImportError)
+			}
+		})
+	}
+}
+
+func TestImportInvoice(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, _ := newTestService(t)
+
+	cups, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Cups", Unit: "cup", UnitCount: 1000, PurchasePrice: 4500})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	file := "name,purchasePrice,effectiveAt\n" +
+		"Cups,$48.00,2025-01-01\n" +
+		"Lids,$10.00,2025-01-01\n"
+
+	input := ImportInput{Kind: ImportInvoice, Source: "INV-1001"}
+
+	result, err := service.Import(ctx, orgId, input, strings.NewReader(file))
+
+	if !errors.Is(err, ImportRowsError) {
+		t.Fatalf("Import(unknown ingredient) = _, %v. want %s", err, ImportRowsError)
+	}
+
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Import(unknown ingredient).Errors = %v. want line 3", result.Errors)
+	}
+
+	// a dry run reports units that do not convert to the ingredient's unit
+	file = "name,unit,purchasePrice\n" +
+		"Cups,lb,$48.00\n"
+
+	result, err = service.Import(ctx, orgId, ImportInput{Kind: ImportInvoice, DryRun: true}, strings.NewReader(file))
+
+	if !errors.Is(err, ImportRowsError) {
+		t.Fatalf("Import(incompatible unit) = _, %v. want %s", err, ImportRowsError)
+	}
+
+	if len(result.Errors) != 1 || result.Errors[0].Column != "unit" {
+		t.Errorf("Import(incompatible unit).Errors = %v. want a unit error", result.Errors)
+	}
+
+	file = "name,purchasePrice,effectiveAt\n" +
+		"Cups,$48.00,2025-01-01\n"
+
+	if _, err := service.Import(ctx, orgId, input, strings.NewReader(file)); err != nil {
+		t.Fatalf("Import() = _, %s. want nil", err)
+	}
+
+	prices, err := service.Prices(ctx, orgId, cups.Id)
+
+	if err != nil {
+		t.Fatalf("Prices() = _, %s. want nil", err)
+	}
+
+	if len(prices) != 2 {
+		t.Fatalf("len(Prices()) = %d. want 2", len(prices))
+	}
+
+	imported := prices[0]
+
+	if !imported.EffectiveAt.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Prices()[0].EffectiveAt = %s. want 2025-01-01", imported.EffectiveAt)
+	}
+
+	if imported.Source != "INV-1001" || imported.PurchasePrice != 4800 || imported.UnitCount != 1000 {
+		t.Errorf("Prices()[0] = %+v. want $48.00 for 1000 from INV-1001", imported)
+	}
+
+	// the ingredient was created after the invoice so its own price stays current
+	current, err := service.GetIngredient(ctx, orgId, cups.Id)
+
+	if err != nil {
+		t.Fatalf("GetIngredient() = _, %s. want nil", err)
+	}
+
+	if current.PurchasePrice != 4500 {
+		t.Errorf("GetIngredient().PurchasePrice = %d. want 4500", current.PurchasePrice)
+	}
+}
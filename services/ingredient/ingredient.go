@@ -0,0 +1,323 @@
+package ingredient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/units"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	IngredientNotFoundError = errors.New("Ingredient not found")
+	InvalidIngredientError  = errors.New("Invalid ingredient")
+	IngredientInUseError    = errors.New("Ingredient is in use")
+)
+
+// Sources of recorded prices. Any other source, like an invoice number, can be
+// given when recording a price
+const (
+	PriceSourceInitial = "initial"
+	PriceSourceManual  = "manual"
+)
+
+// UnitRegistries provides the units available to an organization
+type UnitRegistries interface {
+	Registry(context.Context, int) (*units.Registry, error)
+}
+
+// Provides only the standard units to every organization
+type StandardUnits struct{}
+
+func (StandardUnits) Registry(context.Context, int) (*units.Registry, error) {
+	return units.Default(), nil
+}
+
+type Service struct {
+	Repo   Repo
+	Units  UnitRegistries
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Units:  StandardUnits{},
+		Logger: logging.Logger(logger, slog.String("service", "ingredient")),
+		Now:    common.TimeNow{},
+	}
+}
+
+type IngredientInput struct {
+	Name          string
+	Brand         string
+	Vendor        string
+	Category      string
+	BrandId       *int
+	VendorId      *int
+	Unit          string
+	UnitCount     float64
+	PurchasePrice int64
+	YieldPercent  float64
+}
+
+func (i IngredientInput) validate() error {
+	if strings.TrimSpace(i.Name) == "" {
+		return fmt.Errorf("%w: name is required", InvalidIngredientError)
+	}
+
+	if strings.TrimSpace(i.Unit) == "" {
+		return fmt.Errorf("%w: unit is required", InvalidIngredientError)
+	}
+
+	if i.UnitCount <= 0 {
+		return fmt.Errorf("%w: unit count must be greater than 0", InvalidIngredientError)
+	}
+
+	if i.PurchasePrice < 0 {
+		return fmt.Errorf("%w: purchase price can not be negative", InvalidIngredientError)
+	}
+
+	if i.YieldPercent < 0 || i.YieldPercent > 100 {
+		return fmt.Errorf("%w: yield percent must be between 0 and 100", InvalidIngredientError)
+	}
+
+	return nil
+}
+
+func (i IngredientInput) ingredient(organizationId int) Ingredient {
+	return Ingredient{
+		OrganizationId: organizationId,
+		Name:           strings.TrimSpace(i.Name),
+		Brand:          strings.TrimSpace(i.Brand),
+		Vendor:         strings.TrimSpace(i.Vendor),
+		Category:       strings.TrimSpace(i.Category),
+		BrandId:        i.BrandId,
+		VendorId:       i.VendorId,
+		Unit:           strings.TrimSpace(i.Unit),
+		UnitCount:      i.UnitCount,
+		PurchasePrice:  i.PurchasePrice,
+		CostPerUnit:    UnitCost(i.PurchasePrice, i.UnitCount),
+		YieldPercent:   i.yieldPercent(),
+	}
+}
+
+// A zero YieldPercent means the whole purchase is usable
+func (i IngredientInput) yieldPercent() float64 {
+	if i.YieldPercent == 0 {
+		return 100
+	}
+
+	return i.YieldPercent
+}
+
+func (s *Service) CreateIngredient(ctx context.Context, organizationId int, input IngredientInput) (Ingredient, error) {
+	if err := input.validate(); err != nil {
+		return Ingredient{}, err
+	}
+
+	ingredient := input.ingredient(organizationId)
+	ingredient.CreatedAt = s.Now.Now()
+	ingredient.UpdatedAt = ingredient.CreatedAt
+
+	return s.Repo.CreateIngredient(ctx, ingredient)
+}
+
+func (s *Service) GetIngredient(ctx context.Context, organizationId int, id int) (Ingredient, error) {
+	return s.Repo.GetIngredient(ctx, organizationId, id, s.Now.Now())
+}
+
+// Returns InvalidIngredientError when the unit can not be converted to and
+// from the ingredient's current unit
+func (s *Service) UpdateIngredient(ctx context.Context, organizationId int, id int, input IngredientInput) (Ingredient, error) {
+	if err := input.validate(); err != nil {
+		return Ingredient{}, err
+	}
+
+	now := s.Now.Now()
+
+	current, err := s.Repo.GetIngredient(ctx, organizationId, id, now)
+
+	if err != nil {
+		return Ingredient{}, err
+	}
+
+	ingredient := input.ingredient(organizationId)
+	ingredient.Id = id
+	ingredient.UpdatedAt = now
+
+	if err := s.checkUnit(ctx, organizationId, current.Unit, ingredient.Unit); err != nil {
+		return Ingredient{}, err
+	}
+
+	return s.Repo.UpdateIngredient(ctx, ingredient)
+}
+
+func (s *Service) checkUnit(ctx context.Context, organizationId int, current string, unit string) error {
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	if err := convertsUnit(registry, current, unit); err != nil {
+		return fmt.Errorf("%w: %w", InvalidIngredientError, err)
+	}
+
+	return nil
+}
+
+// Returns an error unless unit converts to and from the current unit. Recipe
+// lines and counts are converted into the ingredient's unit, so a new unit
+// must measure the same dimension as the old one
+func convertsUnit(registry *units.Registry, current string, unit string) error {
+	if _, err := registry.Convert(1, unit, current); err != nil {
+		return err
+	}
+
+	_, err := registry.Convert(1, current, unit)
+
+	return err
+}
+
+// Returns IngredientInUseError when a product or prep recipe uses it or
+// inventory counts or purchases of it are recorded
+func (s *Service) DeleteIngredient(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteIngredient(ctx, organizationId, id)
+}
+
+func (s *Service) ListIngredients(ctx context.Context, organizationId int, filter Filter) ([]Ingredient, error) {
+	return s.Repo.ListIngredients(ctx, organizationId, filter, s.Now.Now())
+}
+
+// Sets the tags of an ingredient, replacing the tags set before
+func (s *Service) SetIngredientTags(ctx context.Context, organizationId int, ingredientId int, tagIds []int) ([]int, error) {
+	tagIds = append([]int{}, tagIds...)
+	slices.Sort(tagIds)
+	tagIds = slices.Compact(tagIds)
+
+	if err := s.Repo.SetIngredientTags(ctx, organizationId, ingredientId, tagIds); err != nil {
+		return nil, err
+	}
+
+	return tagIds, nil
+}
+
+// Returns the ids of the tags of an ingredient
+func (s *Service) ListIngredientTags(ctx context.Context, organizationId int, ingredientId int) ([]int, error) {
+	if _, err := s.Repo.GetIngredient(ctx, organizationId, ingredientId, s.Now.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListIngredientTags(ctx, organizationId, ingredientId)
+}
+
+type PriceInput struct {
+	PurchasePrice int64
+	UnitCount     float64
+	Unit          string
+	Source        string
+	EffectiveAt   time.Time
+}
+
+// Records a purchase price for an ingredient. Unit and UnitCount default to
+// the ingredient's current purchase, Source defaults to PriceSourceManual and
+// EffectiveAt defaults to now. The ingredient's current price is the recorded
+// price while it is the latest one in effect
+func (s *Service) RecordPrice(ctx context.Context, organizationId int, ingredientId int, input PriceInput) (Price, error) {
+	now := s.Now.Now()
+
+	ingredient, err := s.Repo.GetIngredient(ctx, organizationId, ingredientId, now)
+
+	if err != nil {
+		return Price{}, err
+	}
+
+	price := Price{
+		IngredientId:  ingredientId,
+		Unit:          strings.TrimSpace(input.Unit),
+		UnitCount:     input.UnitCount,
+		PurchasePrice: input.PurchasePrice,
+		Source:        strings.TrimSpace(input.Source),
+		EffectiveAt:   input.EffectiveAt,
+		CreatedAt:     now,
+	}
+
+	if price.Unit == "" {
+		price.Unit = ingredient.Unit
+	}
+
+	if price.UnitCount == 0 {
+		price.UnitCount = ingredient.UnitCount
+	}
+
+	if price.Source == "" {
+		price.Source = PriceSourceManual
+	}
+
+	if price.EffectiveAt.IsZero() {
+		price.EffectiveAt = now
+	}
+
+	if price.UnitCount <= 0 {
+		return Price{}, fmt.Errorf("%w: unit count must be greater than 0", InvalidIngredientError)
+	}
+
+	if price.PurchasePrice < 0 {
+		return Price{}, fmt.Errorf("%w: purchase price can not be negative", InvalidIngredientError)
+	}
+
+	if err := s.checkUnit(ctx, organizationId, ingredient.Unit, price.Unit); err != nil {
+		return Price{}, err
+	}
+
+	price, err = s.Repo.RecordPrice(ctx, recordPrice{
+		organizationId: organizationId,
+		price:          price,
+	})
+
+	if err != nil {
+		return price, err
+	}
+
+	s.Logger.Info("Recorded price", "ingredient", ingredientId, "price", price.PurchasePrice, "source", price.Source)
+
+	return price, nil
+}
+
+// Prices returns the ingredient's price timeline, oldest first, with each
+// price compared to the one before it
+func (s *Service) Prices(ctx context.Context, organizationId int, ingredientId int) ([]Price, error) {
+	if _, err := s.Repo.GetIngredient(ctx, organizationId, ingredientId, s.Now.Now()); err != nil {
+		return nil, err
+	}
+
+	prices, err := s.Repo.ListPrices(ctx, organizationId, ingredientId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range prices {
+		prices[i].CostPerUnit = UnitCost(prices[i].PurchasePrice, prices[i].UnitCount)
+
+		if i == 0 || prices[i-1].Unit != prices[i].Unit {
+			continue
+		}
+
+		previous := prices[i-1].CostPerUnit
+		prices[i].Change = prices[i].CostPerUnit - previous
+
+		if previous != 0 {
+			prices[i].ChangePercent = prices[i].Change / previous * 100
+		}
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,665 @@
+package ingredient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moon-cost/common"
+	"moon-cost/services/tag"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+// An ingredient's purchase is read from currentPriceJoin so it always
+// reflects the price in effect when it is read
+const ingredientColumns = `
+  ingredients.id,
+  ingredients.organizationId,
+  ingredients.name,
+  COALESCE(ingredients.brand, ''),
+  COALESCE(ingredients.vendor, ''),
+  COALESCE(ingredients.category, ''),
+  ingredients.brandId,
+  ingredients.vendorId,
+  prices.unit,
+  prices.unitCount,
+  prices.purchasePrice,
+  ingredients.yieldPercent,
+  ingredients.createdAt,
+  ingredients.updatedAt
+`
+
+// Joins each ingredient's latest price in effect at a time, falling back to
+// its earliest price when it had none yet
+const currentPriceJoin = `
+JOIN ingredient_prices AS prices ON prices.id = COALESCE(
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id AND effectiveAt <= ?
+    ORDER BY effectiveAt DESC, id DESC
+    LIMIT 1
+  ),
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id
+    ORDER BY effectiveAt ASC, id ASC
+    LIMIT 1
+  )
+)`
+
+func scanIngredient(row common.Scanner) (Ingredient, error) {
+	var ingredient Ingredient
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&ingredient.Id,
+		&ingredient.OrganizationId,
+		&ingredient.Name,
+		&ingredient.Brand,
+		&ingredient.Vendor,
+		&ingredient.Category,
+		&ingredient.BrandId,
+		&ingredient.VendorId,
+		&ingredient.Unit,
+		&ingredient.UnitCount,
+		&ingredient.PurchasePrice,
+		&ingredient.YieldPercent,
+		&createdAt,
+		&updatedAt,
+	)
+
+	ingredient.CreatedAt = time.UnixMilli(createdAt)
+	ingredient.UpdatedAt = time.UnixMilli(updatedAt)
+	ingredient.CostPerUnit = UnitCost(ingredient.PurchasePrice, ingredient.UnitCount)
+
+	return ingredient, err
+}
+
+// The purchase columns keep the ingredient's first purchase. Later purchases
+// are only recorded in ingredient_prices
+const createIngredientQuery = `
+INSERT INTO ingredients (
+  organizationId,
+  name,
+  brand,
+  vendor,
+  category,
+  brandId,
+  vendorId,
+  unit,
+  unitCount,
+  purchasePrice,
+  yieldPercent,
+  createdAt,
+  updatedAt
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Returns InvalidIngredientError when the ingredient's brand does not exist or
+// its vendor is not linked to the organization
+func checkReferences(ctx context.Context, tx *sql.Tx, ingredient Ingredient) error {
+	var count int
+
+	if ingredient.BrandId != nil {
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM brands WHERE id = ?`, *ingredient.BrandId).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return fmt.Errorf("%w: brand %d does not exist", InvalidIngredientError, *ingredient.BrandId)
+		}
+	}
+
+	if ingredient.VendorId != nil {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT COUNT(*) FROM organization_vendors WHERE organizationId = ? AND vendorId = ?`,
+			ingredient.OrganizationId,
+			*ingredient.VendorId,
+		).Scan(&count)
+
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			return fmt.Errorf("%w: vendor %d is not linked to the organization", InvalidIngredientError, *ingredient.VendorId)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLiteRepo) CreateIngredient(ctx context.Context, ingredient Ingredient) (Ingredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return ingredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := checkReferences(ctx, tx, ingredient); err != nil {
+		return ingredient, err
+	}
+
+	if ingredient, err = createIngredientTx(ctx, tx, ingredient, PriceSourceInitial); err != nil {
+		return ingredient, err
+	}
+
+	return ingredient, tx.Commit()
+}
+
+// Inserts the ingredient along with its first price from source
+func createIngredientTx(ctx context.Context, tx *sql.Tx, ingredient Ingredient, source string) (Ingredient, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		createIngredientQuery,
+		ingredient.OrganizationId,
+		ingredient.Name,
+		ingredient.Brand,
+		ingredient.Vendor,
+		ingredient.Category,
+		ingredient.BrandId,
+		ingredient.VendorId,
+		ingredient.Unit,
+		ingredient.UnitCount,
+		ingredient.PurchasePrice,
+		ingredient.YieldPercent,
+		ingredient.CreatedAt.UnixMilli(),
+		ingredient.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return ingredient, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return ingredient, err
+	}
+
+	ingredient.Id = int(id)
+
+	_, err = insertPrice(ctx, tx, Price{
+		IngredientId:  ingredient.Id,
+		Unit:          ingredient.Unit,
+		UnitCount:     ingredient.UnitCount,
+		PurchasePrice: ingredient.PurchasePrice,
+		Source:        source,
+		EffectiveAt:   ingredient.CreatedAt,
+		CreatedAt:     ingredient.CreatedAt,
+	})
+
+	return ingredient, err
+}
+
+const getIngredientQuery = `
+SELECT ` + ingredientColumns + `
+FROM ingredients
+` + currentPriceJoin + `
+WHERE ingredients.organizationId = ? AND ingredients.id = ?
+`
+
+func (s *SQLiteRepo) GetIngredient(ctx context.Context, organizationId int, id int, at time.Time) (Ingredient, error) {
+	ingredient, err := scanIngredient(s.db.QueryRowContext(ctx, getIngredientQuery, at.UnixMilli(), organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ingredient, IngredientNotFoundError
+	}
+
+	return ingredient, err
+}
+
+const updateIngredientQuery = `
+UPDATE ingredients
+SET
+  name = ?,
+  brand = ?,
+  vendor = ?,
+  category = ?,
+  brandId = ?,
+  vendorId = ?,
+  yieldPercent = ?,
+  updatedAt = ?
+WHERE organizationId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdateIngredient(ctx context.Context, ingredient Ingredient) (Ingredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return ingredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := checkReferences(ctx, tx, ingredient); err != nil {
+		return ingredient, err
+	}
+
+	if err := updateIngredientTx(ctx, tx, ingredient, PriceSourceManual); err != nil {
+		return ingredient, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ingredient, err
+	}
+
+	return s.GetIngredient(ctx, ingredient.OrganizationId, ingredient.Id, ingredient.UpdatedAt)
+}
+
+// Updates the ingredient and records a price from source when its purchase
+// differs from the price in effect at its update
+func updateIngredientTx(ctx context.Context, tx *sql.Tx, ingredient Ingredient, source string) error {
+	previous, err := scanIngredient(tx.QueryRowContext(
+		ctx,
+		getIngredientQuery,
+		ingredient.UpdatedAt.UnixMilli(),
+		ingredient.OrganizationId,
+		ingredient.Id,
+	))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return IngredientNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		updateIngredientQuery,
+		ingredient.Name,
+		ingredient.Brand,
+		ingredient.Vendor,
+		ingredient.Category,
+		ingredient.BrandId,
+		ingredient.VendorId,
+		ingredient.YieldPercent,
+		ingredient.UpdatedAt.UnixMilli(),
+		ingredient.OrganizationId,
+		ingredient.Id,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, IngredientNotFoundError); err != nil {
+		return err
+	}
+
+	priceChanged := previous.Unit != ingredient.Unit ||
+		previous.UnitCount != ingredient.UnitCount ||
+		previous.PurchasePrice != ingredient.PurchasePrice
+
+	if !priceChanged {
+		return nil
+	}
+
+	_, err = insertPrice(ctx, tx, Price{
+		IngredientId:  ingredient.Id,
+		Unit:          ingredient.Unit,
+		UnitCount:     ingredient.UnitCount,
+		PurchasePrice: ingredient.PurchasePrice,
+		Source:        source,
+		EffectiveAt:   ingredient.UpdatedAt,
+		CreatedAt:     ingredient.UpdatedAt,
+	})
+
+	return err
+}
+
+const ingredientInUseQuery = `
+SELECT
+  EXISTS (SELECT 1 FROM product_ingredients WHERE ingredientId = ?)
+  OR EXISTS (SELECT 1 FROM prep_recipe_ingredients WHERE ingredientId = ?)
+  OR EXISTS (SELECT 1 FROM inventory_count_items WHERE ingredientId = ?)
+  OR EXISTS (SELECT 1 FROM inventory_purchases WHERE ingredientId = ?)
+`
+
+// Ingredients used by a product or prep recipe or with recorded counts or
+// purchases can not be deleted
+func (s *SQLiteRepo) DeleteIngredient(ctx context.Context, organizationId int, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return IngredientNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var inUse bool
+
+	if err := tx.QueryRowContext(ctx, ingredientInUseQuery, id, id, id, id).Scan(&inUse); err != nil {
+		return err
+	}
+
+	if inUse {
+		return IngredientInUseError
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM ingredients WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, IngredientNotFoundError); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const listIngredientsQuery = `
+SELECT ` + ingredientColumns + `
+FROM ingredients
+` + currentPriceJoin + `
+WHERE ingredients.organizationId = ?`
+
+// Appends a condition to query for each field set on filter
+func (f Filter) where(query string, args []any) (string, []any) {
+	if f.VendorId != 0 {
+		query += ` AND ingredients.vendorId = ?`
+		args = append(args, f.VendorId)
+	}
+
+	if f.BrandId != 0 {
+		query += ` AND ingredients.brandId = ?`
+		args = append(args, f.BrandId)
+	}
+
+	if len(f.TagIds) > 0 {
+		tags, tagArgs := common.InList(f.TagIds)
+		query += ` AND ingredients.id IN (SELECT ingredientId FROM ingredient_tags WHERE tagId IN (` + tags + `))`
+		args = append(args, tagArgs...)
+	}
+
+	return query, args
+}
+
+func (s *SQLiteRepo) ListIngredients(ctx context.Context, organizationId int, filter Filter, at time.Time) ([]Ingredient, error) {
+	query, args := filter.where(listIngredientsQuery, []any{at.UnixMilli(), organizationId})
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY ingredients.name ASC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ingredients := []Ingredient{}
+
+	for rows.Next() {
+		ingredient, err := scanIngredient(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, rows.Err()
+}
+
+// Replaces the ingredient's tags. Every tag must be an ingredient tag of the
+// organization
+func (s *SQLiteRepo) SetIngredientTags(ctx context.Context, organizationId int, ingredientId int, tagIds []int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		ingredientId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return IngredientNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingredient_tags WHERE ingredientId = ?`, ingredientId); err != nil {
+		return err
+	}
+
+	for _, tagId := range tagIds {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT 1 FROM org_tags WHERE organizationId = ? AND id = ? AND type = ?`,
+			organizationId,
+			tagId,
+			tag.TypeIngredient,
+		).Scan(&exists)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return tag.TagNotFoundError
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO ingredient_tags (ingredientId, tagId) VALUES (?, ?)`, ingredientId, tagId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const listIngredientTagsQuery = `
+SELECT ingredient_tags.tagId
+FROM ingredient_tags
+JOIN ingredients ON ingredients.id = ingredient_tags.ingredientId
+WHERE ingredients.organizationId = ? AND ingredients.id = ?
+ORDER BY ingredient_tags.tagId ASC
+`
+
+func (s *SQLiteRepo) ListIngredientTags(ctx context.Context, organizationId int, ingredientId int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, listIngredientTagsQuery, organizationId, ingredientId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tagIds := []int{}
+
+	for rows.Next() {
+		var tagId int
+
+		if err := rows.Scan(&tagId); err != nil {
+			return nil, err
+		}
+
+		tagIds = append(tagIds, tagId)
+	}
+
+	return tagIds, rows.Err()
+}
+
+const insertPriceQuery = `
+INSERT INTO ingredient_prices (
+  ingredientId,
+  unit,
+  unitCount,
+  purchasePrice,
+  source,
+  effectiveAt,
+  createdAt
+)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+func insertPrice(ctx context.Context, tx *sql.Tx, price Price) (int, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		insertPriceQuery,
+		price.IngredientId,
+		price.Unit,
+		price.UnitCount,
+		price.PurchasePrice,
+		price.Source,
+		price.EffectiveAt.UnixMilli(),
+		price.CreatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+
+	return int(id), err
+}
+
+func (s *SQLiteRepo) RecordPrice(ctx context.Context, input recordPrice) (Price, error) {
+	price := input.price
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return price, err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM ingredients WHERE organizationId = ? AND id = ?`,
+		input.organizationId,
+		price.IngredientId,
+	).Scan(&exists)
+
+	if err != nil {
+		return price, err
+	}
+
+	if exists == 0 {
+		return price, IngredientNotFoundError
+	}
+
+	if price, err = recordPriceTx(ctx, tx, price); err != nil {
+		return price, err
+	}
+
+	return price, tx.Commit()
+}
+
+func recordPriceTx(ctx context.Context, tx *sql.Tx, price Price) (Price, error) {
+	var err error
+
+	if price.Id, err = insertPrice(ctx, tx, price); err != nil {
+		return price, err
+	}
+
+	price.CostPerUnit = UnitCost(price.PurchasePrice, price.UnitCount)
+
+	return price, nil
+}
+
+const listPricesQuery = `
+SELECT
+  ingredient_prices.id,
+  ingredient_prices.ingredientId,
+  ingredient_prices.unit,
+  ingredient_prices.unitCount,
+  ingredient_prices.purchasePrice,
+  ingredient_prices.source,
+  ingredient_prices.effectiveAt,
+  ingredient_prices.createdAt
+FROM ingredient_prices
+JOIN ingredients ON ingredients.id = ingredient_prices.ingredientId
+WHERE ingredients.organizationId = ? AND ingredients.id = ?
+ORDER BY ingredient_prices.effectiveAt ASC, ingredient_prices.id ASC
+`
+
+func (s *SQLiteRepo) ListPrices(ctx context.Context, organizationId int, ingredientId int) ([]Price, error) {
+	rows, err := s.db.QueryContext(ctx, listPricesQuery, organizationId, ingredientId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	prices := []Price{}
+
+	for rows.Next() {
+		var price Price
+		var effectiveAt, createdAt int64
+
+		err := rows.Scan(
+			&price.Id,
+			&price.IngredientId,
+			&price.Unit,
+			&price.UnitCount,
+			&price.PurchasePrice,
+			&price.Source,
+			&effectiveAt,
+			&createdAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		price.EffectiveAt = time.UnixMilli(effectiveAt)
+		price.CreatedAt = time.UnixMilli(createdAt)
+
+		prices = append(prices, price)
+	}
+
+	return prices, rows.Err()
+}
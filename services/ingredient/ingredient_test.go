@@ -0,0 +1,444 @@
+package ingredient
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"moon-cost/common"
+	"moon-cost/moontest"
+	"moon-cost/services/tag"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) (*Service, int, int) {
+	db := moontest.LoadTestDB(t)
+
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	otherOrgId := moontest.InsertTestOrganization(t, db, "Other Cafe")
+
+	return NewService(NewSQLiteRepo(db), slog.New(slog.DiscardHandler)), orgId, otherOrgId
+}
+
+func TestUnitCost(t *testing.T) {
+	tests := []struct {
+		price    int64
+		count    float64
+		expected float64
+	}{
+		{price: 4500, count: 1000, expected: 4.5},
+		{price: 250, count: 1, expected: 250},
+		{price: 1000, count: 0, expected: 0},
+	}
+
+	for _, test := range tests {
+		cost := UnitCost(test.price, test.count)
+
+		if cost != test.expected {
+			t.Errorf("UnitCost(%d, %f) = %f. want %f", test.price, test.count, cost, test.expected)
+		}
+	}
+}
+
+func TestIngredientCRUD(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, otherOrgId := newTestService(t)
+
+	cups := IngredientInput{
+		Name:          "16oz Cups",
+		Vendor:        "Cup Co",
+		Category:      "Disposables",
+		Unit:          "cup",
+		UnitCount:     1000,
+		PurchasePrice: 4500,
+	}
+
+	created, err := service.CreateIngredient(ctx, orgId, cups)
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	fetched, err := service.GetIngredient(ctx, orgId, created.Id)
+
+	if err != nil {
+		t.Fatalf("GetIngredient() = _, %s. want nil", err)
+	}
+
+	if fetched.CostPerUnit != 4.5 {
+		t.Errorf("GetIngredient().CostPerUnit = %f. want %f", fetched.CostPerUnit, 4.5)
+	}
+
+	if fetched.YieldPercent != 100 {
+		t.Errorf("GetIngredient().YieldPercent = %f. want %f", fetched.YieldPercent, 100.0)
+	}
+
+	if _, err := service.GetIngredient(ctx, otherOrgId, created.Id); !errors.Is(err, IngredientNotFoundError) {
+		t.Errorf("GetIngredient(other org) = _, %v. want %s", err, IngredientNotFoundError)
+	}
+
+	cups.PurchasePrice = 5000
+
+	updated, err := service.UpdateIngredient(ctx, orgId, created.Id, cups)
+
+	if err != nil {
+		t.Fatalf("UpdateIngredient() = _, %s. want nil", err)
+	}
+
+	if updated.CostPerUnit != 5 {
+		t.Errorf("UpdateIngredient().CostPerUnit = %f. want %f", updated.CostPerUnit, 5.0)
+	}
+
+	ingredients, err := service.ListIngredients(ctx, orgId, Filter{})
+
+	if err != nil {
+		t.Fatalf("ListIngredients() = _, %s. want nil", err)
+	}
+
+	if len(ingredients) != 1 {
+		t.Errorf("len(ListIngredients()) = %d. want 1", len(ingredients))
+	}
+
+	if err := service.DeleteIngredient(ctx, orgId, created.Id); err != nil {
+		t.Errorf("DeleteIngredient() = %s. want nil", err)
+	}
+
+	if err := service.DeleteIngredient(ctx, orgId, created.Id); !errors.Is(err, IngredientNotFoundError) {
+		t.Errorf("DeleteIngredient(deleted) = %v. want %s", err, IngredientNotFoundError)
+	}
+}
+
+func TestIngredientValidation(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, _ := newTestService(t)
+
+	tests := []struct {
+		test  string
+		input IngredientInput
+	}{
+		{test: "blank name", input: IngredientInput{Unit: "g", UnitCount: 1}},
+		{test: "blank unit", input: IngredientInput{Name: "Flour", UnitCount: 1}},
+		{test: "zero unit count", input: IngredientInput{Name: "Flour", Unit: "g"}},
+		{test: "negative price", input: IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1, PurchasePrice: -1}},
+		{test: "negative yield", input: IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1, YieldPercent: -1}},
+		{test: "yield over 100", input: IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1, YieldPercent: 101}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := service.CreateIngredient(ctx, orgId, test.input)
+
+			if !errors.Is(err, InvalidIngredientError) {
+				t.Errorf("CreateIngredient() = _, %v. want %s", err, InvalidIngredientError)
+			}
+		})
+	}
+}
+
+func TestIngredientUnitChanges(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, _ := newTestService(t)
+
+	flour, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		unit  string
+		valid bool
+	}{
+		{unit: "G", valid: true},
+		{unit: "lb", valid: true},
+		{unit: "cup", valid: false},
+		{unit: "sack", valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.unit, func(t *testing.T) {
+			_, err := service.RecordPrice(ctx, orgId, flour.Id, PriceInput{PurchasePrice: 200, Unit: test.unit})
+
+			if test.valid && err != nil {
+				t.Errorf("RecordPrice(%s) = _, %s. want nil", test.unit, err)
+			}
+
+			if !test.valid && !errors.Is(err, InvalidIngredientError) {
+				t.Errorf("RecordPrice(%s) = _, %v. want %s", test.unit, err, InvalidIngredientError)
+			}
+
+			input := IngredientInput{Name: "Flour", Unit: test.unit, UnitCount: 1, PurchasePrice: 200}
+			_, err = service.UpdateIngredient(ctx, orgId, flour.Id, input)
+
+			if test.valid && err != nil {
+				t.Errorf("UpdateIngredient(%s) = _, %s. want nil", test.unit, err)
+			}
+
+			if !test.valid && !errors.Is(err, InvalidIngredientError) {
+				t.Errorf("UpdateIngredient(%s) = _, %v. want %s", test.unit, err, InvalidIngredientError)
+			}
+		})
+	}
+}
+
+func TestPriceHistory(t *testing.T) {
+	ctx := context.Background()
+	service, orgId, otherOrgId := newTestService(t)
+
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	service.Now = common.TestNow{Time: january}
+
+	flour, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service.Now = common.TestNow{Time: january.AddDate(0, 1, 0)}
+
+	input := IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 300}
+
+	if _, err := service.UpdateIngredient(ctx, orgId, flour.Id, input); err != nil {
+		t.Fatalf("UpdateIngredient() = _, %s. want nil", err)
+	}
+
+	// updating without changing the price does not record a price
+	if _, err := service.UpdateIngredient(ctx, orgId, flour.Id, input); err != nil {
+		t.Fatalf("UpdateIngredient() = _, %s. want nil", err)
+	}
+
+	service.Now = common.TestNow{Time: january.AddDate(0, 3, 0)}
+
+	// a backdated invoice is recorded but the newer price stays current
+	_, err = service.RecordPrice(ctx, orgId, flour.Id, PriceInput{PurchasePrice: 250, Source: "INV-1", EffectiveAt: january.AddDate(0, 0, 15)})
+
+	if err != nil {
+		t.Fatalf("RecordPrice(backdated) = _, %s. want nil", err)
+	}
+
+	current, err := service.GetIngredient(ctx, orgId, flour.Id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.PurchasePrice != 300 {
+		t.Errorf("GetIngredient().PurchasePrice = %d. want %d", current.PurchasePrice, 300)
+	}
+
+	recorded, err := service.RecordPrice(ctx, orgId, flour.Id, PriceInput{PurchasePrice: 450})
+
+	if err != nil {
+		t.Fatalf("RecordPrice() = _, %s. want nil", err)
+	}
+
+	if recorded.Source != PriceSourceManual || !recorded.EffectiveAt.Equal(service.Now.Now()) {
+		t.Errorf("RecordPrice() = %v. want manual price effective now", recorded)
+	}
+
+	current, err = service.GetIngredient(ctx, orgId, flour.Id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.PurchasePrice != 450 {
+		t.Errorf("GetIngredient().PurchasePrice = %d. want %d", current.PurchasePrice, 450)
+	}
+
+	// a price effective in the future becomes current once its date passes
+	_, err = service.RecordPrice(ctx, orgId, flour.Id, PriceInput{PurchasePrice: 500, EffectiveAt: january.AddDate(0, 4, 0)})
+
+	if err != nil {
+		t.Fatalf("RecordPrice(future) = _, %s. want nil", err)
+	}
+
+	if current, _ = service.GetIngredient(ctx, orgId, flour.Id); current.PurchasePrice != 450 {
+		t.Errorf("GetIngredient().PurchasePrice = %d before the future price. want %d", current.PurchasePrice, 450)
+	}
+
+	service.Now = common.TestNow{Time: january.AddDate(0, 5, 0)}
+
+	if current, _ = service.GetIngredient(ctx, orgId, flour.Id); current.PurchasePrice != 500 {
+		t.Errorf("GetIngredient().PurchasePrice = %d after the future price. want %d", current.PurchasePrice, 500)
+	}
+
+	if listed, _ := service.ListIngredients(ctx, orgId, Filter{}); len(listed) != 1 || listed[0].PurchasePrice != 500 {
+		t.Errorf("ListIngredients() = %v after the future price. want Flour at %d", listed, 500)
+	}
+
+	prices, err := service.Prices(ctx, orgId, flour.Id)
+
+	if err != nil {
+		t.Fatalf("Prices() = _, %s. want nil", err)
+	}
+
+	expected := []struct {
+		price         int64
+		source        string
+		changePercent float64
+	}{
+		{price: 200, source: PriceSourceInitial},
+		{price: 250, source: "INV-1", changePercent: 25},
+		{price: 300, source: PriceSourceManual, changePercent: 20},
+		{price: 450, source: PriceSourceManual, changePercent: 50},
+		{price: 500, source: PriceSourceManual, changePercent: 500.0/450*100 - 100},
+	}
+
+	if len(prices) != len(expected) {
+		t.Fatalf("len(Prices()) = %d. want %d", len(prices), len(expected))
+	}
+
+	for i, price := range prices {
+		want := expected[i]
+
+		if price.PurchasePrice != want.price || price.Source != want.source || math.Abs(price.ChangePercent-want.changePercent) > 1e-9 {
+			t.Errorf("Prices()[%d] = %d %s %f. want %d %s %f", i, price.PurchasePrice, price.Source, price.ChangePercent, want.price, want.source, want.changePercent)
+		}
+	}
+
+	if _, err := service.Prices(ctx, otherOrgId, flour.Id); !errors.Is(err, IngredientNotFoundError) {
+		t.Errorf("Prices(other org) = _, %v. want %s", err, IngredientNotFoundError)
+	}
+
+	if _, err := service.RecordPrice(ctx, otherOrgId, flour.Id, PriceInput{PurchasePrice: 1}); !errors.Is(err, IngredientNotFoundError) {
+		t.Errorf("RecordPrice(other org) = _, %v. want %s", err, IngredientNotFoundError)
+	}
+
+	if _, err := service.RecordPrice(ctx, orgId, flour.Id, PriceInput{PurchasePrice: 1, UnitCount: -1}); !errors.Is(err, InvalidIngredientError) {
+		t.Errorf("RecordPrice(negative unit count) = _, %v. want %s", err, InvalidIngredientError)
+	}
+}
+
+func TestIngredientTags(t *testing.T) {
+	ctx := context.Background()
+	db := moontest.LoadTestDB(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	otherOrgId := moontest.InsertTestOrganization(t, db, "Other Cafe")
+
+	service := NewService(NewSQLiteRepo(db), logger)
+	tags := tag.NewService(tag.NewSQLiteRepo(db), logger)
+
+	dairy, err := tags.CreateTag(ctx, orgId, tag.TagInput{Name: "Dairy", Type: tag.TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("CreateTag(dairy) = _, %s. want nil", err)
+	}
+
+	local, err := tags.CreateTag(ctx, orgId, tag.TagInput{Name: "Local", Type: tag.TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("CreateTag(local) = _, %s. want nil", err)
+	}
+
+	beverages, err := tags.CreateTag(ctx, orgId, tag.TagInput{Name: "Beverages", Type: tag.TypeProduct})
+
+	if err != nil {
+		t.Fatalf("CreateTag(beverages) = _, %s. want nil", err)
+	}
+
+	otherTag, err := tags.CreateTag(ctx, otherOrgId, tag.TagInput{Name: "Dairy", Type: tag.TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("CreateTag(other org) = _, %s. want nil", err)
+	}
+
+	milk, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Milk", Unit: "gal", UnitCount: 1, PurchasePrice: 400})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient(milk) = _, %s. want nil", err)
+	}
+
+	butter, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Butter", Unit: "lb", UnitCount: 1, PurchasePrice: 500})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient(butter) = _, %s. want nil", err)
+	}
+
+	if _, err := service.CreateIngredient(ctx, orgId, IngredientInput{Name: "Flour", Unit: "lb", UnitCount: 50, PurchasePrice: 2000}); err != nil {
+		t.Fatalf("CreateIngredient(flour) = _, %s. want nil", err)
+	}
+
+	set, err := service.SetIngredientTags(ctx, orgId, milk.Id, []int{local.Id, dairy.Id, local.Id})
+
+	if err != nil {
+		t.Fatalf("SetIngredientTags(milk) = _, %s. want nil", err)
+	}
+
+	if expected := []int{dairy.Id, local.Id}; !slices.Equal(set, expected) {
+		t.Errorf("SetIngredientTags() = %v. want %v", set, expected)
+	}
+
+	if _, err := service.SetIngredientTags(ctx, orgId, butter.Id, []int{dairy.Id}); err != nil {
+		t.Fatalf("SetIngredientTags(butter) = _, %s. want nil", err)
+	}
+
+	invalid := []struct {
+		test   string
+		tagIds []int
+	}{
+		{"product tag", []int{beverages.Id}},
+		{"other org tag", []int{otherTag.Id}},
+	}
+
+	for _, test := range invalid {
+		t.Run(test.test, func(t *testing.T) {
+			if _, err := service.SetIngredientTags(ctx, orgId, butter.Id, test.tagIds); !errors.Is(err, tag.TagNotFoundError) {
+				t.Errorf("SetIngredientTags() = _, %v. want %v", err, tag.TagNotFoundError)
+			}
+		})
+	}
+
+	filters := []struct {
+		test     string
+		tagIds   []int
+		expected []string
+	}{
+		{"no tags", nil, []string{"Butter", "Flour", "Milk"}},
+		{"one tag", []int{local.Id}, []string{"Milk"}},
+		{"any of the tags", []int{dairy.Id, local.Id}, []string{"Butter", "Milk"}},
+	}
+
+	for _, test := range filters {
+		t.Run(test.test, func(t *testing.T) {
+			ingredients, err := service.ListIngredients(ctx, orgId, Filter{TagIds: test.tagIds})
+
+			if err != nil {
+				t.Fatalf("ListIngredients() = _, %s. want nil", err)
+			}
+
+			var names []string
+
+			for _, ingredient := range ingredients {
+				names = append(names, ingredient.Name)
+			}
+
+			if !slices.Equal(names, test.expected) {
+				t.Errorf("ListIngredients() = %v. want %v", names, test.expected)
+			}
+		})
+	}
+
+	// deleting a tag removes it from its ingredients
+	if err := tags.DeleteTag(ctx, orgId, local.Id); err != nil {
+		t.Fatalf("DeleteTag() = %s. want nil", err)
+	}
+
+	listed, err := service.ListIngredientTags(ctx, orgId, milk.Id)
+
+	if err != nil {
+		t.Fatalf("ListIngredientTags() = _, %s. want nil", err)
+	}
+
+	if expected := []int{dairy.Id}; !slices.Equal(listed, expected) {
+		t.Errorf("ListIngredientTags() = %v. want %v", listed, expected)
+	}
+
+	if _, err := service.ListIngredientTags(ctx, otherOrgId, milk.Id); !errors.Is(err, IngredientNotFoundError) {
+		t.Errorf("ListIngredientTags(other org) = _, %v. want %v", err, IngredientNotFoundError)
+	}
+}
@@ -0,0 +1,27 @@
+package ingredient
+
+import (
+	"context"
+	"time"
+)
+
+type recordPrice struct {
+	organizationId int
+	price          Price
+}
+
+type Repo interface {
+	CreateIngredient(context.Context, Ingredient) (Ingredient, error)
+	GetIngredient(context.Context, int, int, time.Time) (Ingredient, error)
+	UpdateIngredient(context.Context, Ingredient) (Ingredient, error)
+	DeleteIngredient(context.Context, int, int) error
+	ListIngredients(context.Context, int, Filter, time.Time) ([]Ingredient, error)
+
+	SetIngredientTags(context.Context, int, int, []int) error
+	ListIngredientTags(context.Context, int, int) ([]int, error)
+
+	RecordPrice(context.Context, recordPrice) (Price, error)
+	ListPrices(context.Context, int, int) ([]Price, error)
+
+	Import(context.Context, importBatch) ([]ImportRow, error)
+}
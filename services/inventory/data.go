@@ -0,0 +1,98 @@
+package inventory
+
+import "time"
+
+// Count is a count of the ingredients on hand at a location at CountedAt.
+// Items are only loaded when getting a single count
+type Count struct {
+	Id             int         `json:"id"`
+	OrganizationId int         `json:"organizationId"`
+	LocationId     int         `json:"locationId"`
+	CountedAt      time.Time   `json:"countedAt"`
+	Notes          string      `json:"notes"`
+	Items          []CountItem `json:"items,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+// CountItem is the Quantity of an ingredient counted in Unit
+type CountItem struct {
+	IngredientId int     `json:"ingredientId"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+// Purchase is a Quantity of an ingredient received at a location, like a line
+// of an invoice. VendorId is 0 when it was not bought from a vendor. Price is
+// what was paid for the whole Quantity in cents
+type Purchase struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	LocationId     int       `json:"locationId"`
+	IngredientId   int       `json:"ingredientId"`
+	VendorId       int       `json:"vendorId"`
+	Quantity       float64   `json:"quantity"`
+	Unit           string    `json:"unit"`
+	Price          int64     `json:"price"`
+	Source         string    `json:"source"`
+	PurchasedAt    time.Time `json:"purchasedAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Sale is the number of servings of a product sold at a location, usually a
+// day's total from the point of sale
+type Sale struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	LocationId     int       `json:"locationId"`
+	ProductId      int       `json:"productId"`
+	Quantity       int       `json:"quantity"`
+	SoldAt         time.Time `json:"soldAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Filter narrows the counts, purchases and sales returned by the list
+// methods. From and To are inclusive. Zero values match everything
+type Filter struct {
+	LocationId int
+	From       time.Time
+	To         time.Time
+}
+
+// VarianceLine compares the actual and theoretical usage of an ingredient in
+// the unit it is purchased in. Actual usage is Opening + Purchases - Closing
+// and theoretical usage is what the recipes of the products sold should have
+// used. A positive Variance means more was used than the recipes call for.
+// Costs are in cents
+type VarianceLine struct {
+	IngredientId    int     `json:"ingredientId"`
+	Name            string  `json:"name"`
+	Unit            string  `json:"unit"`
+	Opening         float64 `json:"opening"`
+	Purchases       float64 `json:"purchases"`
+	Closing         float64 `json:"closing"`
+	Actual          float64 `json:"actual"`
+	Theoretical     float64 `json:"theoretical"`
+	Variance        float64 `json:"variance"`
+	CostPerUnit     float64 `json:"costPerUnit"`
+	ActualCost      float64 `json:"actualCost"`
+	TheoreticalCost float64 `json:"theoreticalCost"`
+	VarianceCost    float64 `json:"varianceCost"`
+}
+
+// Variance is the usage of every ingredient at a location between two counts.
+// Ingredients are priced as of the closing count. VariancePercent is the
+// VarianceCost as a percentage of the TheoreticalCost
+type Variance struct {
+	LocationId      int            `json:"locationId"`
+	OpeningCountId  int            `json:"openingCountId"`
+	ClosingCountId  int            `json:"closingCountId"`
+	From            time.Time      `json:"from"`
+	To              time.Time      `json:"to"`
+	ServingsSold    int            `json:"servingsSold"`
+	Lines           []VarianceLine `json:"lines"`
+	ActualCost      float64        `json:"actualCost"`
+	TheoreticalCost float64        `json:"theoreticalCost"`
+	VarianceCost    float64        `json:"varianceCost"`
+	VariancePercent float64        `json:"variancePercent"`
+}
@@ -0,0 +1,313 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/services/product"
+	"strings"
+	"time"
+)
+
+var (
+	CountNotFoundError     = errors.New("Inventory count not found")
+	CountItemNotFoundError = errors.New("Inventory count item not found")
+	PurchaseNotFoundError  = errors.New("Purchase not found")
+	SaleNotFoundError      = errors.New("Sale not found")
+	InvalidCountError      = errors.New("Invalid inventory count")
+	InvalidPurchaseError   = errors.New("Invalid purchase")
+	InvalidSaleError       = errors.New("Invalid sale")
+	InvalidVarianceError   = errors.New("Invalid variance")
+)
+
+// Recipes computes how much of each ingredient a serving of a product uses
+type Recipes interface {
+	Usage(context.Context, int, int, time.Time) ([]product.IngredientUsage, error)
+}
+
+type Service struct {
+	Repo    Repo
+	Recipes Recipes
+	Units   product.UnitRegistries
+	Logger  *slog.Logger
+	Now     common.Now
+}
+
+func NewService(repo Repo, recipes Recipes, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:    repo,
+		Recipes: recipes,
+		Units:   product.StandardUnits{},
+		Logger:  logging.Logger(logger, slog.String("service", "inventory")),
+		Now:     common.TimeNow{},
+	}
+}
+
+// Returns the unit a quantity of an ingredient is stored in. A blank unit is
+// the unit the ingredient is purchased in. Any other unit must convert to it
+func (s *Service) quantityUnit(ctx context.Context, organizationId int, ingredientId int, unit string, invalid error) (string, error) {
+	ingredientUnit, err := s.Repo.IngredientUnit(ctx, organizationId, ingredientId)
+
+	if err != nil {
+		return "", err
+	}
+
+	unit = strings.TrimSpace(unit)
+
+	if unit == "" {
+		return ingredientUnit, nil
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := registry.Convert(1, unit, ingredientUnit); err != nil {
+		return "", fmt.Errorf("%w: %w", invalid, err)
+	}
+
+	return unit, nil
+}
+
+// Unit defaults to the unit the ingredient is purchased in
+type CountItemInput struct {
+	IngredientId int
+	Quantity     float64
+	Unit         string
+}
+
+func (s *Service) countItem(ctx context.Context, organizationId int, input CountItemInput) (CountItem, error) {
+	if input.Quantity < 0 {
+		return CountItem{}, fmt.Errorf("%w: quantity can not be negative", InvalidCountError)
+	}
+
+	unit, err := s.quantityUnit(ctx, organizationId, input.IngredientId, input.Unit, InvalidCountError)
+
+	if err != nil {
+		return CountItem{}, err
+	}
+
+	return CountItem{
+		IngredientId: input.IngredientId,
+		Quantity:     input.Quantity,
+		Unit:         unit,
+	}, nil
+}
+
+// CountedAt defaults to now
+type CountInput struct {
+	LocationId int
+	CountedAt  time.Time
+	Notes      string
+	Items      []CountItemInput
+}
+
+// Records a count of a location with the quantity of each ingredient counted
+func (s *Service) CreateCount(ctx context.Context, organizationId int, input CountInput) (Count, error) {
+	now := s.Now.Now()
+
+	count := Count{
+		OrganizationId: organizationId,
+		LocationId:     input.LocationId,
+		CountedAt:      input.CountedAt,
+		Notes:          strings.TrimSpace(input.Notes),
+		Items:          []CountItem{},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if count.CountedAt.IsZero() {
+		count.CountedAt = now
+	}
+
+	counted := map[int]bool{}
+
+	for _, itemInput := range input.Items {
+		if counted[itemInput.IngredientId] {
+			return Count{}, fmt.Errorf("%w: ingredient %d is counted more than once", InvalidCountError, itemInput.IngredientId)
+		}
+
+		counted[itemInput.IngredientId] = true
+
+		item, err := s.countItem(ctx, organizationId, itemInput)
+
+		if err != nil {
+			return Count{}, err
+		}
+
+		count.Items = append(count.Items, item)
+	}
+
+	return s.Repo.CreateCount(ctx, count)
+}
+
+// Returns the count with its items
+func (s *Service) GetCount(ctx context.Context, organizationId int, id int) (Count, error) {
+	return s.Repo.GetCount(ctx, organizationId, id)
+}
+
+// Lists counts from the latest without their items
+func (s *Service) ListCounts(ctx context.Context, organizationId int, filter Filter) ([]Count, error) {
+	return s.Repo.ListCounts(ctx, organizationId, filter)
+}
+
+func (s *Service) DeleteCount(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteCount(ctx, organizationId, id)
+}
+
+// Sets the counted quantity of an ingredient, adding it to the count when it
+// was not counted yet
+func (s *Service) SetCountItem(ctx context.Context, organizationId int, countId int, input CountItemInput) (CountItem, error) {
+	item, err := s.countItem(ctx, organizationId, input)
+
+	if err != nil {
+		return CountItem{}, err
+	}
+
+	return item, s.Repo.SetCountItem(ctx, organizationId, countId, item)
+}
+
+func (s *Service) RemoveCountItem(ctx context.Context, organizationId int, countId int, ingredientId int) error {
+	return s.Repo.RemoveCountItem(ctx, organizationId, countId, ingredientId)
+}
+
+// Unit defaults to the unit the ingredient is purchased in and PurchasedAt
+// defaults to now. VendorId defaults to the ingredient's vendor and Price, in
+// cents for the whole Quantity, defaults to the ingredient's price in effect
+// at PurchasedAt
+type PurchaseInput struct {
+	LocationId   int
+	IngredientId int
+	VendorId     int
+	Quantity     float64
+	Unit         string
+	Price        int64
+	Source       string
+	PurchasedAt  time.Time
+}
+
+func (s *Service) RecordPurchase(ctx context.Context, organizationId int, input PurchaseInput) (Purchase, error) {
+	if input.Quantity <= 0 {
+		return Purchase{}, fmt.Errorf("%w: quantity must be greater than 0", InvalidPurchaseError)
+	}
+
+	if input.Price < 0 {
+		return Purchase{}, fmt.Errorf("%w: price can not be negative", InvalidPurchaseError)
+	}
+
+	unit, err := s.quantityUnit(ctx, organizationId, input.IngredientId, input.Unit, InvalidPurchaseError)
+
+	if err != nil {
+		return Purchase{}, err
+	}
+
+	purchase := Purchase{
+		OrganizationId: organizationId,
+		LocationId:     input.LocationId,
+		IngredientId:   input.IngredientId,
+		VendorId:       input.VendorId,
+		Quantity:       input.Quantity,
+		Unit:           unit,
+		Price:          input.Price,
+		Source:         strings.TrimSpace(input.Source),
+		PurchasedAt:    input.PurchasedAt,
+		CreatedAt:      s.Now.Now(),
+	}
+
+	if purchase.PurchasedAt.IsZero() {
+		purchase.PurchasedAt = purchase.CreatedAt
+	}
+
+	if purchase.VendorId == 0 || purchase.Price == 0 {
+		if err := s.applyPurchaseTerms(ctx, &purchase); err != nil {
+			return Purchase{}, err
+		}
+	}
+
+	return s.Repo.RecordPurchase(ctx, purchase)
+}
+
+// Fills in the vendor and price a purchase was recorded without from its
+// ingredient's vendor and the price in effect when it was purchased
+func (s *Service) applyPurchaseTerms(ctx context.Context, purchase *Purchase) error {
+	terms, err := s.Repo.PurchaseTerms(ctx, purchase.OrganizationId, purchase.IngredientId, purchase.PurchasedAt)
+
+	if err != nil {
+		return err
+	}
+
+	if purchase.VendorId == 0 {
+		purchase.VendorId = terms.vendorId
+	}
+
+	if purchase.Price != 0 {
+		return nil
+	}
+
+	registry, err := s.Units.Registry(ctx, purchase.OrganizationId)
+
+	if err != nil {
+		return err
+	}
+
+	quantity, err := registry.Convert(purchase.Quantity, purchase.Unit, terms.unit)
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", InvalidPurchaseError, err)
+	}
+
+	purchase.Price = int64(math.Round(quantity / terms.unitCount * float64(terms.purchasePrice)))
+
+	return nil
+}
+
+func (s *Service) ListPurchases(ctx context.Context, organizationId int, filter Filter) ([]Purchase, error) {
+	return s.Repo.ListPurchases(ctx, organizationId, filter)
+}
+
+func (s *Service) DeletePurchase(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeletePurchase(ctx, organizationId, id)
+}
+
+// SoldAt defaults to now
+type SaleInput struct {
+	LocationId int
+	ProductId  int
+	Quantity   int
+	SoldAt     time.Time
+}
+
+func (s *Service) RecordSale(ctx context.Context, organizationId int, input SaleInput) (Sale, error) {
+	if input.Quantity < 1 {
+		return Sale{}, fmt.Errorf("%w: quantity must be at least 1", InvalidSaleError)
+	}
+
+	sale := Sale{
+		OrganizationId: organizationId,
+		LocationId:     input.LocationId,
+		ProductId:      input.ProductId,
+		Quantity:       input.Quantity,
+		SoldAt:         input.SoldAt,
+		CreatedAt:      s.Now.Now(),
+	}
+
+	if sale.SoldAt.IsZero() {
+		sale.SoldAt = sale.CreatedAt
+	}
+
+	return s.Repo.RecordSale(ctx, sale)
+}
+
+func (s *Service) ListSales(ctx context.Context, organizationId int, filter Filter) ([]Sale, error) {
+	return s.Repo.ListSales(ctx, organizationId, filter)
+}
+
+func (s *Service) DeleteSale(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteSale(ctx, organizationId, id)
+}
@@ -0,0 +1,677 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moon-cost/common"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+// Appends a condition to query for each field set on filter. timeColumn is the
+// column From and To compare against
+func (f Filter) where(query string, args []any, timeColumn string) (string, []any) {
+	if f.LocationId != 0 {
+		query += ` AND locationId = ?`
+		args = append(args, f.LocationId)
+	}
+
+	if !f.From.IsZero() {
+		query += ` AND ` + timeColumn + ` >= ?`
+		args = append(args, f.From.UnixMilli())
+	}
+
+	if !f.To.IsZero() {
+		query += ` AND ` + timeColumn + ` <= ?`
+		args = append(args, f.To.UnixMilli())
+	}
+
+	return query, args
+}
+
+func checkLocation(ctx context.Context, tx *sql.Tx, organizationId int, locationId int) error {
+	var exists int
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM locations WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		locationId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return organization.LocationNotFoundError
+	}
+
+	return err
+}
+
+func checkIngredient(ctx context.Context, tx *sql.Tx, organizationId int, ingredientId int) error {
+	var exists int
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		ingredientId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ingredient.IngredientNotFoundError
+	}
+
+	return err
+}
+
+// Vendors are shared by every organization. A VendorId of 0 is no vendor
+func checkVendor(ctx context.Context, tx *sql.Tx, vendorId int) error {
+	if vendorId == 0 {
+		return nil
+	}
+
+	var exists int
+
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM vendors WHERE id = ?`, vendorId).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: vendor %d not found", InvalidPurchaseError, vendorId)
+	}
+
+	return err
+}
+
+const countColumns = `id, organizationId, locationId, countedAt, COALESCE(notes, ''), createdAt, updatedAt`
+
+func scanCount(row common.Scanner) (Count, error) {
+	var count Count
+	var countedAt, createdAt, updatedAt int64
+
+	err := row.Scan(
+		&count.Id,
+		&count.OrganizationId,
+		&count.LocationId,
+		&countedAt,
+		&count.Notes,
+		&createdAt,
+		&updatedAt,
+	)
+
+	count.CountedAt = time.UnixMilli(countedAt)
+	count.CreatedAt = time.UnixMilli(createdAt)
+	count.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return count, err
+}
+
+const createCountQuery = `
+INSERT INTO inventory_counts (organizationId, locationId, countedAt, notes, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const setCountItemQuery = `
+INSERT INTO inventory_count_items (countId, ingredientId, quantity, unit)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (countId, ingredientId) DO UPDATE SET quantity = excluded.quantity, unit = excluded.unit
+`
+
+func (s *SQLiteRepo) CreateCount(ctx context.Context, count Count) (Count, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return count, err
+	}
+
+	defer tx.Rollback()
+
+	if err := checkLocation(ctx, tx, count.OrganizationId, count.LocationId); err != nil {
+		return count, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		createCountQuery,
+		count.OrganizationId,
+		count.LocationId,
+		count.CountedAt.UnixMilli(),
+		count.Notes,
+		count.CreatedAt.UnixMilli(),
+		count.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return count, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return count, err
+	}
+
+	count.Id = int(id)
+
+	for _, item := range count.Items {
+		_, err := tx.ExecContext(ctx, setCountItemQuery, count.Id, item.IngredientId, item.Quantity, item.Unit)
+
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, tx.Commit()
+}
+
+const getCountQuery = `SELECT ` + countColumns + ` FROM inventory_counts WHERE organizationId = ? AND id = ?`
+
+const countItemsQuery = `
+SELECT ingredientId, quantity, unit FROM inventory_count_items
+WHERE countId = ?
+ORDER BY ingredientId ASC
+`
+
+func (s *SQLiteRepo) GetCount(ctx context.Context, organizationId int, id int) (Count, error) {
+	count, err := scanCount(s.db.QueryRowContext(ctx, getCountQuery, organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return count, CountNotFoundError
+	}
+
+	if err != nil {
+		return count, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, countItemsQuery, id)
+
+	if err != nil {
+		return count, err
+	}
+
+	defer rows.Close()
+
+	count.Items = []CountItem{}
+
+	for rows.Next() {
+		var item CountItem
+
+		if err := rows.Scan(&item.IngredientId, &item.Quantity, &item.Unit); err != nil {
+			return count, err
+		}
+
+		count.Items = append(count.Items, item)
+	}
+
+	return count, rows.Err()
+}
+
+const listCountsQuery = `SELECT ` + countColumns + ` FROM inventory_counts WHERE organizationId = ?`
+
+func (s *SQLiteRepo) ListCounts(ctx context.Context, organizationId int, filter Filter) ([]Count, error) {
+	query, args := filter.where(listCountsQuery, []any{organizationId}, "countedAt")
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY countedAt DESC, id DESC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	counts := []Count{}
+
+	for rows.Next() {
+		count, err := scanCount(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func (s *SQLiteRepo) DeleteCount(ctx context.Context, organizationId int, id int) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM inventory_counts WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, CountNotFoundError)
+}
+
+func (s *SQLiteRepo) SetCountItem(ctx context.Context, organizationId int, countId int, item CountItem) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM inventory_counts WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		countId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return CountNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, setCountItemQuery, countId, item.IngredientId, item.Quantity, item.Unit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const removeCountItemQuery = `
+DELETE FROM inventory_count_items
+WHERE ingredientId = ? AND countId = (
+  SELECT id FROM inventory_counts WHERE organizationId = ? AND id = ?
+)
+`
+
+func (s *SQLiteRepo) RemoveCountItem(ctx context.Context, organizationId int, countId int, ingredientId int) error {
+	res, err := s.db.ExecContext(ctx, removeCountItemQuery, ingredientId, organizationId, countId)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, CountItemNotFoundError)
+}
+
+const purchaseColumns = `id, organizationId, locationId, ingredientId, COALESCE(vendorId, 0), quantity, unit, price, COALESCE(source, ''), purchasedAt, createdAt`
+
+func scanPurchase(row common.Scanner) (Purchase, error) {
+	var purchase Purchase
+	var purchasedAt, createdAt int64
+
+	err := row.Scan(
+		&purchase.Id,
+		&purchase.OrganizationId,
+		&purchase.LocationId,
+		&purchase.IngredientId,
+		&purchase.VendorId,
+		&purchase.Quantity,
+		&purchase.Unit,
+		&purchase.Price,
+		&purchase.Source,
+		&purchasedAt,
+		&createdAt,
+	)
+
+	purchase.PurchasedAt = time.UnixMilli(purchasedAt)
+	purchase.CreatedAt = time.UnixMilli(createdAt)
+
+	return purchase, err
+}
+
+const recordPurchaseQuery = `
+INSERT INTO inventory_purchases (organizationId, locationId, ingredientId, vendorId, quantity, unit, price, source, purchasedAt, createdAt)
+VALUES (?, ?, ?, NULLIF(?, 0), ?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) RecordPurchase(ctx context.Context, purchase Purchase) (Purchase, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return purchase, err
+	}
+
+	defer tx.Rollback()
+
+	if err := checkLocation(ctx, tx, purchase.OrganizationId, purchase.LocationId); err != nil {
+		return purchase, err
+	}
+
+	if err := checkIngredient(ctx, tx, purchase.OrganizationId, purchase.IngredientId); err != nil {
+		return purchase, err
+	}
+
+	if err := checkVendor(ctx, tx, purchase.VendorId); err != nil {
+		return purchase, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		recordPurchaseQuery,
+		purchase.OrganizationId,
+		purchase.LocationId,
+		purchase.IngredientId,
+		purchase.VendorId,
+		purchase.Quantity,
+		purchase.Unit,
+		purchase.Price,
+		purchase.Source,
+		purchase.PurchasedAt.UnixMilli(),
+		purchase.CreatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return purchase, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return purchase, err
+	}
+
+	purchase.Id = int(id)
+
+	return purchase, tx.Commit()
+}
+
+const listPurchasesQuery = `SELECT ` + purchaseColumns + ` FROM inventory_purchases WHERE organizationId = ?`
+
+func (s *SQLiteRepo) ListPurchases(ctx context.Context, organizationId int, filter Filter) ([]Purchase, error) {
+	query, args := filter.where(listPurchasesQuery, []any{organizationId}, "purchasedAt")
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY purchasedAt ASC, id ASC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	purchases := []Purchase{}
+
+	for rows.Next() {
+		purchase, err := scanPurchase(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		purchases = append(purchases, purchase)
+	}
+
+	return purchases, rows.Err()
+}
+
+func (s *SQLiteRepo) DeletePurchase(ctx context.Context, organizationId int, id int) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM inventory_purchases WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, PurchaseNotFoundError)
+}
+
+const saleColumns = `id, organizationId, locationId, productId, quantity, soldAt, createdAt`
+
+func scanSale(row common.Scanner) (Sale, error) {
+	var sale Sale
+	var soldAt, createdAt int64
+
+	err := row.Scan(
+		&sale.Id,
+		&sale.OrganizationId,
+		&sale.LocationId,
+		&sale.ProductId,
+		&sale.Quantity,
+		&soldAt,
+		&createdAt,
+	)
+
+	sale.SoldAt = time.UnixMilli(soldAt)
+	sale.CreatedAt = time.UnixMilli(createdAt)
+
+	return sale, err
+}
+
+const recordSaleQuery = `
+INSERT INTO sales (organizationId, locationId, productId, quantity, soldAt, createdAt)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) RecordSale(ctx context.Context, sale Sale) (Sale, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return sale, err
+	}
+
+	defer tx.Rollback()
+
+	if err := checkLocation(ctx, tx, sale.OrganizationId, sale.LocationId); err != nil {
+		return sale, err
+	}
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM products WHERE organizationId = ? AND id = ?`,
+		sale.OrganizationId,
+		sale.ProductId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return sale, product.ProductNotFoundError
+	}
+
+	if err != nil {
+		return sale, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		recordSaleQuery,
+		sale.OrganizationId,
+		sale.LocationId,
+		sale.ProductId,
+		sale.Quantity,
+		sale.SoldAt.UnixMilli(),
+		sale.CreatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return sale, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return sale, err
+	}
+
+	sale.Id = int(id)
+
+	return sale, tx.Commit()
+}
+
+const listSalesQuery = `SELECT ` + saleColumns + ` FROM sales WHERE organizationId = ?`
+
+func (s *SQLiteRepo) ListSales(ctx context.Context, organizationId int, filter Filter) ([]Sale, error) {
+	query, args := filter.where(listSalesQuery, []any{organizationId}, "soldAt")
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY soldAt ASC, id ASC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sales := []Sale{}
+
+	for rows.Next() {
+		sale, err := scanSale(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sales = append(sales, sale)
+	}
+
+	return sales, rows.Err()
+}
+
+func (s *SQLiteRepo) DeleteSale(ctx context.Context, organizationId int, id int) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM sales WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, SaleNotFoundError)
+}
+
+func (s *SQLiteRepo) IngredientUnit(ctx context.Context, organizationId int, ingredientId int) (string, error) {
+	var unit string
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT unit FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		ingredientId,
+	).Scan(&unit)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return unit, ingredient.IngredientNotFoundError
+	}
+
+	return unit, err
+}
+
+// The latest price of an ingredient in effect at a time, falling back to its
+// earliest price when it had none yet
+const purchaseTermsQuery = `
+SELECT
+  COALESCE(ingredients.vendorId, 0),
+  prices.unit,
+  prices.unitCount,
+  prices.purchasePrice
+FROM ingredients
+JOIN ingredient_prices AS prices ON prices.id = COALESCE(
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id AND effectiveAt <= ?
+    ORDER BY effectiveAt DESC, id DESC
+    LIMIT 1
+  ),
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id
+    ORDER BY effectiveAt ASC, id ASC
+    LIMIT 1
+  )
+)
+WHERE ingredients.organizationId = ? AND ingredients.id = ?
+`
+
+func (s *SQLiteRepo) PurchaseTerms(ctx context.Context, organizationId int, ingredientId int, at time.Time) (purchaseTerms, error) {
+	var terms purchaseTerms
+
+	err := s.db.QueryRowContext(ctx, purchaseTermsQuery, at.UnixMilli(), organizationId, ingredientId).Scan(
+		&terms.vendorId,
+		&terms.unit,
+		&terms.unitCount,
+		&terms.purchasePrice,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return terms, ingredient.IngredientNotFoundError
+	}
+
+	return terms, err
+}
+
+// Prices each ingredient with the latest price in effect at asOf, falling back
+// to its earliest price when it had none yet
+const ingredientCostsQuery = `
+SELECT
+  ingredients.id,
+  ingredients.name,
+  prices.unit,
+  prices.unitCount,
+  prices.purchasePrice
+FROM ingredients
+JOIN ingredient_prices AS prices ON prices.id = COALESCE(
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id AND effectiveAt <= ?
+    ORDER BY effectiveAt DESC, id DESC
+    LIMIT 1
+  ),
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id
+    ORDER BY effectiveAt ASC, id ASC
+    LIMIT 1
+  )
+)
+WHERE ingredients.organizationId = ?
+`
+
+func (s *SQLiteRepo) IngredientCosts(ctx context.Context, organizationId int, asOf time.Time) (map[int]ingredientCost, error) {
+	rows, err := s.db.QueryContext(ctx, ingredientCostsQuery, asOf.UnixMilli(), organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	costs := map[int]ingredientCost{}
+
+	for rows.Next() {
+		var id int
+		var cost ingredientCost
+		var unitCount float64
+		var purchasePrice int64
+
+		if err := rows.Scan(&id, &cost.name, &cost.unit, &unitCount, &purchasePrice); err != nil {
+			return nil, err
+		}
+
+		cost.costPerUnit = ingredient.UnitCost(purchasePrice, unitCount)
+		costs[id] = cost
+	}
+
+	return costs, rows.Err()
+}
@@ -0,0 +1,408 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"math"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/units"
+	"testing"
+	"time"
+)
+
+type testFixture struct {
+	moontest.TestEnv
+	inventory   *Service
+	products    *product.Service
+	ingredients *ingredient.Service
+	locations   *organization.Service
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	env := moontest.NewTestEnv(t)
+	products := product.NewService(product.NewSQLiteRepo(env.DB), env.Logger)
+
+	return testFixture{
+		TestEnv:     env,
+		inventory:   NewService(NewSQLiteRepo(env.DB), products, env.Logger),
+		products:    products,
+		ingredients: ingredient.NewService(ingredient.NewSQLiteRepo(env.DB), env.Logger),
+		locations:   organization.NewService(organization.NewSQLiteRepo(env.DB), env.Logger),
+	}
+}
+
+func (f testFixture) location(t *testing.T, orgId int) organization.Location {
+	t.Helper()
+
+	location, err := f.locations.CreateLocation(context.Background(), orgId, organization.LocationInput{Address: "1 Main St"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	return location
+}
+
+func (f testFixture) ingredient(t *testing.T, orgId int, input ingredient.IngredientInput) ingredient.Ingredient {
+	t.Helper()
+
+	created, err := f.ingredients.CreateIngredient(context.Background(), orgId, input)
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func TestInventoryValidation(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	location := f.location(t, f.OrgId)
+	otherLocation := f.location(t, f.OtherOrgId)
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+	otherFlour := f.ingredient(t, f.OtherOrgId, ingredient.IngredientInput{Name: "Flour", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+
+	countTests := []struct {
+		test     string
+		input    CountInput
+		expected error
+	}{
+		{"valid", CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: flour.Id, Quantity: 500, Unit: "g"}}}, nil},
+		{"no items", CountInput{LocationId: location.Id}, nil},
+		{"negative quantity", CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: flour.Id, Quantity: -1}}}, InvalidCountError},
+		{"counted twice", CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: flour.Id, Quantity: 1}, {IngredientId: flour.Id, Quantity: 2}}}, InvalidCountError},
+		{"incompatible unit", CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: flour.Id, Quantity: 1, Unit: "l"}}}, units.IncompatibleUnitError},
+		{"other org location", CountInput{LocationId: otherLocation.Id}, organization.LocationNotFoundError},
+		{"other org ingredient", CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: otherFlour.Id, Quantity: 1}}}, ingredient.IngredientNotFoundError},
+	}
+
+	for _, test := range countTests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := f.inventory.CreateCount(ctx, f.OrgId, test.input)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("CreateCount() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+
+	purchaseTests := []struct {
+		test     string
+		input    PurchaseInput
+		expected error
+	}{
+		{"valid", PurchaseInput{LocationId: location.Id, IngredientId: flour.Id, Quantity: 25}, nil},
+		{"zero quantity", PurchaseInput{LocationId: location.Id, IngredientId: flour.Id}, InvalidPurchaseError},
+		{"negative price", PurchaseInput{LocationId: location.Id, IngredientId: flour.Id, Quantity: 1, Price: -1}, InvalidPurchaseError},
+		{"unknown vendor", PurchaseInput{LocationId: location.Id, IngredientId: flour.Id, Quantity: 1, VendorId: 999}, InvalidPurchaseError},
+		{"unknown unit", PurchaseInput{LocationId: location.Id, IngredientId: flour.Id, Quantity: 1, Unit: "sack"}, units.UnknownUnitError},
+		{"other org location", PurchaseInput{LocationId: otherLocation.Id, IngredientId: flour.Id, Quantity: 1}, organization.LocationNotFoundError},
+	}
+
+	for _, test := range purchaseTests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := f.inventory.RecordPurchase(ctx, f.OrgId, test.input)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("RecordPurchase() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, product.ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	saleTests := []struct {
+		test     string
+		orgId    int
+		input    SaleInput
+		expected error
+	}{
+		{"valid", f.OrgId, SaleInput{LocationId: location.Id, ProductId: pizza.Id, Quantity: 3}, nil},
+		{"zero quantity", f.OrgId, SaleInput{LocationId: location.Id, ProductId: pizza.Id}, InvalidSaleError},
+		{"other org product", f.OtherOrgId, SaleInput{LocationId: otherLocation.Id, ProductId: pizza.Id, Quantity: 1}, product.ProductNotFoundError},
+	}
+
+	for _, test := range saleTests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := f.inventory.RecordSale(ctx, test.orgId, test.input)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("RecordSale() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestCountItems(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	location := f.location(t, f.OrgId)
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+
+	count, err := f.inventory.CreateCount(ctx, f.OrgId, CountInput{LocationId: location.Id, Notes: " Monday "})
+
+	if err != nil {
+		t.Fatalf("CreateCount() = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.SetCountItem(ctx, f.OrgId, count.Id, CountItemInput{IngredientId: flour.Id, Quantity: 3}); err != nil {
+		t.Fatalf("SetCountItem() = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.SetCountItem(ctx, f.OrgId, count.Id, CountItemInput{IngredientId: flour.Id, Quantity: 2500, Unit: "g"}); err != nil {
+		t.Fatalf("SetCountItem(recount) = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.SetCountItem(ctx, f.OtherOrgId, count.Id, CountItemInput{IngredientId: flour.Id, Quantity: 1}); !errors.Is(err, ingredient.IngredientNotFoundError) {
+		t.Errorf("SetCountItem(other org) = _, %v. want %v", err, ingredient.IngredientNotFoundError)
+	}
+
+	found, err := f.inventory.GetCount(ctx, f.OrgId, count.Id)
+
+	if err != nil {
+		t.Fatalf("GetCount() = _, %s. want nil", err)
+	}
+
+	if found.Notes != "Monday" {
+		t.Errorf("GetCount().Notes = %q. want %q", found.Notes, "Monday")
+	}
+
+	if len(found.Items) != 1 || found.Items[0].Quantity != 2500 || found.Items[0].Unit != "g" {
+		t.Fatalf("GetCount().Items = %+v. want 2500g of flour", found.Items)
+	}
+
+	if err := f.inventory.RemoveCountItem(ctx, f.OrgId, count.Id, flour.Id); err != nil {
+		t.Fatalf("RemoveCountItem() = %s. want nil", err)
+	}
+
+	if err := f.inventory.RemoveCountItem(ctx, f.OrgId, count.Id, flour.Id); !errors.Is(err, CountItemNotFoundError) {
+		t.Errorf("RemoveCountItem(removed) = %v. want %v", err, CountItemNotFoundError)
+	}
+
+	if err := f.inventory.DeleteCount(ctx, f.OtherOrgId, count.Id); !errors.Is(err, CountNotFoundError) {
+		t.Errorf("DeleteCount(other org) = %v. want %v", err, CountNotFoundError)
+	}
+
+	if err := f.inventory.DeleteCount(ctx, f.OrgId, count.Id); err != nil {
+		t.Fatalf("DeleteCount() = %s. want nil", err)
+	}
+
+	if _, err := f.inventory.GetCount(ctx, f.OrgId, count.Id); !errors.Is(err, CountNotFoundError) {
+		t.Errorf("GetCount(deleted) = _, %v. want %v", err, CountNotFoundError)
+	}
+}
+
+func TestVariance(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	location := f.location(t, f.OrgId)
+	otherLocation := f.location(t, f.OrgId)
+
+	// $2.00 per kg
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+	// $10.00 per kg
+	cheese := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Cheese", Unit: "kg", UnitCount: 1, PurchasePrice: 1000})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, product.ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, product.ProductIngredientInput{IngredientId: flour.Id, Amount: 200, Unit: "g"}); err != nil {
+		t.Fatalf("AddProductIngredient(flour) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, product.ProductIngredientInput{IngredientId: cheese.Id, Amount: 100, Unit: "g"}); err != nil {
+		t.Fatalf("AddProductIngredient(cheese) = _, %s. want nil", err)
+	}
+
+	monday := time.Date(2025, 5, 5, 8, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	opening, err := f.inventory.CreateCount(ctx, f.OrgId, CountInput{
+		LocationId: location.Id,
+		CountedAt:  monday,
+		Items: []CountItemInput{
+			{IngredientId: flour.Id, Quantity: 10},
+			{IngredientId: cheese.Id, Quantity: 3},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("CreateCount(opening) = _, %s. want nil", err)
+	}
+
+	closing, err := f.inventory.CreateCount(ctx, f.OrgId, CountInput{
+		LocationId: location.Id,
+		CountedAt:  monday.Add(7 * day),
+		Items: []CountItemInput{
+			{IngredientId: flour.Id, Quantity: 9500, Unit: "g"},
+			{IngredientId: cheese.Id, Quantity: 1},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("CreateCount(closing) = _, %s. want nil", err)
+	}
+
+	purchases := []PurchaseInput{
+		{LocationId: location.Id, IngredientId: flour.Id, Quantity: 5, PurchasedAt: monday.Add(day)},
+		// Before the opening count, at another location and after the closing
+		// count so none are included
+		{LocationId: location.Id, IngredientId: flour.Id, Quantity: 100, PurchasedAt: monday},
+		{LocationId: otherLocation.Id, IngredientId: flour.Id, Quantity: 100, PurchasedAt: monday.Add(day)},
+		{LocationId: location.Id, IngredientId: flour.Id, Quantity: 100, PurchasedAt: monday.Add(8 * day)},
+	}
+
+	for _, purchase := range purchases {
+		if _, err := f.inventory.RecordPurchase(ctx, f.OrgId, purchase); err != nil {
+			t.Fatalf("RecordPurchase() = _, %s. want nil", err)
+		}
+	}
+
+	sales := []SaleInput{
+		{LocationId: location.Id, ProductId: pizza.Id, Quantity: 12, SoldAt: monday.Add(2 * day)},
+		{LocationId: location.Id, ProductId: pizza.Id, Quantity: 8, SoldAt: monday.Add(7 * day)},
+		{LocationId: otherLocation.Id, ProductId: pizza.Id, Quantity: 50, SoldAt: monday.Add(2 * day)},
+	}
+
+	for _, sale := range sales {
+		if _, err := f.inventory.RecordSale(ctx, f.OrgId, sale); err != nil {
+			t.Fatalf("RecordSale() = _, %s. want nil", err)
+		}
+	}
+
+	variance, err := f.inventory.Variance(ctx, f.OrgId, opening.Id, closing.Id)
+
+	if err != nil {
+		t.Fatalf("Variance() = _, %s. want nil", err)
+	}
+
+	if variance.ServingsSold != 20 {
+		t.Errorf("Variance().ServingsSold = %d. want 20", variance.ServingsSold)
+	}
+
+	expected := []VarianceLine{
+		// 3 - 1 = 2kg used, 20 * 100g = 2kg expected
+		{IngredientId: cheese.Id, Actual: 2, Theoretical: 2, Variance: 0, VarianceCost: 0},
+		// 10 + 5 - 9.5 = 5.5kg used, 20 * 200g = 4kg expected
+		{IngredientId: flour.Id, Actual: 5.5, Theoretical: 4, Variance: 1.5, VarianceCost: 300},
+	}
+
+	if len(variance.Lines) != len(expected) {
+		t.Fatalf("len(Variance().Lines) = %d. want %d", len(variance.Lines), len(expected))
+	}
+
+	for i, line := range variance.Lines {
+		want := expected[i]
+
+		if line.IngredientId != want.IngredientId {
+			t.Errorf("Variance().Lines[%d].IngredientId = %d. want %d", i, line.IngredientId, want.IngredientId)
+		}
+
+		if math.Abs(line.Actual-want.Actual) > 1e-9 || math.Abs(line.Theoretical-want.Theoretical) > 1e-9 {
+			t.Errorf("Variance().Lines[%d] actual %f theoretical %f. want %f %f", i, line.Actual, line.Theoretical, want.Actual, want.Theoretical)
+		}
+
+		if math.Abs(line.Variance-want.Variance) > 1e-9 || math.Abs(line.VarianceCost-want.VarianceCost) > 1e-9 {
+			t.Errorf("Variance().Lines[%d] variance %f cost %f. want %f %f", i, line.Variance, line.VarianceCost, want.Variance, want.VarianceCost)
+		}
+	}
+
+	// $28.00 expected, $31.00 used
+	if math.Abs(variance.TheoreticalCost-2800) > 1e-9 || math.Abs(variance.ActualCost-3100) > 1e-9 {
+		t.Errorf("Variance() theoretical %f actual %f. want 2800 3100", variance.TheoreticalCost, variance.ActualCost)
+	}
+
+	if math.Abs(variance.VariancePercent-300.0/28) > 1e-9 {
+		t.Errorf("Variance().VariancePercent = %f. want %f", variance.VariancePercent, 300.0/28)
+	}
+
+	elsewhere, err := f.inventory.CreateCount(ctx, f.OrgId, CountInput{LocationId: otherLocation.Id, CountedAt: monday.Add(day)})
+
+	if err != nil {
+		t.Fatalf("CreateCount(elsewhere) = _, %s. want nil", err)
+	}
+
+	tests := []struct {
+		test     string
+		orgId    int
+		opening  int
+		closing  int
+		expected error
+	}{
+		{"closing before opening", f.OrgId, closing.Id, opening.Id, InvalidVarianceError},
+		{"same count", f.OrgId, opening.Id, opening.Id, InvalidVarianceError},
+		{"different locations", f.OrgId, opening.Id, elsewhere.Id, InvalidVarianceError},
+		{"other org", f.OtherOrgId, opening.Id, closing.Id, CountNotFoundError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := f.inventory.Variance(ctx, test.orgId, test.opening, test.closing)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Variance() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestDeleteWithHistory(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	location := f.location(t, f.OrgId)
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+	cheese := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Cheese", Unit: "kg", UnitCount: 1, PurchasePrice: 1000})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, product.ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.CreateCount(ctx, f.OrgId, CountInput{LocationId: location.Id, Items: []CountItemInput{{IngredientId: flour.Id, Quantity: 1}}}); err != nil {
+		t.Fatalf("CreateCount() = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.RecordPurchase(ctx, f.OrgId, PurchaseInput{LocationId: location.Id, IngredientId: cheese.Id, Quantity: 1}); err != nil {
+		t.Fatalf("RecordPurchase() = _, %s. want nil", err)
+	}
+
+	if _, err := f.inventory.RecordSale(ctx, f.OrgId, SaleInput{LocationId: location.Id, ProductId: pizza.Id, Quantity: 1}); err != nil {
+		t.Fatalf("RecordSale() = _, %s. want nil", err)
+	}
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, flour.Id); !errors.Is(err, ingredient.IngredientInUseError) {
+		t.Errorf("DeleteIngredient(counted) = %v. want %s", err, ingredient.IngredientInUseError)
+	}
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, cheese.Id); !errors.Is(err, ingredient.IngredientInUseError) {
+		t.Errorf("DeleteIngredient(purchased) = %v. want %s", err, ingredient.IngredientInUseError)
+	}
+
+	if err := f.products.DeleteProduct(ctx, f.OrgId, pizza.Id); !errors.Is(err, product.ProductInUseError) {
+		t.Errorf("DeleteProduct(sold) = %v. want %s", err, product.ProductInUseError)
+	}
+
+	if err := f.locations.DeleteLocation(ctx, f.OrgId, location.Id); !errors.Is(err, organization.LocationInUseError) {
+		t.Errorf("DeleteLocation(with history) = %v. want %s", err, organization.LocationInUseError)
+	}
+
+	if err := f.locations.DeleteOrganization(ctx, f.OrgId); err != nil {
+		t.Errorf("DeleteOrganization() = %s. want nil", err)
+	}
+}
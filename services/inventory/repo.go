@@ -0,0 +1,43 @@
+package inventory
+
+import (
+	"context"
+	"time"
+)
+
+// The name, purchase unit and as-purchased cost per unit of an ingredient
+type ingredientCost struct {
+	name        string
+	unit        string
+	costPerUnit float64
+}
+
+// The vendor an ingredient is bought from and its price in effect at a time
+type purchaseTerms struct {
+	vendorId      int
+	unit          string
+	unitCount     float64
+	purchasePrice int64
+}
+
+type Repo interface {
+	CreateCount(context.Context, Count) (Count, error)
+	GetCount(context.Context, int, int) (Count, error)
+	ListCounts(context.Context, int, Filter) ([]Count, error)
+	DeleteCount(context.Context, int, int) error
+	SetCountItem(context.Context, int, int, CountItem) error
+	RemoveCountItem(context.Context, int, int, int) error
+
+	RecordPurchase(context.Context, Purchase) (Purchase, error)
+	ListPurchases(context.Context, int, Filter) ([]Purchase, error)
+	DeletePurchase(context.Context, int, int) error
+
+	RecordSale(context.Context, Sale) (Sale, error)
+	ListSales(context.Context, int, Filter) ([]Sale, error)
+	DeleteSale(context.Context, int, int) error
+
+	IngredientUnit(context.Context, int, int) (string, error)
+	PurchaseTerms(context.Context, int, int, time.Time) (purchaseTerms, error)
+	// Every ingredient of the organization priced as of a time
+	IngredientCosts(context.Context, int, time.Time) (map[int]ingredientCost, error)
+}
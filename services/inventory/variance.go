@@ -0,0 +1,186 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"moon-cost/services/product"
+	"moon-cost/units"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Compares the actual usage of every ingredient at a location between two
+// counts with the theoretical usage of the products sold in between. Sales and
+// purchases are included after the opening count up to and including the
+// closing count. An ingredient missing from a count is counted as none on hand
+func (s *Service) Variance(ctx context.Context, organizationId int, openingCountId int, closingCountId int) (Variance, error) {
+	opening, err := s.Repo.GetCount(ctx, organizationId, openingCountId)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	closing, err := s.Repo.GetCount(ctx, organizationId, closingCountId)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	if opening.LocationId != closing.LocationId {
+		return Variance{}, fmt.Errorf("%w: counts are of different locations", InvalidVarianceError)
+	}
+
+	if !opening.CountedAt.Before(closing.CountedAt) {
+		return Variance{}, fmt.Errorf("%w: opening count must be before the closing count", InvalidVarianceError)
+	}
+
+	filter := Filter{
+		LocationId: opening.LocationId,
+		From:       opening.CountedAt.Add(time.Millisecond),
+		To:         closing.CountedAt,
+	}
+
+	purchases, err := s.Repo.ListPurchases(ctx, organizationId, filter)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	sales, err := s.Repo.ListSales(ctx, organizationId, filter)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	costs, err := s.Repo.IngredientCosts(ctx, organizationId, closing.CountedAt)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return Variance{}, err
+	}
+
+	variance := Variance{
+		LocationId:     opening.LocationId,
+		OpeningCountId: opening.Id,
+		ClosingCountId: closing.Id,
+		From:           opening.CountedAt,
+		To:             closing.CountedAt,
+		Lines:          []VarianceLine{},
+	}
+
+	lines := varianceLines{costs: costs, registry: registry, lines: map[int]*VarianceLine{}}
+
+	for _, item := range opening.Items {
+		if err := lines.add(item.IngredientId, item.Quantity, item.Unit, func(l *VarianceLine, q float64) { l.Opening += q }); err != nil {
+			return Variance{}, err
+		}
+	}
+
+	for _, item := range closing.Items {
+		if err := lines.add(item.IngredientId, item.Quantity, item.Unit, func(l *VarianceLine, q float64) { l.Closing += q }); err != nil {
+			return Variance{}, err
+		}
+	}
+
+	for _, purchase := range purchases {
+		if err := lines.add(purchase.IngredientId, purchase.Quantity, purchase.Unit, func(l *VarianceLine, q float64) { l.Purchases += q }); err != nil {
+			return Variance{}, err
+		}
+	}
+
+	usages := map[int][]product.IngredientUsage{}
+
+	for _, sale := range sales {
+		usage, ok := usages[sale.ProductId]
+
+		if !ok {
+			usage, err = s.Recipes.Usage(ctx, organizationId, sale.ProductId, closing.CountedAt)
+
+			if err != nil {
+				return Variance{}, err
+			}
+
+			usages[sale.ProductId] = usage
+		}
+
+		variance.ServingsSold += sale.Quantity
+
+		for _, u := range usage {
+			amount := u.Amount * float64(sale.Quantity)
+
+			if err := lines.add(u.IngredientId, amount, u.Unit, func(l *VarianceLine, q float64) { l.Theoretical += q }); err != nil {
+				return Variance{}, err
+			}
+		}
+	}
+
+	for _, line := range lines.lines {
+		line.Actual = line.Opening + line.Purchases - line.Closing
+		line.Variance = line.Actual - line.Theoretical
+		line.ActualCost = line.Actual * line.CostPerUnit
+		line.TheoreticalCost = line.Theoretical * line.CostPerUnit
+		line.VarianceCost = line.Variance * line.CostPerUnit
+
+		variance.ActualCost += line.ActualCost
+		variance.TheoreticalCost += line.TheoreticalCost
+		variance.VarianceCost += line.VarianceCost
+		variance.Lines = append(variance.Lines, *line)
+	}
+
+	slices.SortFunc(variance.Lines, func(a, b VarianceLine) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	if variance.TheoreticalCost != 0 {
+		variance.VariancePercent = variance.VarianceCost / variance.TheoreticalCost * 100
+	}
+
+	return variance, nil
+}
+
+// varianceLines collects the quantities of each ingredient in the unit it is
+// purchased in
+type varianceLines struct {
+	costs    map[int]ingredientCost
+	registry *units.Registry
+	lines    map[int]*VarianceLine
+}
+
+// Converts quantity to the ingredient's unit and applies it to the
+// ingredient's line with set
+func (v varianceLines) add(ingredientId int, quantity float64, unit string, set func(*VarianceLine, float64)) error {
+	cost, ok := v.costs[ingredientId]
+
+	if !ok {
+		return nil
+	}
+
+	line, ok := v.lines[ingredientId]
+
+	if !ok {
+		line = &VarianceLine{
+			IngredientId: ingredientId,
+			Name:         cost.name,
+			Unit:         cost.unit,
+			CostPerUnit:  cost.costPerUnit,
+		}
+
+		v.lines[ingredientId] = line
+	}
+
+	converted, err := v.registry.Convert(quantity, unit, cost.unit)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", cost.name, err)
+	}
+
+	set(line, converted)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package organization
+
+import "time"
+
+type Organization struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Location struct {
+	Id             int    `json:"id"`
+	OrganizationId int    `json:"organizationId"`
+	Address        string `json:"address"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+}
+
+// Role of a member within an organization. Roles are ordered so an owner can
+// do anything a manager can and a manager can do anything a viewer can
+type Role string
+
+const (
+	RoleOwner   Role = "owner"
+	RoleManager Role = "manager"
+	RoleViewer  Role = "viewer"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:  1,
+	RoleManager: 2,
+	RoleOwner:   3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+
+	return ok
+}
+
+// Allows reports whether a member with role r meets the required role
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+type Member struct {
+	OrganizationId int       `json:"organizationId"`
+	UserId         int       `json:"userId"`
+	Email          string    `json:"email"`
+	Firstname      string    `json:"firstname"`
+	Lastname       string    `json:"lastname"`
+	Role           Role      `json:"role"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
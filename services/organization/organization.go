@@ -0,0 +1,260 @@
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"strings"
+)
+
+var (
+	OrganizationNotFoundError  = errors.New("Organization not found")
+	LocationNotFoundError      = errors.New("Location not found")
+	LocationInUseError         = errors.New("Location is in use")
+	InvalidOrganizationError   = errors.New("Organization name is required")
+	MemberNotFoundError        = errors.New("Member not found")
+	MemberExistsError          = errors.New("User is already a member of the organization")
+	MemberAccountNotFoundError = errors.New("No account found for email")
+	InvalidMemberError         = errors.New("Invalid member")
+	LastOwnerError             = errors.New("Organization must have at least one owner")
+)
+
+type Service struct {
+	Repo   Repo
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Logger: logging.Logger(logger, slog.String("service", "organization")),
+		Now:    common.TimeNow{},
+	}
+}
+
+type OrganizationInput struct {
+	Name string
+}
+
+// Creates an organization owned by the user creating it
+func (s *Service) CreateOrganization(ctx context.Context, userId int, input OrganizationInput) (Organization, error) {
+	name := strings.TrimSpace(input.Name)
+
+	if name == "" {
+		return Organization{}, InvalidOrganizationError
+	}
+
+	organization, err := s.Repo.CreateOrganization(ctx, createOrganization{
+		name:      name,
+		ownerId:   userId,
+		createdAt: s.Now.Now(),
+	})
+
+	if err != nil {
+		return organization, err
+	}
+
+	s.Logger.Info("Created organization", "organization", organization.Id)
+
+	return organization, nil
+}
+
+func (s *Service) GetOrganization(ctx context.Context, id int) (Organization, error) {
+	return s.Repo.GetOrganization(ctx, id)
+}
+
+func (s *Service) UpdateOrganization(ctx context.Context, id int, input OrganizationInput) (Organization, error) {
+	name := strings.TrimSpace(input.Name)
+
+	if name == "" {
+		return Organization{}, InvalidOrganizationError
+	}
+
+	return s.Repo.UpdateOrganization(ctx, updateOrganization{
+		id:        id,
+		name:      name,
+		updatedAt: s.Now.Now(),
+	})
+}
+
+// Deletes an organization along with all of its locations
+func (s *Service) DeleteOrganization(ctx context.Context, id int) error {
+	if err := s.Repo.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+
+	s.Logger.Info("Deleted organization", "organization", id)
+
+	return nil
+}
+
+// Lists the organizations the user is a member of
+func (s *Service) ListOrganizations(ctx context.Context, userId int) ([]Organization, error) {
+	return s.Repo.ListOrganizations(ctx, userId)
+}
+
+type LocationInput struct {
+	Address string
+	City    string
+	State   string
+}
+
+func (s *Service) CreateLocation(ctx context.Context, organizationId int, input LocationInput) (Location, error) {
+	if _, err := s.Repo.GetOrganization(ctx, organizationId); err != nil {
+		return Location{}, err
+	}
+
+	return s.Repo.CreateLocation(ctx, Location{
+		OrganizationId: organizationId,
+		Address:        input.Address,
+		City:           input.City,
+		State:          input.State,
+	})
+}
+
+func (s *Service) GetLocation(ctx context.Context, organizationId int, id int) (Location, error) {
+	return s.Repo.GetLocation(ctx, organizationId, id)
+}
+
+func (s *Service) UpdateLocation(ctx context.Context, organizationId int, id int, input LocationInput) (Location, error) {
+	return s.Repo.UpdateLocation(ctx, Location{
+		Id:             id,
+		OrganizationId: organizationId,
+		Address:        input.Address,
+		City:           input.City,
+		State:          input.State,
+	})
+}
+
+// Returns LocationInUseError when inventory counts, purchases or sales are
+// recorded at it
+func (s *Service) DeleteLocation(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteLocation(ctx, organizationId, id)
+}
+
+func (s *Service) ListLocations(ctx context.Context, organizationId int) ([]Location, error) {
+	if _, err := s.Repo.GetOrganization(ctx, organizationId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListLocations(ctx, organizationId)
+}
+
+type MemberInput struct {
+	Email string
+	Role  Role
+}
+
+// Adds the user with an existing account for email to the organization
+func (s *Service) InviteMember(ctx context.Context, organizationId int, input MemberInput) (Member, error) {
+	email := strings.TrimSpace(input.Email)
+
+	if email == "" {
+		return Member{}, fmt.Errorf("%w: email is required", InvalidMemberError)
+	}
+
+	if !input.Role.Valid() {
+		return Member{}, fmt.Errorf("%w: unknown role %q", InvalidMemberError, input.Role)
+	}
+
+	if _, err := s.Repo.GetOrganization(ctx, organizationId); err != nil {
+		return Member{}, err
+	}
+
+	member, err := s.Repo.AddMember(ctx, addMember{
+		organizationId: organizationId,
+		email:          email,
+		role:           input.Role,
+		createdAt:      s.Now.Now(),
+	})
+
+	if err != nil {
+		return member, err
+	}
+
+	s.Logger.Info("Added member", "organization", organizationId, "user", member.UserId, "role", member.Role)
+
+	return member, nil
+}
+
+func (s *Service) GetMember(ctx context.Context, organizationId int, userId int) (Member, error) {
+	return s.Repo.GetMember(ctx, organizationId, userId)
+}
+
+func (s *Service) ListMembers(ctx context.Context, organizationId int) ([]Member, error) {
+	if _, err := s.Repo.GetOrganization(ctx, organizationId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListMembers(ctx, organizationId)
+}
+
+func (s *Service) UpdateMemberRole(ctx context.Context, organizationId int, userId int, role Role) (Member, error) {
+	if !role.Valid() {
+		return Member{}, fmt.Errorf("%w: unknown role %q", InvalidMemberError, role)
+	}
+
+	member, err := s.Repo.GetMember(ctx, organizationId, userId)
+
+	if err != nil {
+		return member, err
+	}
+
+	if member.Role == RoleOwner && role != RoleOwner {
+		if err := s.ensureOtherOwner(ctx, organizationId); err != nil {
+			return member, err
+		}
+	}
+
+	return s.Repo.UpdateMemberRole(ctx, organizationId, userId, role)
+}
+
+func (s *Service) RemoveMember(ctx context.Context, organizationId int, userId int) error {
+	member, err := s.Repo.GetMember(ctx, organizationId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if member.Role == RoleOwner {
+		if err := s.ensureOtherOwner(ctx, organizationId); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Repo.RemoveMember(ctx, organizationId, userId); err != nil {
+		return err
+	}
+
+	s.Logger.Info("Removed member", "organization", organizationId, "user", userId)
+
+	return nil
+}
+
+// Returns LastOwnerError unless the organization has more than one owner so
+// an owner can never be demoted or removed when no other owner would remain
+func (s *Service) ensureOtherOwner(ctx context.Context, organizationId int) error {
+	members, err := s.Repo.ListMembers(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+
+	for _, member := range members {
+		if member.Role == RoleOwner {
+			owners++
+		}
+	}
+
+	if owners < 2 {
+		return LastOwnerError
+	}
+
+	return nil
+}
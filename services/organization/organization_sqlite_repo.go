@@ -0,0 +1,557 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+func scanOrganization(row common.Scanner) (Organization, error) {
+	var organization Organization
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&organization.Id,
+		&organization.Name,
+		&createdAt,
+		&updatedAt,
+	)
+
+	organization.CreatedAt = time.UnixMilli(createdAt)
+	organization.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return organization, err
+}
+
+const createOrganizationQuery = `
+INSERT INTO organizations (name, createdAt, updatedAt)
+VALUES (?, ?, ?)
+`
+
+const createOwnerQuery = `
+INSERT INTO organization_members (organizationId, userId, role, createdAt)
+VALUES (?, ?, ?, ?)
+`
+
+// Creates the organization and its owner membership in a single transaction
+func (s *SQLiteRepo) CreateOrganization(ctx context.Context, input createOrganization) (Organization, error) {
+	organization := Organization{
+		Name:      input.name,
+		CreatedAt: input.createdAt,
+		UpdatedAt: input.createdAt,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return organization, err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		createOrganizationQuery,
+		input.name,
+		input.createdAt.UnixMilli(),
+		input.createdAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return organization, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return organization, err
+	}
+
+	organization.Id = int(id)
+
+	_, err = tx.ExecContext(
+		ctx,
+		createOwnerQuery,
+		organization.Id,
+		input.ownerId,
+		RoleOwner,
+		input.createdAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return organization, err
+	}
+
+	return organization, tx.Commit()
+}
+
+const getOrganizationQuery = `
+SELECT id, name, createdAt, updatedAt
+FROM organizations
+WHERE id = ?
+`
+
+func (s *SQLiteRepo) GetOrganization(ctx context.Context, id int) (Organization, error) {
+	organization, err := scanOrganization(s.db.QueryRowContext(ctx, getOrganizationQuery, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return organization, OrganizationNotFoundError
+	}
+
+	return organization, err
+}
+
+const updateOrganizationQuery = `
+UPDATE organizations
+SET name = ?, updatedAt = ?
+WHERE id = ?
+`
+
+func (s *SQLiteRepo) UpdateOrganization(ctx context.Context, input updateOrganization) (Organization, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		updateOrganizationQuery,
+		input.name,
+		input.updatedAt.UnixMilli(),
+		input.id,
+	)
+
+	if err != nil {
+		return Organization{}, err
+	}
+
+	if err := common.ExpectAffected(res, OrganizationNotFoundError); err != nil {
+		return Organization{}, err
+	}
+
+	return s.GetOrganization(ctx, input.id)
+}
+
+// Recipe lines and inventory history restrict deleting the ingredients,
+// prep recipes, products and locations they use, which is checked as each
+// row cascades, so they are deleted first
+var deleteOrganizationDataQueries = []string{
+	`DELETE FROM sales WHERE organizationId = ?`,
+	`DELETE FROM inventory_purchases WHERE organizationId = ?`,
+	`DELETE FROM inventory_counts WHERE organizationId = ?`,
+	`DELETE FROM product_ingredients WHERE productId IN (SELECT id FROM products WHERE organizationId = ?)`,
+	`DELETE FROM prep_recipe_ingredients WHERE recipeId IN (SELECT id FROM prep_recipes WHERE organizationId = ?)`,
+	`DELETE FROM locations WHERE organizationId = ?`,
+}
+
+func (s *SQLiteRepo) DeleteOrganization(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for _, query := range deleteOrganizationDataQueries {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM organizations WHERE id = ?`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, OrganizationNotFoundError); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const listOrganizationsQuery = `
+SELECT organizations.id, organizations.name, organizations.createdAt, organizations.updatedAt
+FROM organizations
+JOIN organization_members ON organization_members.organizationId = organizations.id
+WHERE organization_members.userId = ?
+ORDER BY organizations.name ASC
+`
+
+func (s *SQLiteRepo) ListOrganizations(ctx context.Context, userId int) ([]Organization, error) {
+	rows, err := s.db.QueryContext(ctx, listOrganizationsQuery, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	organizations := []Organization{}
+
+	for rows.Next() {
+		organization, err := scanOrganization(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		organizations = append(organizations, organization)
+	}
+
+	return organizations, rows.Err()
+}
+
+const locationColumns = `id, organizationId, COALESCE(address, ''), COALESCE(city, ''), COALESCE(state, '')`
+
+func scanLocation(row common.Scanner) (Location, error) {
+	var location Location
+
+	err := row.Scan(
+		&location.Id,
+		&location.OrganizationId,
+		&location.Address,
+		&location.City,
+		&location.State,
+	)
+
+	return location, err
+}
+
+const createLocationQuery = `
+INSERT INTO locations (address, city, state, organizationId)
+VALUES (?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateLocation(ctx context.Context, location Location) (Location, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		createLocationQuery,
+		location.Address,
+		location.City,
+		location.State,
+		location.OrganizationId,
+	)
+
+	if err != nil {
+		return location, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return location, err
+	}
+
+	location.Id = int(id)
+
+	return location, nil
+}
+
+const getLocationQuery = `SELECT ` + locationColumns + ` FROM locations WHERE organizationId = ? AND id = ?`
+
+func (s *SQLiteRepo) GetLocation(ctx context.Context, organizationId int, id int) (Location, error) {
+	location, err := scanLocation(s.db.QueryRowContext(ctx, getLocationQuery, organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return location, LocationNotFoundError
+	}
+
+	return location, err
+}
+
+const updateLocationQuery = `
+UPDATE locations
+SET address = ?, city = ?, state = ?
+WHERE organizationId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdateLocation(ctx context.Context, location Location) (Location, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		updateLocationQuery,
+		location.Address,
+		location.City,
+		location.State,
+		location.OrganizationId,
+		location.Id,
+	)
+
+	if err != nil {
+		return location, err
+	}
+
+	if err := common.ExpectAffected(res, LocationNotFoundError); err != nil {
+		return location, err
+	}
+
+	return location, nil
+}
+
+const locationInUseQuery = `
+SELECT
+  EXISTS (SELECT 1 FROM inventory_counts WHERE locationId = ?)
+  OR EXISTS (SELECT 1 FROM inventory_purchases WHERE locationId = ?)
+  OR EXISTS (SELECT 1 FROM sales WHERE locationId = ?)
+`
+
+// Locations with recorded counts, purchases or sales can not be deleted
+func (s *SQLiteRepo) DeleteLocation(ctx context.Context, organizationId int, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM locations WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return LocationNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var inUse bool
+
+	if err := tx.QueryRowContext(ctx, locationInUseQuery, id, id, id).Scan(&inUse); err != nil {
+		return err
+	}
+
+	if inUse {
+		return LocationInUseError
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM locations WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, LocationNotFoundError); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const listLocationsQuery = `SELECT ` + locationColumns + ` FROM locations WHERE organizationId = ? ORDER BY id ASC`
+
+func (s *SQLiteRepo) ListLocations(ctx context.Context, organizationId int) ([]Location, error) {
+	rows, err := s.db.QueryContext(ctx, listLocationsQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locations := []Location{}
+
+	for rows.Next() {
+		location, err := scanLocation(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations, rows.Err()
+}
+
+const memberColumns = `
+  organization_members.organizationId,
+  organization_members.userId,
+  accounts.email,
+  users.firstname,
+  users.lastname,
+  organization_members.role,
+  organization_members.createdAt
+`
+
+const memberJoins = `
+FROM organization_members
+JOIN users ON users.id = organization_members.userId
+JOIN accounts ON accounts.userId = users.id
+`
+
+func scanMember(row common.Scanner) (Member, error) {
+	var member Member
+	var createdAt int64
+
+	err := row.Scan(
+		&member.OrganizationId,
+		&member.UserId,
+		&member.Email,
+		&member.Firstname,
+		&member.Lastname,
+		&member.Role,
+		&createdAt,
+	)
+
+	member.CreatedAt = time.UnixMilli(createdAt)
+
+	return member, err
+}
+
+const addMemberQuery = `
+INSERT INTO organization_members (organizationId, userId, role, createdAt)
+SELECT ?, accounts.userId, ?, ?
+FROM accounts
+WHERE accounts.email = ?
+`
+
+const memberExistsQuery = `
+SELECT COUNT(*)
+FROM organization_members
+JOIN accounts ON accounts.userId = organization_members.userId
+WHERE organization_members.organizationId = ? AND accounts.email = ?
+`
+
+func (s *SQLiteRepo) AddMember(ctx context.Context, input addMember) (Member, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return Member{}, err
+	}
+
+	defer tx.Rollback()
+
+	var existing int
+
+	if err := tx.QueryRowContext(ctx, memberExistsQuery, input.organizationId, input.email).Scan(&existing); err != nil {
+		return Member{}, err
+	}
+
+	if existing > 0 {
+		return Member{}, MemberExistsError
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		addMemberQuery,
+		input.organizationId,
+		input.role,
+		input.createdAt.UnixMilli(),
+		input.email,
+	)
+
+	if err != nil {
+		return Member{}, err
+	}
+
+	if err := common.ExpectAffected(res, MemberAccountNotFoundError); err != nil {
+		return Member{}, err
+	}
+
+	member, err := scanMember(tx.QueryRowContext(ctx, getMemberByEmailQuery, input.organizationId, input.email))
+
+	if err != nil {
+		return member, err
+	}
+
+	return member, tx.Commit()
+}
+
+const getMemberByEmailQuery = `SELECT ` + memberColumns + memberJoins + `
+WHERE organization_members.organizationId = ? AND accounts.email = ?
+`
+
+const getMemberQuery = `SELECT ` + memberColumns + memberJoins + `
+WHERE organization_members.organizationId = ? AND organization_members.userId = ?
+`
+
+func (s *SQLiteRepo) GetMember(ctx context.Context, organizationId int, userId int) (Member, error) {
+	member, err := scanMember(s.db.QueryRowContext(ctx, getMemberQuery, organizationId, userId))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return member, MemberNotFoundError
+	}
+
+	return member, err
+}
+
+const updateMemberRoleQuery = `
+UPDATE organization_members
+SET role = ?
+WHERE organizationId = ? AND userId = ?
+`
+
+func (s *SQLiteRepo) UpdateMemberRole(ctx context.Context, organizationId int, userId int, role Role) (Member, error) {
+	res, err := s.db.ExecContext(ctx, updateMemberRoleQuery, role, organizationId, userId)
+
+	if err != nil {
+		return Member{}, err
+	}
+
+	if err := common.ExpectAffected(res, MemberNotFoundError); err != nil {
+		return Member{}, err
+	}
+
+	return s.GetMember(ctx, organizationId, userId)
+}
+
+const removeMemberQuery = `
+DELETE FROM organization_members
+WHERE organizationId = ? AND userId = ?
+`
+
+func (s *SQLiteRepo) RemoveMember(ctx context.Context, organizationId int, userId int) error {
+	res, err := s.db.ExecContext(ctx, removeMemberQuery, organizationId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, MemberNotFoundError)
+}
+
+const listMembersQuery = `SELECT ` + memberColumns + memberJoins + `
+WHERE organization_members.organizationId = ?
+ORDER BY users.lastname ASC, users.firstname ASC
+`
+
+func (s *SQLiteRepo) ListMembers(ctx context.Context, organizationId int) ([]Member, error) {
+	rows, err := s.db.QueryContext(ctx, listMembersQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := []Member{}
+
+	for rows.Next() {
+		member, err := scanMember(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
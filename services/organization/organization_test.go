@@ -0,0 +1,265 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"moon-cost/moontest"
+	"testing"
+)
+
+func newTestService(t *testing.T) (*Service, *sql.DB, int) {
+	db := moontest.LoadTestDB(t)
+	userId := moontest.InsertTestUser(t, db, "owner@test.com")
+
+	return NewService(NewSQLiteRepo(db), slog.New(slog.DiscardHandler)), db, userId
+}
+
+func TestOrganizationCRUD(t *testing.T) {
+	ctx := context.Background()
+	service, _, userId := newTestService(t)
+
+	if _, err := service.CreateOrganization(ctx, userId, OrganizationInput{Name: "  "}); !errors.Is(err, InvalidOrganizationError) {
+		t.Errorf("CreateOrganization(blank) = _, %v. want %s", err, InvalidOrganizationError)
+	}
+
+	created, err := service.CreateOrganization(ctx, userId, OrganizationInput{Name: "Moon Cafe"})
+
+	if err != nil {
+		t.Fatalf("CreateOrganization() = _, %s. want nil", err)
+	}
+
+	updated, err := service.UpdateOrganization(ctx, created.Id, OrganizationInput{Name: "Moon Bistro"})
+
+	if err != nil {
+		t.Fatalf("UpdateOrganization() = _, %s. want nil", err)
+	}
+
+	if updated.Name != "Moon Bistro" {
+		t.Errorf("UpdateOrganization().Name = %s. want %s", updated.Name, "Moon Bistro")
+	}
+
+	organizations, err := service.ListOrganizations(ctx, userId)
+
+	if err != nil {
+		t.Fatalf("ListOrganizations() = _, %s. want nil", err)
+	}
+
+	if len(organizations) != 1 || organizations[0].Id != created.Id {
+		t.Errorf("ListOrganizations() = %v. want [%d]", organizations, created.Id)
+	}
+
+	if err := service.DeleteOrganization(ctx, created.Id); err != nil {
+		t.Fatalf("DeleteOrganization() = %s. want nil", err)
+	}
+
+	if _, err := service.GetOrganization(ctx, created.Id); !errors.Is(err, OrganizationNotFoundError) {
+		t.Errorf("GetOrganization(deleted) = _, %v. want %s", err, OrganizationNotFoundError)
+	}
+
+	if _, err := service.UpdateOrganization(ctx, created.Id, OrganizationInput{Name: "Gone"}); !errors.Is(err, OrganizationNotFoundError) {
+		t.Errorf("UpdateOrganization(deleted) = _, %v. want %s", err, OrganizationNotFoundError)
+	}
+}
+
+func TestLocationCRUD(t *testing.T) {
+	ctx := context.Background()
+	service, _, userId := newTestService(t)
+
+	organization, err := service.CreateOrganization(ctx, userId, OrganizationInput{Name: "Moon Cafe"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := service.CreateOrganization(ctx, userId, OrganizationInput{Name: "Other Cafe"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.CreateLocation(ctx, 1000, LocationInput{City: "Nowhere"}); !errors.Is(err, OrganizationNotFoundError) {
+		t.Errorf("CreateLocation(unknown org) = _, %v. want %s", err, OrganizationNotFoundError)
+	}
+
+	location, err := service.CreateLocation(ctx, organization.Id, LocationInput{Address: "1 Main St", City: "Austin", State: "TX"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	if location.Id == 0 {
+		t.Errorf("CreateLocation().Id = 0. want generated id")
+	}
+
+	if _, err := service.GetLocation(ctx, other.Id, location.Id); !errors.Is(err, LocationNotFoundError) {
+		t.Errorf("GetLocation(other org) = _, %v. want %s", err, LocationNotFoundError)
+	}
+
+	updated, err := service.UpdateLocation(ctx, organization.Id, location.Id, LocationInput{Address: "2 Main St", City: "Austin", State: "TX"})
+
+	if err != nil {
+		t.Fatalf("UpdateLocation() = _, %s. want nil", err)
+	}
+
+	fetched, err := service.GetLocation(ctx, organization.Id, location.Id)
+
+	if err != nil {
+		t.Fatalf("GetLocation() = _, %s. want nil", err)
+	}
+
+	if fetched != updated {
+		t.Errorf("GetLocation() = %v. want %v", fetched, updated)
+	}
+
+	locations, err := service.ListLocations(ctx, organization.Id)
+
+	if err != nil {
+		t.Fatalf("ListLocations() = _, %s. want nil", err)
+	}
+
+	if len(locations) != 1 {
+		t.Errorf("len(ListLocations()) = %d. want 1", len(locations))
+	}
+
+	if err := service.DeleteLocation(ctx, other.Id, location.Id); !errors.Is(err, LocationNotFoundError) {
+		t.Errorf("DeleteLocation(other org) = %v. want %s", err, LocationNotFoundError)
+	}
+
+	if err := service.DeleteLocation(ctx, organization.Id, location.Id); err != nil {
+		t.Errorf("DeleteLocation() = %s. want nil", err)
+	}
+}
+
+func TestListOrganizationsOnlyIncludesMemberships(t *testing.T) {
+	ctx := context.Background()
+	service, db, userId := newTestService(t)
+	otherUserId := moontest.InsertTestUser(t, db, "other@test.com")
+
+	if _, err := service.CreateOrganization(ctx, userId, OrganizationInput{Name: "Moon Cafe"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.CreateOrganization(ctx, otherUserId, OrganizationInput{Name: "Other Cafe"}); err != nil {
+		t.Fatal(err)
+	}
+
+	organizations, err := service.ListOrganizations(ctx, userId)
+
+	if err != nil {
+		t.Fatalf("ListOrganizations() = _, %s. want nil", err)
+	}
+
+	if len(organizations) != 1 || organizations[0].Name != "Moon Cafe" {
+		t.Errorf("ListOrganizations() = %v. want [Moon Cafe]", organizations)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	ctx := context.Background()
+	service, db, ownerId := newTestService(t)
+	managerId := moontest.InsertTestUser(t, db, "manager@test.com")
+
+	organization, err := service.CreateOrganization(ctx, ownerId, OrganizationInput{Name: "Moon Cafe"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner, err := service.GetMember(ctx, organization.Id, ownerId)
+
+	if err != nil {
+		t.Fatalf("GetMember(owner) = _, %s. want nil", err)
+	}
+
+	if owner.Role != RoleOwner {
+		t.Errorf("GetMember(owner).Role = %s. want %s", owner.Role, RoleOwner)
+	}
+
+	inviteErrors := []struct {
+		test  string
+		input MemberInput
+		err   error
+	}{
+		{test: "blank email", input: MemberInput{Role: RoleViewer}, err: InvalidMemberError},
+		{test: "unknown role", input: MemberInput{Email: "manager@test.com", Role: "chef"}, err: InvalidMemberError},
+		{test: "unknown account", input: MemberInput{Email: "nobody@test.com", Role: RoleViewer}, err: MemberAccountNotFoundError},
+		{test: "existing member", input: MemberInput{Email: "owner@test.com", Role: RoleViewer}, err: MemberExistsError},
+	}
+
+	for _, test := range inviteErrors {
+		t.Run(test.test, func(t *testing.T) {
+			if _, err := service.InviteMember(ctx, organization.Id, test.input); !errors.Is(err, test.err) {
+				t.Errorf("InviteMember() = _, %v. want %s", err, test.err)
+			}
+		})
+	}
+
+	invited, err := service.InviteMember(ctx, organization.Id, MemberInput{Email: "manager@test.com", Role: RoleViewer})
+
+	if err != nil {
+		t.Fatalf("InviteMember() = _, %s. want nil", err)
+	}
+
+	if invited.UserId != managerId || invited.Role != RoleViewer {
+		t.Errorf("InviteMember() = %v. want user %d with role %s", invited, managerId, RoleViewer)
+	}
+
+	updated, err := service.UpdateMemberRole(ctx, organization.Id, managerId, RoleManager)
+
+	if err != nil {
+		t.Fatalf("UpdateMemberRole() = _, %s. want nil", err)
+	}
+
+	if updated.Role != RoleManager {
+		t.Errorf("UpdateMemberRole().Role = %s. want %s", updated.Role, RoleManager)
+	}
+
+	if _, err := service.UpdateMemberRole(ctx, organization.Id, ownerId, RoleViewer); !errors.Is(err, LastOwnerError) {
+		t.Errorf("UpdateMemberRole(last owner) = _, %v. want %s", err, LastOwnerError)
+	}
+
+	if err := service.RemoveMember(ctx, organization.Id, ownerId); !errors.Is(err, LastOwnerError) {
+		t.Errorf("RemoveMember(last owner) = %v. want %s", err, LastOwnerError)
+	}
+
+	members, err := service.ListMembers(ctx, organization.Id)
+
+	if err != nil {
+		t.Fatalf("ListMembers() = _, %s. want nil", err)
+	}
+
+	if len(members) != 2 {
+		t.Errorf("len(ListMembers()) = %d. want 2", len(members))
+	}
+
+	if err := service.RemoveMember(ctx, organization.Id, managerId); err != nil {
+		t.Errorf("RemoveMember() = %s. want nil", err)
+	}
+
+	if _, err := service.GetMember(ctx, organization.Id, managerId); !errors.Is(err, MemberNotFoundError) {
+		t.Errorf("GetMember(removed) = _, %v. want %s", err, MemberNotFoundError)
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		expected bool
+	}{
+		{role: RoleOwner, required: RoleOwner, expected: true},
+		{role: RoleOwner, required: RoleViewer, expected: true},
+		{role: RoleManager, required: RoleViewer, expected: true},
+		{role: RoleManager, required: RoleOwner, expected: false},
+		{role: RoleViewer, required: RoleManager, expected: false},
+		{role: Role("chef"), required: RoleViewer, expected: false},
+	}
+
+	for _, test := range tests {
+		if allowed := test.role.Allows(test.required); allowed != test.expected {
+			t.Errorf("%s.Allows(%s) = %t. want %t", test.role, test.required, allowed, test.expected)
+		}
+	}
+}
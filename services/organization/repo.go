@@ -0,0 +1,45 @@
+package organization
+
+import (
+	"context"
+	"time"
+)
+
+type createOrganization struct {
+	name      string
+	ownerId   int
+	createdAt time.Time
+}
+
+type updateOrganization struct {
+	id        int
+	name      string
+	updatedAt time.Time
+}
+
+type addMember struct {
+	organizationId int
+	email          string
+	role           Role
+	createdAt      time.Time
+}
+
+type Repo interface {
+	CreateOrganization(context.Context, createOrganization) (Organization, error)
+	GetOrganization(context.Context, int) (Organization, error)
+	UpdateOrganization(context.Context, updateOrganization) (Organization, error)
+	DeleteOrganization(context.Context, int) error
+	ListOrganizations(context.Context, int) ([]Organization, error)
+
+	CreateLocation(context.Context, Location) (Location, error)
+	GetLocation(context.Context, int, int) (Location, error)
+	UpdateLocation(context.Context, Location) (Location, error)
+	DeleteLocation(context.Context, int, int) error
+	ListLocations(context.Context, int) ([]Location, error)
+
+	AddMember(context.Context, addMember) (Member, error)
+	GetMember(context.Context, int, int) (Member, error)
+	UpdateMemberRole(context.Context, int, int, Role) (Member, error)
+	RemoveMember(context.Context, int, int) error
+	ListMembers(context.Context, int) ([]Member, error)
+}
@@ -0,0 +1,51 @@
+package pricing
+
+import "time"
+
+// Target is the food cost percentage the products of a category should be
+// priced at. Products are within the target band when their food cost is no
+// more than TolerancePercent points from TargetPercent. A blank Category is
+// the default target of the organization
+type Target struct {
+	OrganizationId   int       `json:"organizationId"`
+	Category         string    `json:"category"`
+	TargetPercent    float64   `json:"targetPercent"`
+	TolerancePercent float64   `json:"tolerancePercent"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// Status is how the food cost of a product at its menu price compares to its
+// target band
+type Status string
+
+const (
+	StatusWithin Status = "within"
+	// The food cost is over the band so the product is priced too low
+	StatusAbove Status = "above"
+	// The food cost is under the band so the product is priced too high
+	StatusBelow Status = "below"
+	// The product has no menu price
+	StatusUnpriced Status = "unpriced"
+	// The product could not be costed so it is counted as outside its band
+	StatusError Status = "error"
+)
+
+// Suggestion is the menu price that would bring a product to its target food
+// cost. Money is in cents. Error is set, with StatusError and the costs and
+// suggestion left zero, when the product could not be costed
+type Suggestion struct {
+	ProductId                int     `json:"productId"`
+	Name                     string  `json:"name"`
+	Category                 string  `json:"category"`
+	MenuPrice                int64   `json:"menuPrice"`
+	CostPerServing           float64 `json:"costPerServing"`
+	FoodCostPercent          float64 `json:"foodCostPercent"`
+	TargetPercent            float64 `json:"targetPercent"`
+	TolerancePercent         float64 `json:"tolerancePercent"`
+	SuggestedPrice           int64   `json:"suggestedPrice"`
+	SuggestedFoodCostPercent float64 `json:"suggestedFoodCostPercent"`
+	Status                   Status  `json:"status,omitempty"`
+	OutsideTarget            bool    `json:"outsideTarget"`
+	Error                    string  `json:"error,omitempty"`
+}
@@ -0,0 +1,227 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/services/costing"
+	"moon-cost/services/product"
+	"strings"
+)
+
+var (
+	TargetNotFoundError  = errors.New("Food cost target not found")
+	InvalidTargetError   = errors.New("Invalid food cost target")
+	InvalidRoundingError = errors.New("Invalid rounding")
+)
+
+// Used for products without a target for their category when the
+// organization has not set a default target
+const (
+	DefaultTargetPercent    = 30
+	DefaultTolerancePercent = 2
+)
+
+// Products lists and costs the products of an organization
+type Products interface {
+	ListProducts(context.Context, int, product.Filter) ([]product.Product, error)
+	Cost(context.Context, int, int) (costing.Breakdown, error)
+}
+
+type Service struct {
+	Repo     Repo
+	Products Products
+	Logger   *slog.Logger
+	Now      common.Now
+}
+
+func NewService(repo Repo, products Products, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:     repo,
+		Products: products,
+		Logger:   logging.Logger(logger, slog.String("service", "pricing")),
+		Now:      common.TimeNow{},
+	}
+}
+
+// A blank Category sets the default target of the organization
+type TargetInput struct {
+	Category         string
+	TargetPercent    float64
+	TolerancePercent float64
+}
+
+func (t TargetInput) validate() error {
+	if t.TargetPercent <= 0 || t.TargetPercent >= 100 {
+		return fmt.Errorf("%w: target percent must be between 0 and 100", InvalidTargetError)
+	}
+
+	if t.TolerancePercent < 0 {
+		return fmt.Errorf("%w: tolerance percent can not be negative", InvalidTargetError)
+	}
+
+	return nil
+}
+
+// Creates or replaces the target of a category
+func (s *Service) SetTarget(ctx context.Context, organizationId int, input TargetInput) (Target, error) {
+	if err := input.validate(); err != nil {
+		return Target{}, err
+	}
+
+	now := s.Now.Now()
+
+	return s.Repo.SetTarget(ctx, Target{
+		OrganizationId:   organizationId,
+		Category:         strings.TrimSpace(input.Category),
+		TargetPercent:    input.TargetPercent,
+		TolerancePercent: input.TolerancePercent,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	})
+}
+
+// Lists the targets of the organization with the default target first
+func (s *Service) ListTargets(ctx context.Context, organizationId int) ([]Target, error) {
+	return s.Repo.ListTargets(ctx, organizationId)
+}
+
+func (s *Service) DeleteTarget(ctx context.Context, organizationId int, category string) error {
+	return s.Repo.DeleteTarget(ctx, organizationId, strings.TrimSpace(category))
+}
+
+// targets finds the target of a product's category, falling back to the
+// default target
+type targets struct {
+	byCategory map[string]Target
+	fallback   Target
+}
+
+func (s *Service) targets(ctx context.Context, organizationId int) (targets, error) {
+	list, err := s.Repo.ListTargets(ctx, organizationId)
+
+	if err != nil {
+		return targets{}, err
+	}
+
+	t := targets{
+		byCategory: map[string]Target{},
+		fallback: Target{
+			OrganizationId:   organizationId,
+			TargetPercent:    DefaultTargetPercent,
+			TolerancePercent: DefaultTolerancePercent,
+		},
+	}
+
+	for _, target := range list {
+		if target.Category == "" {
+			t.fallback = target
+			continue
+		}
+
+		t.byCategory[strings.ToLower(target.Category)] = target
+	}
+
+	return t, nil
+}
+
+func (t targets) of(category string) Target {
+	if target, ok := t.byCategory[strings.ToLower(category)]; ok {
+		return target
+	}
+
+	return t.fallback
+}
+
+// Rounding defaults to RoundNone. TagIds only includes products with any of
+// the tags and OutsideOnly only includes products outside their target band
+type SuggestionInput struct {
+	Rounding    Rounding
+	TagIds      []int
+	OutsideOnly bool
+}
+
+// Suggests a menu price for every product that hits the target food cost of
+// its category
+func (s *Service) Suggestions(ctx context.Context, organizationId int, input SuggestionInput) ([]Suggestion, error) {
+	rounding, err := ParseRounding(string(input.Rounding))
+
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := s.targets(ctx, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.Products.ListProducts(ctx, organizationId, product.Filter{TagIds: input.TagIds})
+
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []Suggestion{}
+
+	for _, p := range products {
+		suggestion := s.suggest(ctx, organizationId, p, targets.of(p.Category), rounding)
+
+		if input.OutsideOnly && !suggestion.OutsideTarget {
+			continue
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+func (s *Service) suggest(ctx context.Context, organizationId int, p product.Product, target Target, rounding Rounding) Suggestion {
+	suggestion := Suggestion{
+		ProductId:        p.Id,
+		Name:             p.Name,
+		Category:         p.Category,
+		MenuPrice:        p.MenuPrice,
+		TargetPercent:    target.TargetPercent,
+		TolerancePercent: target.TolerancePercent,
+	}
+
+	breakdown, err := s.Products.Cost(ctx, organizationId, p.Id)
+
+	if err != nil {
+		s.Logger.Warn("Could not cost product", "product", p.Id, "error", err)
+		suggestion.Error = err.Error()
+		suggestion.Status = StatusError
+		suggestion.OutsideTarget = true
+
+		return suggestion
+	}
+
+	suggestion.CostPerServing = breakdown.CostPerServing
+	suggestion.FoodCostPercent = breakdown.FoodCostPercent
+	suggestion.SuggestedPrice = rounding.Round(breakdown.CostPerServing / target.TargetPercent * 100)
+
+	if suggestion.SuggestedPrice > 0 {
+		suggestion.SuggestedFoodCostPercent = breakdown.CostPerServing / float64(suggestion.SuggestedPrice) * 100
+	}
+
+	switch {
+	case p.MenuPrice <= 0:
+		suggestion.Status = StatusUnpriced
+	case math.Abs(breakdown.FoodCostPercent-target.TargetPercent) <= target.TolerancePercent:
+		suggestion.Status = StatusWithin
+	case breakdown.FoodCostPercent > target.TargetPercent:
+		suggestion.Status = StatusAbove
+	default:
+		suggestion.Status = StatusBelow
+	}
+
+	suggestion.OutsideTarget = suggestion.Status != StatusWithin
+
+	return suggestion
+}
@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"moon-cost/common"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+const targetColumns = `organizationId, category, targetPercent, tolerancePercent, createdAt, updatedAt`
+
+func scanTarget(row common.Scanner) (Target, error) {
+	var target Target
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&target.OrganizationId,
+		&target.Category,
+		&target.TargetPercent,
+		&target.TolerancePercent,
+		&createdAt,
+		&updatedAt,
+	)
+
+	target.CreatedAt = time.UnixMilli(createdAt)
+	target.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return target, err
+}
+
+// Replacing a target keeps its createdAt and the category as first written
+const setTargetQuery = `
+INSERT INTO food_cost_targets (organizationId, category, targetPercent, tolerancePercent, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (organizationId, category) DO UPDATE SET
+  targetPercent = excluded.targetPercent,
+  tolerancePercent = excluded.tolerancePercent,
+  updatedAt = excluded.updatedAt
+RETURNING ` + targetColumns
+
+func (s *SQLiteRepo) SetTarget(ctx context.Context, target Target) (Target, error) {
+	return scanTarget(s.db.QueryRowContext(
+		ctx,
+		setTargetQuery,
+		target.OrganizationId,
+		target.Category,
+		target.TargetPercent,
+		target.TolerancePercent,
+		target.CreatedAt.UnixMilli(),
+		target.UpdatedAt.UnixMilli(),
+	))
+}
+
+const listTargetsQuery = `
+SELECT ` + targetColumns + ` FROM food_cost_targets
+WHERE organizationId = ?
+ORDER BY category ASC
+`
+
+func (s *SQLiteRepo) ListTargets(ctx context.Context, organizationId int) ([]Target, error) {
+	rows, err := s.db.QueryContext(ctx, listTargetsQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	targets := []Target{}
+
+	for rows.Next() {
+		target, err := scanTarget(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+func (s *SQLiteRepo) DeleteTarget(ctx context.Context, organizationId int, category string) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM food_cost_targets WHERE organizationId = ? AND category = ?`,
+		organizationId,
+		category,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, TargetNotFoundError)
+}
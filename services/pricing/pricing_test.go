@@ -0,0 +1,291 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"math"
+	"moon-cost/moontest"
+	"moon-cost/services/costing"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/product"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		rounding Rounding
+		price    float64
+		expected int64
+	}{
+		{RoundNone, 1234.4, 1234},
+		{RoundNone, 1234.5, 1235},
+		{RoundNone, 0, 0},
+		{RoundCharm, 1000, 1049},
+		{RoundCharm, 1049, 1049},
+		{RoundCharm, 1049.0000001, 1049},
+		{RoundCharm, 1050, 1099},
+		{RoundCharm, 980, 999},
+		{RoundCharm, 10, 49},
+		{RoundQuarter, 1012, 1000},
+		{RoundQuarter, 1013, 1025},
+		{RoundQuarter, 1090, 1100},
+		{RoundQuarter, 5, 25},
+		{RoundQuarter, -5, 0},
+	}
+
+	for _, test := range tests {
+		if actual := test.rounding.Round(test.price); actual != test.expected {
+			t.Errorf("%s.Round(%f) = %d. want %d", test.rounding, test.price, actual, test.expected)
+		}
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Rounding
+		err      error
+	}{
+		{"", RoundNone, nil},
+		{"charm", RoundCharm, nil},
+		{"quarter", RoundQuarter, nil},
+		{"dime", "", InvalidRoundingError},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseRounding(test.value)
+
+		if actual != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("ParseRounding(%q) = %q, %v. want %q, %v", test.value, actual, err, test.expected, test.err)
+		}
+	}
+}
+
+type testFixture struct {
+	moontest.TestEnv
+	pricing     *Service
+	products    *product.Service
+	ingredients *ingredient.Service
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	env := moontest.NewTestEnv(t)
+	products := product.NewService(product.NewSQLiteRepo(env.DB), env.Logger)
+
+	return testFixture{
+		TestEnv:     env,
+		pricing:     NewService(NewSQLiteRepo(env.DB), products, env.Logger),
+		products:    products,
+		ingredients: ingredient.NewService(ingredient.NewSQLiteRepo(env.DB), env.Logger),
+	}
+}
+
+// Creates a single serving product that costs cost cents
+func (f testFixture) product(t *testing.T, input product.ProductInput, cost int64) product.Product {
+	t.Helper()
+	ctx := context.Background()
+
+	input.Servings = 1
+	created, err := f.products.CreateProduct(ctx, f.OrgId, input)
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	item, err := f.ingredients.CreateIngredient(ctx, f.OrgId, ingredient.IngredientInput{
+		Name:          input.Name + " mix",
+		Unit:          "each",
+		UnitCount:     1,
+		PurchasePrice: cost,
+	})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, created.Id, product.ProductIngredientInput{IngredientId: item.Id, Amount: 1}); err != nil {
+		t.Fatalf("AddProductIngredient() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func TestTargets(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	tests := []struct {
+		test     string
+		input    TargetInput
+		expected error
+	}{
+		{"category", TargetInput{Category: " Pizza ", TargetPercent: 25, TolerancePercent: 3}, nil},
+		{"default", TargetInput{TargetPercent: 32, TolerancePercent: 2}, nil},
+		{"zero target", TargetInput{Category: "Drinks"}, InvalidTargetError},
+		{"target of 100", TargetInput{Category: "Drinks", TargetPercent: 100}, InvalidTargetError},
+		{"negative tolerance", TargetInput{Category: "Drinks", TargetPercent: 20, TolerancePercent: -1}, InvalidTargetError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := f.pricing.SetTarget(ctx, f.OrgId, test.input)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("SetTarget() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+
+	replaced, err := f.pricing.SetTarget(ctx, f.OrgId, TargetInput{Category: "PIZZA", TargetPercent: 28, TolerancePercent: 1})
+
+	if err != nil {
+		t.Fatalf("SetTarget(replace) = _, %s. want nil", err)
+	}
+
+	if replaced.Category != "Pizza" || replaced.TargetPercent != 28 {
+		t.Errorf("SetTarget(replace) = %s %f. want Pizza 28", replaced.Category, replaced.TargetPercent)
+	}
+
+	targets, err := f.pricing.ListTargets(ctx, f.OrgId)
+
+	if err != nil {
+		t.Fatalf("ListTargets() = _, %s. want nil", err)
+	}
+
+	if len(targets) != 2 || targets[0].Category != "" || targets[1].Category != "Pizza" {
+		t.Errorf("ListTargets() = %+v. want default and Pizza", targets)
+	}
+
+	if err := f.pricing.DeleteTarget(ctx, f.OtherOrgId, "Pizza"); !errors.Is(err, TargetNotFoundError) {
+		t.Errorf("DeleteTarget(other org) = %v. want %v", err, TargetNotFoundError)
+	}
+
+	if err := f.pricing.DeleteTarget(ctx, f.OrgId, "pizza"); err != nil {
+		t.Errorf("DeleteTarget() = %s. want nil", err)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	margherita := f.product(t, product.ProductInput{Name: "Margherita", Category: "Pizza", MenuPrice: 1200}, 300)
+	calzone := f.product(t, product.ProductInput{Name: "Calzone", Category: "pizza", MenuPrice: 1500}, 300)
+	latte := f.product(t, product.ProductInput{Name: "Latte", Category: "Drinks", MenuPrice: 500}, 200)
+	water := f.product(t, product.ProductInput{Name: "Water", Category: "Drinks"}, 10)
+
+	suggestions, err := f.pricing.Suggestions(ctx, f.OrgId, SuggestionInput{})
+
+	if err != nil {
+		t.Fatalf("Suggestions() = _, %s. want nil", err)
+	}
+
+	// Without targets every product uses the built in default
+	for _, suggestion := range suggestions {
+		if suggestion.TargetPercent != DefaultTargetPercent {
+			t.Errorf("Suggestions(%s).TargetPercent = %f. want %d", suggestion.Name, suggestion.TargetPercent, DefaultTargetPercent)
+		}
+	}
+
+	if _, err := f.pricing.SetTarget(ctx, f.OrgId, TargetInput{Category: "Pizza", TargetPercent: 25, TolerancePercent: 3}); err != nil {
+		t.Fatalf("SetTarget(pizza) = _, %s. want nil", err)
+	}
+
+	if _, err := f.pricing.SetTarget(ctx, f.OrgId, TargetInput{TargetPercent: 32, TolerancePercent: 2}); err != nil {
+		t.Fatalf("SetTarget(default) = _, %s. want nil", err)
+	}
+
+	expected := map[int]struct {
+		target float64
+		status Status
+		prices map[Rounding]int64
+	}{
+		// 300 / 25% = $12.00
+		margherita.Id: {25, StatusWithin, map[Rounding]int64{RoundNone: 1200, RoundCharm: 1249, RoundQuarter: 1200}},
+		// 20% is under 25% by more than 3 points
+		calzone.Id: {25, StatusBelow, map[Rounding]int64{RoundNone: 1200, RoundCharm: 1249, RoundQuarter: 1200}},
+		// 200 / 32% = $6.25
+		latte.Id: {32, StatusAbove, map[Rounding]int64{RoundNone: 625, RoundCharm: 649, RoundQuarter: 625}},
+		// 10 / 32% = $0.3125
+		water.Id: {32, StatusUnpriced, map[Rounding]int64{RoundNone: 31, RoundCharm: 49, RoundQuarter: 25}},
+	}
+
+	for _, rounding := range []Rounding{RoundNone, RoundCharm, RoundQuarter} {
+		suggestions, err := f.pricing.Suggestions(ctx, f.OrgId, SuggestionInput{Rounding: rounding})
+
+		if err != nil {
+			t.Fatalf("Suggestions(%s) = _, %s. want nil", rounding, err)
+		}
+
+		if len(suggestions) != len(expected) {
+			t.Fatalf("len(Suggestions(%s)) = %d. want %d", rounding, len(suggestions), len(expected))
+		}
+
+		for _, suggestion := range suggestions {
+			want := expected[suggestion.ProductId]
+
+			if suggestion.TargetPercent != want.target {
+				t.Errorf("Suggestions(%s).TargetPercent = %f. want %f", suggestion.Name, suggestion.TargetPercent, want.target)
+			}
+
+			if suggestion.Status != want.status || suggestion.OutsideTarget != (want.status != StatusWithin) {
+				t.Errorf("Suggestions(%s).Status = %s %t. want %s", suggestion.Name, suggestion.Status, suggestion.OutsideTarget, want.status)
+			}
+
+			if suggestion.SuggestedPrice != want.prices[rounding] {
+				t.Errorf("Suggestions(%s, %s).SuggestedPrice = %d. want %d", suggestion.Name, rounding, suggestion.SuggestedPrice, want.prices[rounding])
+			}
+
+			actualPercent := suggestion.CostPerServing / float64(suggestion.SuggestedPrice) * 100
+
+			if math.Abs(suggestion.SuggestedFoodCostPercent-actualPercent) > 1e-9 {
+				t.Errorf("Suggestions(%s).SuggestedFoodCostPercent = %f. want %f", suggestion.Name, suggestion.SuggestedFoodCostPercent, actualPercent)
+			}
+		}
+	}
+
+	outside, err := f.pricing.Suggestions(ctx, f.OrgId, SuggestionInput{OutsideOnly: true})
+
+	if err != nil {
+		t.Fatalf("Suggestions(outside only) = _, %s. want nil", err)
+	}
+
+	if len(outside) != 3 {
+		t.Errorf("len(Suggestions(outside only)) = %d. want 3", len(outside))
+	}
+
+	if _, err := f.pricing.Suggestions(ctx, f.OrgId, SuggestionInput{Rounding: "dime"}); !errors.Is(err, InvalidRoundingError) {
+		t.Errorf("Suggestions(dime) = _, %v. want %v", err, InvalidRoundingError)
+	}
+}
+
+// Fails to cost every product
+type failingProducts struct {
+	Products
+}
+
+func (failingProducts) Cost(context.Context, int, int) (costing.Breakdown, error) {
+	return costing.Breakdown{}, errors.New("no price")
+}
+
+func TestSuggestionsCostError(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	margherita := f.product(t, product.ProductInput{Name: "Margherita", Category: "Pizza", MenuPrice: 1200}, 300)
+	f.pricing.Products = failingProducts{f.products}
+
+	suggestions, err := f.pricing.Suggestions(ctx, f.OrgId, SuggestionInput{OutsideOnly: true})
+
+	if err != nil {
+		t.Fatalf("Suggestions() = _, %s. want nil", err)
+	}
+
+	if len(suggestions) != 1 || suggestions[0].ProductId != margherita.Id {
+		t.Fatalf("Suggestions(outside only) = %+v. want Margherita", suggestions)
+	}
+
+	if suggestion := suggestions[0]; suggestion.Status != StatusError || !suggestion.OutsideTarget || suggestion.Error == "" {
+		t.Errorf("Suggestions(%s) = %s %t %q. want %s outside the target with the error", suggestion.Name, suggestion.Status, suggestion.OutsideTarget, suggestion.Error, StatusError)
+	}
+}
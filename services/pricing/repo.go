@@ -0,0 +1,9 @@
+package pricing
+
+import "context"
+
+type Repo interface {
+	SetTarget(context.Context, Target) (Target, error)
+	ListTargets(context.Context, int) ([]Target, error)
+	DeleteTarget(context.Context, int, string) error
+}
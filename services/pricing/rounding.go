@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rounding is how a suggested price in cents is rounded to a menu price
+type Rounding string
+
+const (
+	// Rounds to the nearest cent
+	RoundNone Rounding = "none"
+	// Rounds up to the next price ending in .49 or .99 so the food cost stays
+	// at or under the target
+	RoundCharm Rounding = "charm"
+	// Rounds to the nearest quarter
+	RoundQuarter Rounding = "quarter"
+)
+
+// Parses a rounding strategy. Blank is RoundNone
+func ParseRounding(value string) (Rounding, error) {
+	switch Rounding(value) {
+	case "":
+		return RoundNone, nil
+	case RoundNone, RoundCharm, RoundQuarter:
+		return Rounding(value), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", InvalidRoundingError, value)
+}
+
+// Rounds a price in cents
+func (r Rounding) Round(price float64) int64 {
+	// to the cent first so float error can not push a price up a step
+	price = math.Round(price)
+
+	if price <= 0 {
+		return 0
+	}
+
+	switch r {
+	case RoundCharm:
+		// prices ending in .49 or .99 are 49 cents plus a multiple of 50
+		steps := math.Max(math.Ceil((price-49)/50), 0)
+		return 49 + int64(steps)*50
+	case RoundQuarter:
+		return int64(math.Max(math.Round(price/25), 1)) * 25
+	}
+
+	return int64(price)
+}
@@ -0,0 +1,89 @@
+package product
+
+import (
+	"moon-cost/services/costing"
+	"time"
+)
+
+// Product is a menu item. It is made from its ingredients in a batch that
+// yields Servings servings, each sold for MenuPrice cents. Category groups
+// products on menus and reports, like "Pizza" or "Drinks"
+type Product struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category"`
+	MenuPrice      int64     `json:"menuPrice"`
+	Servings       int       `json:"servings"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Filter narrows the products returned by ListProducts. TagIds matches
+// products with any of the tags. Zero values match every product
+type Filter struct {
+	TagIds []int
+}
+
+// ProductIngredient is the Amount of an ingredient or prep recipe used to make
+// a product. Only one of IngredientId and PrepRecipeId is set. Amount is in
+// Unit, or the ingredient's purchase unit or prep recipe's yield unit when
+// Unit is blank. WastePercent is the part of the amount lost while making the
+// product, e.g. sauce left in the pan
+type ProductIngredient struct {
+	Id           int     `json:"id"`
+	ProductId    int     `json:"productId"`
+	IngredientId int     `json:"ingredientId,omitempty"`
+	PrepRecipeId int     `json:"prepRecipeId,omitempty"`
+	Amount       float64 `json:"amount"`
+	Unit         string  `json:"unit"`
+	WastePercent float64 `json:"wastePercent"`
+}
+
+// PrepRecipe is an item made in the kitchen, like a sauce or dough, that is
+// used by products and other prep recipes. A batch yields YieldAmount of
+// YieldUnit
+type PrepRecipe struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	Name           string    `json:"name"`
+	YieldAmount    float64   `json:"yieldAmount"`
+	YieldUnit      string    `json:"yieldUnit"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// PrepIngredient is the Amount of an ingredient or another prep recipe used
+// to make a batch of a prep recipe. It follows the same rules as
+// ProductIngredient
+type PrepIngredient struct {
+	Id           int     `json:"id"`
+	RecipeId     int     `json:"recipeId"`
+	IngredientId int     `json:"ingredientId,omitempty"`
+	PrepRecipeId int     `json:"prepRecipeId,omitempty"`
+	Amount       float64 `json:"amount"`
+	Unit         string  `json:"unit"`
+	WastePercent float64 `json:"wastePercent"`
+}
+
+// IngredientUsage is the amount of an ingredient used, in the unit it is
+// purchased in. Amount is as purchased so it includes trim and waste.
+// CostPerUnit is the as-purchased cost of one unit in cents
+type IngredientUsage struct {
+	IngredientId int     `json:"ingredientId"`
+	Name         string  `json:"name"`
+	Unit         string  `json:"unit"`
+	Amount       float64 `json:"amount"`
+	CostPerUnit  float64 `json:"costPerUnit"`
+}
+
+// PrepCost is the cost of a batch of a prep recipe and of each unit of its
+// yield. Costs are in cents and CostPerUnit uses the edible portion cost
+type PrepCost struct {
+	Lines           []costing.LineCost `json:"lines"`
+	AsPurchasedCost float64            `json:"asPurchasedCost"`
+	Cost            float64            `json:"cost"`
+	YieldAmount     float64            `json:"yieldAmount"`
+	YieldUnit       string             `json:"yieldUnit"`
+	CostPerUnit     float64            `json:"costPerUnit"`
+}
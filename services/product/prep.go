@@ -0,0 +1,262 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	PrepRecipeNotFoundError     = errors.New("Prep recipe not found")
+	PrepIngredientNotFoundError = errors.New("Prep recipe ingredient not found")
+	InvalidPrepRecipeError      = errors.New("Invalid prep recipe")
+	InvalidPrepIngredientError  = errors.New("Invalid prep recipe ingredient")
+	PrepRecipeInUseError        = errors.New("Prep recipe is in use")
+	PrepRecipeCycleError        = errors.New("Prep recipe can not include itself")
+)
+
+type PrepRecipeInput struct {
+	Name        string
+	YieldAmount float64
+	YieldUnit   string
+}
+
+func (p PrepRecipeInput) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", InvalidPrepRecipeError)
+	}
+
+	if p.YieldAmount <= 0 {
+		return fmt.Errorf("%w: yield amount must be greater than 0", InvalidPrepRecipeError)
+	}
+
+	if strings.TrimSpace(p.YieldUnit) == "" {
+		return fmt.Errorf("%w: yield unit is required", InvalidPrepRecipeError)
+	}
+
+	return nil
+}
+
+func (p PrepRecipeInput) prepRecipe(organizationId int) PrepRecipe {
+	return PrepRecipe{
+		OrganizationId: organizationId,
+		Name:           strings.TrimSpace(p.Name),
+		YieldAmount:    p.YieldAmount,
+		YieldUnit:      strings.TrimSpace(p.YieldUnit),
+	}
+}
+
+func (s *Service) validatePrepRecipe(ctx context.Context, organizationId int, input PrepRecipeInput) error {
+	if err := input.validate(); err != nil {
+		return err
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := registry.Lookup(strings.TrimSpace(input.YieldUnit)); err != nil {
+		return fmt.Errorf("%w: %w", InvalidPrepRecipeError, err)
+	}
+
+	return nil
+}
+
+func (s *Service) CreatePrepRecipe(ctx context.Context, organizationId int, input PrepRecipeInput) (PrepRecipe, error) {
+	if err := s.validatePrepRecipe(ctx, organizationId, input); err != nil {
+		return PrepRecipe{}, err
+	}
+
+	recipe := input.prepRecipe(organizationId)
+	recipe.CreatedAt = s.Now.Now()
+	recipe.UpdatedAt = recipe.CreatedAt
+
+	return s.Repo.CreatePrepRecipe(ctx, recipe)
+}
+
+func (s *Service) GetPrepRecipe(ctx context.Context, organizationId int, id int) (PrepRecipe, error) {
+	return s.Repo.GetPrepRecipe(ctx, organizationId, id)
+}
+
+// Returns PrepRecipeInUseError when a product or prep line using the recipe
+// is in a unit the new yield unit can not be converted to
+func (s *Service) UpdatePrepRecipe(ctx context.Context, organizationId int, id int, input PrepRecipeInput) (PrepRecipe, error) {
+	if err := s.validatePrepRecipe(ctx, organizationId, input); err != nil {
+		return PrepRecipe{}, err
+	}
+
+	if err := s.checkPrepRecipeUses(ctx, organizationId, id, strings.TrimSpace(input.YieldUnit)); err != nil {
+		return PrepRecipe{}, err
+	}
+
+	recipe := input.prepRecipe(organizationId)
+	recipe.Id = id
+	recipe.UpdatedAt = s.Now.Now()
+
+	return s.Repo.UpdatePrepRecipe(ctx, recipe)
+}
+
+// Checks every line using the prep recipe can still be converted to yieldUnit.
+// Lines without a unit are in the current yield unit
+func (s *Service) checkPrepRecipeUses(ctx context.Context, organizationId int, id int, yieldUnit string) error {
+	current, err := s.Repo.GetPrepRecipe(ctx, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	units, err := s.Repo.PrepRecipeUseUnits(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	for _, unit := range units {
+		if unit == "" {
+			unit = current.YieldUnit
+		}
+
+		if _, err := registry.Convert(1, unit, yieldUnit); err != nil {
+			return fmt.Errorf("%w: %w", PrepRecipeInUseError, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns PrepRecipeInUseError when a product or another prep recipe uses it
+func (s *Service) DeletePrepRecipe(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeletePrepRecipe(ctx, organizationId, id)
+}
+
+func (s *Service) ListPrepRecipes(ctx context.Context, organizationId int) ([]PrepRecipe, error) {
+	return s.Repo.ListPrepRecipes(ctx, organizationId)
+}
+
+// Only one of IngredientId and PrepRecipeId may be set
+type PrepIngredientInput struct {
+	IngredientId int
+	PrepRecipeId int
+	Amount       float64
+	Unit         string
+	WastePercent float64
+}
+
+func (p PrepIngredientInput) component() component {
+	return component{
+		ingredientId: p.IngredientId,
+		prepRecipeId: p.PrepRecipeId,
+		amount:       p.Amount,
+		unit:         strings.TrimSpace(p.Unit),
+		wastePercent: p.WastePercent,
+	}
+}
+
+func (p PrepIngredientInput) prepIngredient(recipeId int) PrepIngredient {
+	return PrepIngredient{
+		RecipeId:     recipeId,
+		IngredientId: p.IngredientId,
+		PrepRecipeId: p.PrepRecipeId,
+		Amount:       p.Amount,
+		Unit:         strings.TrimSpace(p.Unit),
+		WastePercent: p.WastePercent,
+	}
+}
+
+func (s *Service) validatePrepIngredient(ctx context.Context, organizationId int, recipeId int, input PrepIngredientInput) error {
+	if err := s.validateComponent(ctx, organizationId, input.component(), InvalidPrepIngredientError); err != nil {
+		return err
+	}
+
+	if input.PrepRecipeId == 0 {
+		return nil
+	}
+
+	return s.checkCycle(ctx, organizationId, recipeId, input.PrepRecipeId)
+}
+
+// Returns PrepRecipeCycleError when recipeId using prepRecipeId would make a
+// prep recipe include itself, directly or through other prep recipes
+func (s *Service) checkCycle(ctx context.Context, organizationId int, recipeId int, prepRecipeId int) error {
+	edges, err := s.Repo.PrepEdges(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	visited := map[int]bool{}
+	stack := []int{prepRecipeId}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == recipeId {
+			return PrepRecipeCycleError
+		}
+
+		if visited[id] {
+			continue
+		}
+
+		visited[id] = true
+		stack = append(stack, edges[id]...)
+	}
+
+	return nil
+}
+
+// Adds an ingredient or another prep recipe of the same organization to a
+// prep recipe
+func (s *Service) AddPrepIngredient(ctx context.Context, organizationId int, recipeId int, input PrepIngredientInput) (PrepIngredient, error) {
+	if err := s.validatePrepIngredient(ctx, organizationId, recipeId, input); err != nil {
+		return PrepIngredient{}, err
+	}
+
+	return s.Repo.AddPrepIngredient(ctx, organizationId, input.prepIngredient(recipeId))
+}
+
+func (s *Service) UpdatePrepIngredient(ctx context.Context, organizationId int, recipeId int, id int, input PrepIngredientInput) (PrepIngredient, error) {
+	if err := s.validatePrepIngredient(ctx, organizationId, recipeId, input); err != nil {
+		return PrepIngredient{}, err
+	}
+
+	prepIngredient := input.prepIngredient(recipeId)
+	prepIngredient.Id = id
+
+	return s.Repo.UpdatePrepIngredient(ctx, organizationId, prepIngredient)
+}
+
+func (s *Service) RemovePrepIngredient(ctx context.Context, organizationId int, recipeId int, id int) error {
+	return s.Repo.RemovePrepIngredient(ctx, organizationId, recipeId, id)
+}
+
+func (s *Service) ListPrepIngredients(ctx context.Context, organizationId int, recipeId int) ([]PrepIngredient, error) {
+	if _, err := s.Repo.GetPrepRecipe(ctx, organizationId, recipeId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListPrepIngredients(ctx, organizationId, recipeId)
+}
+
+// Computes the cost of a batch of a prep recipe and of each unit of its yield
+// from the prices in effect at asOf. A zero asOf uses the prices in effect now
+func (s *Service) PrepCost(ctx context.Context, organizationId int, id int, asOf time.Time) (PrepCost, error) {
+	recipes, err := s.recipeCoster(ctx, organizationId, s.pricesAt(asOf))
+
+	if err != nil {
+		return PrepCost{}, err
+	}
+
+	return recipes.prep(ctx, id)
+}
@@ -0,0 +1,423 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"time"
+)
+
+const prepRecipeColumns = `id, organizationId, name, yieldAmount, yieldUnit, createdAt, updatedAt`
+
+func scanPrepRecipe(row common.Scanner) (PrepRecipe, error) {
+	var recipe PrepRecipe
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&recipe.Id,
+		&recipe.OrganizationId,
+		&recipe.Name,
+		&recipe.YieldAmount,
+		&recipe.YieldUnit,
+		&createdAt,
+		&updatedAt,
+	)
+
+	recipe.CreatedAt = time.UnixMilli(createdAt)
+	recipe.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return recipe, err
+}
+
+const createPrepRecipeQuery = `
+INSERT INTO prep_recipes (organizationId, name, yieldAmount, yieldUnit, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreatePrepRecipe(ctx context.Context, recipe PrepRecipe) (PrepRecipe, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		createPrepRecipeQuery,
+		recipe.OrganizationId,
+		recipe.Name,
+		recipe.YieldAmount,
+		recipe.YieldUnit,
+		recipe.CreatedAt.UnixMilli(),
+		recipe.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return recipe, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return recipe, err
+	}
+
+	recipe.Id = int(id)
+
+	return recipe, nil
+}
+
+const getPrepRecipeQuery = `SELECT ` + prepRecipeColumns + ` FROM prep_recipes WHERE organizationId = ? AND id = ?`
+
+func (s *SQLiteRepo) GetPrepRecipe(ctx context.Context, organizationId int, id int) (PrepRecipe, error) {
+	recipe, err := scanPrepRecipe(s.db.QueryRowContext(ctx, getPrepRecipeQuery, organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return recipe, PrepRecipeNotFoundError
+	}
+
+	return recipe, err
+}
+
+const updatePrepRecipeQuery = `
+UPDATE prep_recipes
+SET name = ?, yieldAmount = ?, yieldUnit = ?, updatedAt = ?
+WHERE organizationId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdatePrepRecipe(ctx context.Context, recipe PrepRecipe) (PrepRecipe, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		updatePrepRecipeQuery,
+		recipe.Name,
+		recipe.YieldAmount,
+		recipe.YieldUnit,
+		recipe.UpdatedAt.UnixMilli(),
+		recipe.OrganizationId,
+		recipe.Id,
+	)
+
+	if err != nil {
+		return recipe, err
+	}
+
+	if err := common.ExpectAffected(res, PrepRecipeNotFoundError); err != nil {
+		return recipe, err
+	}
+
+	return s.GetPrepRecipe(ctx, recipe.OrganizationId, recipe.Id)
+}
+
+const prepRecipeUseUnitsQuery = `
+SELECT DISTINCT TRIM(COALESCE(unit, '')) FROM (
+  SELECT unit FROM product_ingredients WHERE prepRecipeId = ?
+  UNION ALL
+  SELECT unit FROM prep_recipe_ingredients WHERE prepRecipeId = ?
+)
+`
+
+func (s *SQLiteRepo) PrepRecipeUseUnits(ctx context.Context, id int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, prepRecipeUseUnitsQuery, id, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var units []string
+
+	for rows.Next() {
+		var unit string
+
+		if err := rows.Scan(&unit); err != nil {
+			return nil, err
+		}
+
+		units = append(units, unit)
+	}
+
+	return units, rows.Err()
+}
+
+const prepRecipeInUseQuery = `
+SELECT
+  EXISTS (SELECT 1 FROM product_ingredients WHERE prepRecipeId = ?)
+  OR EXISTS (SELECT 1 FROM prep_recipe_ingredients WHERE prepRecipeId = ?)
+`
+
+// Prep recipes used by a product or another prep recipe can not be deleted
+func (s *SQLiteRepo) DeletePrepRecipe(ctx context.Context, organizationId int, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM prep_recipes WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return PrepRecipeNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var inUse bool
+
+	if err := tx.QueryRowContext(ctx, prepRecipeInUseQuery, id, id).Scan(&inUse); err != nil {
+		return err
+	}
+
+	if inUse {
+		return PrepRecipeInUseError
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM prep_recipes WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, PrepRecipeNotFoundError); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const listPrepRecipesQuery = `SELECT ` + prepRecipeColumns + ` FROM prep_recipes WHERE organizationId = ? ORDER BY name ASC`
+
+func (s *SQLiteRepo) ListPrepRecipes(ctx context.Context, organizationId int) ([]PrepRecipe, error) {
+	rows, err := s.db.QueryContext(ctx, listPrepRecipesQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	recipes := []PrepRecipe{}
+
+	for rows.Next() {
+		recipe, err := scanPrepRecipe(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		recipes = append(recipes, recipe)
+	}
+
+	return recipes, rows.Err()
+}
+
+// Ensures both the prep recipe and the ingredient or prep recipe it uses
+// belong to the organization
+func (s *SQLiteRepo) checkPrepIngredientTx(ctx context.Context, tx *sql.Tx, organizationId int, prepIngredient PrepIngredient) error {
+	var exists int
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM prep_recipes WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		prepIngredient.RecipeId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return PrepRecipeNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkComponentTx(ctx, tx, organizationId, prepIngredient.IngredientId, prepIngredient.PrepRecipeId)
+}
+
+const addPrepIngredientQuery = `
+INSERT INTO prep_recipe_ingredients (recipeId, ingredientId, prepRecipeId, amount, unit, wastePercent)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) AddPrepIngredient(ctx context.Context, organizationId int, prepIngredient PrepIngredient) (PrepIngredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return prepIngredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.checkPrepIngredientTx(ctx, tx, organizationId, prepIngredient); err != nil {
+		return prepIngredient, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		addPrepIngredientQuery,
+		prepIngredient.RecipeId,
+		nullId(prepIngredient.IngredientId),
+		nullId(prepIngredient.PrepRecipeId),
+		prepIngredient.Amount,
+		prepIngredient.Unit,
+		prepIngredient.WastePercent,
+	)
+
+	if err != nil {
+		return prepIngredient, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return prepIngredient, err
+	}
+
+	prepIngredient.Id = int(id)
+
+	return prepIngredient, tx.Commit()
+}
+
+const updatePrepIngredientQuery = `
+UPDATE prep_recipe_ingredients
+SET ingredientId = ?, prepRecipeId = ?, amount = ?, unit = ?, wastePercent = ?
+WHERE recipeId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdatePrepIngredient(ctx context.Context, organizationId int, prepIngredient PrepIngredient) (PrepIngredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return prepIngredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.checkPrepIngredientTx(ctx, tx, organizationId, prepIngredient); err != nil {
+		return prepIngredient, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		updatePrepIngredientQuery,
+		nullId(prepIngredient.IngredientId),
+		nullId(prepIngredient.PrepRecipeId),
+		prepIngredient.Amount,
+		prepIngredient.Unit,
+		prepIngredient.WastePercent,
+		prepIngredient.RecipeId,
+		prepIngredient.Id,
+	)
+
+	if err != nil {
+		return prepIngredient, err
+	}
+
+	if err := common.ExpectAffected(res, PrepIngredientNotFoundError); err != nil {
+		return prepIngredient, err
+	}
+
+	return prepIngredient, tx.Commit()
+}
+
+const removePrepIngredientQuery = `
+DELETE FROM prep_recipe_ingredients
+WHERE id = ?
+AND recipeId = (SELECT id FROM prep_recipes WHERE organizationId = ? AND id = ?)
+`
+
+func (s *SQLiteRepo) RemovePrepIngredient(ctx context.Context, organizationId int, recipeId int, id int) error {
+	res, err := s.db.ExecContext(ctx, removePrepIngredientQuery, id, organizationId, recipeId)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, PrepIngredientNotFoundError)
+}
+
+const listPrepIngredientsQuery = `
+SELECT
+  prep_recipe_ingredients.id,
+  prep_recipe_ingredients.recipeId,
+  COALESCE(prep_recipe_ingredients.ingredientId, 0),
+  COALESCE(prep_recipe_ingredients.prepRecipeId, 0),
+  prep_recipe_ingredients.amount,
+  COALESCE(prep_recipe_ingredients.unit, ''),
+  prep_recipe_ingredients.wastePercent
+FROM prep_recipe_ingredients
+JOIN prep_recipes ON prep_recipes.id = prep_recipe_ingredients.recipeId
+WHERE prep_recipes.organizationId = ? AND prep_recipes.id = ?
+ORDER BY prep_recipe_ingredients.id ASC
+`
+
+func (s *SQLiteRepo) ListPrepIngredients(ctx context.Context, organizationId int, recipeId int) ([]PrepIngredient, error) {
+	rows, err := s.db.QueryContext(ctx, listPrepIngredientsQuery, organizationId, recipeId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	prepIngredients := []PrepIngredient{}
+
+	for rows.Next() {
+		var prepIngredient PrepIngredient
+
+		err := rows.Scan(
+			&prepIngredient.Id,
+			&prepIngredient.RecipeId,
+			&prepIngredient.IngredientId,
+			&prepIngredient.PrepRecipeId,
+			&prepIngredient.Amount,
+			&prepIngredient.Unit,
+			&prepIngredient.WastePercent,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prepIngredients = append(prepIngredients, prepIngredient)
+	}
+
+	return prepIngredients, rows.Err()
+}
+
+const prepEdgesQuery = `
+SELECT prep_recipe_ingredients.recipeId, prep_recipe_ingredients.prepRecipeId
+FROM prep_recipe_ingredients
+JOIN prep_recipes ON prep_recipes.id = prep_recipe_ingredients.recipeId
+WHERE prep_recipes.organizationId = ? AND prep_recipe_ingredients.prepRecipeId IS NOT NULL
+`
+
+func (s *SQLiteRepo) PrepEdges(ctx context.Context, organizationId int) (map[int][]int, error) {
+	rows, err := s.db.QueryContext(ctx, prepEdgesQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	edges := map[int][]int{}
+
+	for rows.Next() {
+		var recipeId, prepRecipeId int
+
+		if err := rows.Scan(&recipeId, &prepRecipeId); err != nil {
+			return nil, err
+		}
+
+		edges[recipeId] = append(edges[recipeId], prepRecipeId)
+	}
+
+	return edges, rows.Err()
+}
@@ -0,0 +1,385 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"math"
+	"moon-cost/services/ingredient"
+	"testing"
+	"time"
+)
+
+func (f testFixture) prep(t *testing.T, orgId int, input PrepRecipeInput) PrepRecipe {
+	t.Helper()
+
+	created, err := f.products.CreatePrepRecipe(context.Background(), orgId, input)
+
+	if err != nil {
+		t.Fatalf("CreatePrepRecipe() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func (f testFixture) addPrepIngredient(t *testing.T, recipeId int, input PrepIngredientInput) PrepIngredient {
+	t.Helper()
+
+	created, err := f.products.AddPrepIngredient(context.Background(), f.OrgId, recipeId, input)
+
+	if err != nil {
+		t.Fatalf("AddPrepIngredient() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func TestPrepCost(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	// 1000g for $2.00 = 0.2 cents per gram
+	tomato := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Tomato", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	// 1l for $10.00 = 1 cent per ml
+	oil := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Olive Oil", Unit: "l", UnitCount: 1, PurchasePrice: 1000})
+
+	// 2000g of tomato and 100ml of oil make 2l of sauce for $5.00
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Tomato Sauce", YieldAmount: 2, YieldUnit: "l"})
+	f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: tomato.Id, Amount: 2, Unit: "kg"})
+	f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: oil.Id, Amount: 100, Unit: "ml"})
+
+	cost, err := f.products.PrepCost(ctx, f.OrgId, sauce.Id, time.Time{})
+
+	if err != nil {
+		t.Fatalf("PrepCost() = _, %s. want nil", err)
+	}
+
+	if math.Abs(cost.Cost-500) > 1e-9 {
+		t.Errorf("PrepCost().Cost = %f. want %f", cost.Cost, 500.0)
+	}
+
+	if math.Abs(cost.CostPerUnit-250) > 1e-9 {
+		t.Errorf("PrepCost().CostPerUnit = %f. want %f", cost.CostPerUnit, 250.0)
+	}
+
+	// 500ml of sauce costs $1.25 and 20g of tomato 4 cents
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{PrepRecipeId: sauce.Id, Amount: 500, Unit: "ml"}); err != nil {
+		t.Fatalf("AddProductIngredient(sauce) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: tomato.Id, Amount: 20}); err != nil {
+		t.Fatalf("AddProductIngredient(tomato) = _, %s. want nil", err)
+	}
+
+	breakdown, err := f.products.Cost(ctx, f.OrgId, pizza.Id)
+
+	if err != nil {
+		t.Fatalf("Cost() = _, %s. want nil", err)
+	}
+
+	if math.Abs(breakdown.Cost-129) > 1e-9 {
+		t.Errorf("Cost().Cost = %f. want %f", breakdown.Cost, 129.0)
+	}
+
+	if breakdown.Lines[0].PrepRecipeId != sauce.Id || breakdown.Lines[0].Name != "Tomato Sauce" {
+		t.Errorf("Cost().Lines[0] = %+v. want prep recipe %d", breakdown.Lines[0], sauce.Id)
+	}
+
+	// 1l of sauce makes 4 portions of pasta sauce, costing $2.50 for 4
+	pastaSauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Pasta Sauce", YieldAmount: 4, YieldUnit: "each"})
+	f.addPrepIngredient(t, pastaSauce.Id, PrepIngredientInput{PrepRecipeId: sauce.Id, Amount: 1})
+
+	nested, err := f.products.PrepCost(ctx, f.OrgId, pastaSauce.Id, time.Time{})
+
+	if err != nil {
+		t.Fatalf("PrepCost(nested) = _, %s. want nil", err)
+	}
+
+	if math.Abs(nested.CostPerUnit-62.5) > 1e-9 {
+		t.Errorf("PrepCost(nested).CostPerUnit = %f. want %f", nested.CostPerUnit, 62.5)
+	}
+}
+
+func TestPrepCostWithTrim(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	// 1000g for $2.00 = 0.2 cents per gram, with a fifth trimmed away
+	onion := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Onion", Unit: "g", UnitCount: 1000, PurchasePrice: 200, YieldPercent: 80})
+
+	// 800g of diced onion is $1.60 as purchased and $2.00 after trim
+	diced := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Diced Onion", YieldAmount: 800, YieldUnit: "g"})
+	f.addPrepIngredient(t, diced.Id, PrepIngredientInput{IngredientId: onion.Id, Amount: 800})
+
+	salsa, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Salsa", MenuPrice: 500, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, salsa.Id, ProductIngredientInput{PrepRecipeId: diced.Id, Amount: 100}); err != nil {
+		t.Fatalf("AddProductIngredient(diced onion) = _, %s. want nil", err)
+	}
+
+	breakdown, err := f.products.Cost(ctx, f.OrgId, salsa.Id)
+
+	if err != nil {
+		t.Fatalf("Cost() = _, %s. want nil", err)
+	}
+
+	// 100g of diced onion is 20 cents as purchased and 25 cents after trim
+	if math.Abs(breakdown.AsPurchasedCost-20) > 1e-9 {
+		t.Errorf("Cost().AsPurchasedCost = %f. want %f", breakdown.AsPurchasedCost, 20.0)
+	}
+
+	if math.Abs(breakdown.Cost-25) > 1e-9 {
+		t.Errorf("Cost().Cost = %f. want %f", breakdown.Cost, 25.0)
+	}
+
+	if line := breakdown.Lines[0]; math.Abs(line.CostPerUnit-0.2) > 1e-9 || math.Abs(line.EdibleCostPerUnit-0.25) > 1e-9 {
+		t.Errorf("Cost().Lines[0] = %+v. want 0.2 cents per gram as purchased and 0.25 edible", line)
+	}
+}
+
+func TestPrepIngredientValidation(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	otherSugar := f.ingredient(t, f.OtherOrgId, ingredient.IngredientInput{Name: "Sugar", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	dough := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Dough", YieldAmount: 1, YieldUnit: "kg"})
+	otherDough := f.prep(t, f.OtherOrgId, PrepRecipeInput{Name: "Dough", YieldAmount: 1, YieldUnit: "kg"})
+
+	tests := []struct {
+		name  string
+		input PrepIngredientInput
+		err   error
+	}{
+		{"missing component", PrepIngredientInput{Amount: 1}, InvalidPrepIngredientError},
+		{"both components", PrepIngredientInput{IngredientId: flour.Id, PrepRecipeId: dough.Id, Amount: 1}, InvalidPrepIngredientError},
+		{"zero amount", PrepIngredientInput{IngredientId: flour.Id}, InvalidPrepIngredientError},
+		{"incompatible unit", PrepIngredientInput{IngredientId: flour.Id, Amount: 1, Unit: "ml"}, InvalidPrepIngredientError},
+		{"other org ingredient", PrepIngredientInput{IngredientId: otherSugar.Id, Amount: 1}, ingredient.IngredientNotFoundError},
+		{"other org prep", PrepIngredientInput{PrepRecipeId: otherDough.Id, Amount: 1}, PrepRecipeNotFoundError},
+		{"itself", PrepIngredientInput{PrepRecipeId: dough.Id, Amount: 1}, PrepRecipeCycleError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := f.products.AddPrepIngredient(ctx, f.OrgId, dough.Id, test.input)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("AddPrepIngredient() = _, %v. want %v", err, test.err)
+			}
+		})
+	}
+
+	if _, err := f.products.CreatePrepRecipe(ctx, f.OrgId, PrepRecipeInput{Name: "Bad", YieldAmount: 1, YieldUnit: "smidge"}); !errors.Is(err, InvalidPrepRecipeError) {
+		t.Errorf("CreatePrepRecipe(unknown unit) = _, %v. want %v", err, InvalidPrepRecipeError)
+	}
+}
+
+func TestPrepRecipeCycle(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	a := f.prep(t, f.OrgId, PrepRecipeInput{Name: "A", YieldAmount: 1, YieldUnit: "each"})
+	b := f.prep(t, f.OrgId, PrepRecipeInput{Name: "B", YieldAmount: 1, YieldUnit: "each"})
+	c := f.prep(t, f.OrgId, PrepRecipeInput{Name: "C", YieldAmount: 1, YieldUnit: "each"})
+
+	// A uses B and B uses C
+	f.addPrepIngredient(t, a.Id, PrepIngredientInput{PrepRecipeId: b.Id, Amount: 1})
+	line := f.addPrepIngredient(t, b.Id, PrepIngredientInput{PrepRecipeId: c.Id, Amount: 1})
+
+	if _, err := f.products.AddPrepIngredient(ctx, f.OrgId, c.Id, PrepIngredientInput{PrepRecipeId: a.Id, Amount: 1}); !errors.Is(err, PrepRecipeCycleError) {
+		t.Errorf("AddPrepIngredient(C uses A) = _, %v. want %v", err, PrepRecipeCycleError)
+	}
+
+	if _, err := f.products.UpdatePrepIngredient(ctx, f.OrgId, b.Id, line.Id, PrepIngredientInput{PrepRecipeId: a.Id, Amount: 1}); !errors.Is(err, PrepRecipeCycleError) {
+		t.Errorf("UpdatePrepIngredient(B uses A) = _, %v. want %v", err, PrepRecipeCycleError)
+	}
+
+	if _, err := f.products.AddPrepIngredient(ctx, f.OrgId, c.Id, PrepIngredientInput{PrepRecipeId: b.Id, Amount: 1}); !errors.Is(err, PrepRecipeCycleError) {
+		t.Errorf("AddPrepIngredient(C uses B) = _, %v. want %v", err, PrepRecipeCycleError)
+	}
+
+	// A using C directly as well as through B is not a cycle
+	f.addPrepIngredient(t, a.Id, PrepIngredientInput{PrepRecipeId: c.Id, Amount: 1})
+}
+
+func TestDeletePrepRecipe(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Sauce", YieldAmount: 1, YieldUnit: "l"})
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	line, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{PrepRecipeId: sauce.Id, Amount: 100, Unit: "ml"})
+
+	if err != nil {
+		t.Fatalf("AddProductIngredient() = _, %s. want nil", err)
+	}
+
+	if err := f.products.DeletePrepRecipe(ctx, f.OtherOrgId, sauce.Id); !errors.Is(err, PrepRecipeNotFoundError) {
+		t.Errorf("DeletePrepRecipe(other org) = %v. want %v", err, PrepRecipeNotFoundError)
+	}
+
+	if err := f.products.DeletePrepRecipe(ctx, f.OrgId, sauce.Id); !errors.Is(err, PrepRecipeInUseError) {
+		t.Errorf("DeletePrepRecipe(in use) = %v. want %v", err, PrepRecipeInUseError)
+	}
+
+	if err := f.products.RemoveProductIngredient(ctx, f.OrgId, pizza.Id, line.Id); err != nil {
+		t.Fatalf("RemoveProductIngredient() = %s. want nil", err)
+	}
+
+	if err := f.products.DeletePrepRecipe(ctx, f.OrgId, sauce.Id); err != nil {
+		t.Errorf("DeletePrepRecipe() = %v. want nil", err)
+	}
+}
+
+func TestUpdatePrepRecipeYieldUnit(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Sauce", YieldAmount: 1, YieldUnit: "l"})
+	dough := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Dough", YieldAmount: 1, YieldUnit: "kg"})
+	// no unit so the amount is in the yield unit
+	f.addPrepIngredient(t, dough.Id, PrepIngredientInput{PrepRecipeId: sauce.Id, Amount: 1})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{PrepRecipeId: sauce.Id, Amount: 100, Unit: "ml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.UpdatePrepRecipe(ctx, f.OrgId, sauce.Id, PrepRecipeInput{Name: "Sauce", YieldAmount: 1, YieldUnit: "kg"}); !errors.Is(err, PrepRecipeInUseError) {
+		t.Errorf("UpdatePrepRecipe(l to kg) = _, %v. want %s", err, PrepRecipeInUseError)
+	}
+
+	updated, err := f.products.UpdatePrepRecipe(ctx, f.OrgId, sauce.Id, PrepRecipeInput{Name: "Sauce", YieldAmount: 2000, YieldUnit: "ml"})
+
+	if err != nil {
+		t.Fatalf("UpdatePrepRecipe(l to ml) = _, %s. want nil", err)
+	}
+
+	if updated.YieldUnit != "ml" {
+		t.Errorf("UpdatePrepRecipe().YieldUnit = %s. want ml", updated.YieldUnit)
+	}
+}
+
+func TestDeleteIngredientUsedByPrep(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	tomato := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Tomato", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Sauce", YieldAmount: 1, YieldUnit: "l"})
+	line := f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: tomato.Id, Amount: 2, Unit: "kg"})
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, tomato.Id); !errors.Is(err, ingredient.IngredientInUseError) {
+		t.Fatalf("DeleteIngredient(used) = %v. want %s", err, ingredient.IngredientInUseError)
+	}
+
+	if err := f.products.RemovePrepIngredient(ctx, f.OrgId, sauce.Id, line.Id); err != nil {
+		t.Fatalf("RemovePrepIngredient() = %s. want nil", err)
+	}
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, tomato.Id); err != nil {
+		t.Errorf("DeleteIngredient(unused) = %s. want nil", err)
+	}
+}
+
+func TestDeleteOrganizationWithRecipes(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	tomato := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Tomato", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Sauce", YieldAmount: 1, YieldUnit: "l"})
+	f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: tomato.Id, Amount: 2, Unit: "kg"})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: tomato.Id, Amount: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{PrepRecipeId: sauce.Id, Amount: 100, Unit: "ml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.locations.DeleteOrganization(ctx, f.OrgId); err != nil {
+		t.Errorf("DeleteOrganization() = %s. want nil", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	tomato := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Tomato", Unit: "kg", UnitCount: 1, PurchasePrice: 200})
+	oil := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Olive Oil", Unit: "l", UnitCount: 1, PurchasePrice: 1000})
+
+	// 2kg of tomato and 100ml of oil make 2l of sauce
+	sauce := f.prep(t, f.OrgId, PrepRecipeInput{Name: "Tomato Sauce", YieldAmount: 2, YieldUnit: "l"})
+	f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: tomato.Id, Amount: 2, Unit: "kg"})
+	f.addPrepIngredient(t, sauce.Id, PrepIngredientInput{IngredientId: oil.Id, Amount: 100, Unit: "ml"})
+
+	// 4 servings use 1l of sauce and 200g of tomato, half of it wasted
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{PrepRecipeId: sauce.Id, Amount: 1, Unit: "l"}); err != nil {
+		t.Fatalf("AddProductIngredient(sauce) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: tomato.Id, Amount: 200, Unit: "g", WastePercent: 50}); err != nil {
+		t.Fatalf("AddProductIngredient(tomato) = _, %s. want nil", err)
+	}
+
+	usage, err := f.products.Usage(ctx, f.OrgId, pizza.Id, time.Time{})
+
+	if err != nil {
+		t.Fatalf("Usage() = _, %s. want nil", err)
+	}
+
+	expected := map[int]float64{
+		// (1kg of sauce + 0.4kg) / 4
+		tomato.Id: 0.35,
+		// 0.05l / 4
+		oil.Id: 0.0125,
+	}
+
+	if len(usage) != len(expected) {
+		t.Fatalf("len(Usage()) = %d. want %d", len(usage), len(expected))
+	}
+
+	for _, line := range usage {
+		if math.Abs(line.Amount-expected[line.IngredientId]) > 1e-9 {
+			t.Errorf("Usage(%s).Amount = %f. want %f", line.Name, line.Amount, expected[line.IngredientId])
+		}
+	}
+
+	if _, err := f.products.Usage(ctx, f.OtherOrgId, pizza.Id, time.Time{}); !errors.Is(err, ProductNotFoundError) {
+		t.Errorf("Usage(other org) = _, %v. want %s", err, ProductNotFoundError)
+	}
+}
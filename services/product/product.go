@@ -0,0 +1,509 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/services/costing"
+	"moon-cost/services/ingredient"
+	"moon-cost/units"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ProductNotFoundError           = errors.New("Product not found")
+	ProductIngredientNotFoundError = errors.New("Product ingredient not found")
+	InvalidProductError            = errors.New("Invalid product")
+	InvalidProductIngredientError  = errors.New("Invalid product ingredient")
+	ProductInUseError              = errors.New("Product is in use")
+)
+
+// UnitRegistries provides the units available to an organization
+type UnitRegistries interface {
+	Registry(context.Context, int) (*units.Registry, error)
+}
+
+// Provides only the standard units to every organization
+type StandardUnits struct{}
+
+func (StandardUnits) Registry(context.Context, int) (*units.Registry, error) {
+	return units.Default(), nil
+}
+
+type Service struct {
+	Repo   Repo
+	Units  UnitRegistries
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Units:  StandardUnits{},
+		Logger: logging.Logger(logger, slog.String("service", "product")),
+		Now:    common.TimeNow{},
+	}
+}
+
+type ProductInput struct {
+	Name      string
+	Category  string
+	MenuPrice int64
+	Servings  int
+}
+
+func (p ProductInput) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", InvalidProductError)
+	}
+
+	if p.MenuPrice < 0 {
+		return fmt.Errorf("%w: menu price can not be negative", InvalidProductError)
+	}
+
+	if p.Servings < 1 {
+		return fmt.Errorf("%w: servings must be at least 1", InvalidProductError)
+	}
+
+	return nil
+}
+
+func (p ProductInput) product(organizationId int) Product {
+	return Product{
+		OrganizationId: organizationId,
+		Name:           strings.TrimSpace(p.Name),
+		Category:       strings.TrimSpace(p.Category),
+		MenuPrice:      p.MenuPrice,
+		Servings:       p.Servings,
+	}
+}
+
+func (s *Service) CreateProduct(ctx context.Context, organizationId int, input ProductInput) (Product, error) {
+	if err := input.validate(); err != nil {
+		return Product{}, err
+	}
+
+	product := input.product(organizationId)
+	product.CreatedAt = s.Now.Now()
+	product.UpdatedAt = product.CreatedAt
+
+	return s.Repo.CreateProduct(ctx, product)
+}
+
+func (s *Service) GetProduct(ctx context.Context, organizationId int, id int) (Product, error) {
+	return s.Repo.GetProduct(ctx, organizationId, id)
+}
+
+func (s *Service) UpdateProduct(ctx context.Context, organizationId int, id int, input ProductInput) (Product, error) {
+	if err := input.validate(); err != nil {
+		return Product{}, err
+	}
+
+	product := input.product(organizationId)
+	product.Id = id
+	product.UpdatedAt = s.Now.Now()
+
+	return s.Repo.UpdateProduct(ctx, product)
+}
+
+// Returns ProductInUseError when sales of it are recorded
+func (s *Service) DeleteProduct(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteProduct(ctx, organizationId, id)
+}
+
+func (s *Service) ListProducts(ctx context.Context, organizationId int, filter Filter) ([]Product, error) {
+	return s.Repo.ListProducts(ctx, organizationId, filter)
+}
+
+// Sets the locations of the organization that sell a product, replacing the
+// locations set before
+func (s *Service) SetProductLocations(ctx context.Context, organizationId int, productId int, locationIds []int) ([]int, error) {
+	locationIds = append([]int{}, locationIds...)
+	slices.Sort(locationIds)
+	locationIds = slices.Compact(locationIds)
+
+	if err := s.Repo.SetProductLocations(ctx, organizationId, productId, locationIds); err != nil {
+		return nil, err
+	}
+
+	return locationIds, nil
+}
+
+// Returns the ids of the locations that sell a product
+func (s *Service) ListProductLocations(ctx context.Context, organizationId int, productId int) ([]int, error) {
+	if _, err := s.Repo.GetProduct(ctx, organizationId, productId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListProductLocations(ctx, organizationId, productId)
+}
+
+// Sets the tags of a product, replacing the tags set before
+func (s *Service) SetProductTags(ctx context.Context, organizationId int, productId int, tagIds []int) ([]int, error) {
+	tagIds = append([]int{}, tagIds...)
+	slices.Sort(tagIds)
+	tagIds = slices.Compact(tagIds)
+
+	if err := s.Repo.SetProductTags(ctx, organizationId, productId, tagIds); err != nil {
+		return nil, err
+	}
+
+	return tagIds, nil
+}
+
+// Returns the ids of the tags of a product
+func (s *Service) ListProductTags(ctx context.Context, organizationId int, productId int) ([]int, error) {
+	if _, err := s.Repo.GetProduct(ctx, organizationId, productId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListProductTags(ctx, organizationId, productId)
+}
+
+// A line of a recipe. Exactly one of ingredientId and prepRecipeId is set
+type component struct {
+	ingredientId int
+	prepRecipeId int
+	amount       float64
+	unit         string
+	wastePercent float64
+}
+
+func (c component) validate(invalid error) error {
+	if (c.ingredientId == 0) == (c.prepRecipeId == 0) {
+		return fmt.Errorf("%w: one of ingredient or prep recipe is required", invalid)
+	}
+
+	if c.amount <= 0 {
+		return fmt.Errorf("%w: amount must be greater than 0", invalid)
+	}
+
+	if c.wastePercent < 0 || c.wastePercent >= 100 {
+		return fmt.Errorf("%w: waste percent must be at least 0 and less than 100", invalid)
+	}
+
+	return nil
+}
+
+// Ensures the unit of a recipe line can be converted to the unit the
+// ingredient is purchased in or the unit the prep recipe yields
+func (s *Service) validateComponent(ctx context.Context, organizationId int, c component, invalid error) error {
+	if err := c.validate(invalid); err != nil {
+		return err
+	}
+
+	var componentUnit string
+
+	if c.prepRecipeId != 0 {
+		prep, err := s.Repo.GetPrepRecipe(ctx, organizationId, c.prepRecipeId)
+
+		if err != nil {
+			return err
+		}
+
+		componentUnit = prep.YieldUnit
+	} else {
+		ingredientUnit, err := s.Repo.IngredientUnit(ctx, organizationId, c.ingredientId)
+
+		if err != nil {
+			return err
+		}
+
+		componentUnit = ingredientUnit
+	}
+
+	if strings.TrimSpace(c.unit) == "" {
+		return nil
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := registry.Convert(c.amount, c.unit, componentUnit); err != nil {
+		return fmt.Errorf("%w: %w", invalid, err)
+	}
+
+	return nil
+}
+
+// Only one of IngredientId and PrepRecipeId may be set
+type ProductIngredientInput struct {
+	IngredientId int
+	PrepRecipeId int
+	Amount       float64
+	Unit         string
+	WastePercent float64
+}
+
+func (p ProductIngredientInput) component() component {
+	return component{
+		ingredientId: p.IngredientId,
+		prepRecipeId: p.PrepRecipeId,
+		amount:       p.Amount,
+		unit:         strings.TrimSpace(p.Unit),
+		wastePercent: p.WastePercent,
+	}
+}
+
+func (p ProductIngredientInput) productIngredient(productId int) ProductIngredient {
+	return ProductIngredient{
+		ProductId:    productId,
+		IngredientId: p.IngredientId,
+		PrepRecipeId: p.PrepRecipeId,
+		Amount:       p.Amount,
+		Unit:         strings.TrimSpace(p.Unit),
+		WastePercent: p.WastePercent,
+	}
+}
+
+// Adds an ingredient or prep recipe of the same organization to a product
+func (s *Service) AddProductIngredient(ctx context.Context, organizationId int, productId int, input ProductIngredientInput) (ProductIngredient, error) {
+	if err := s.validateComponent(ctx, organizationId, input.component(), InvalidProductIngredientError); err != nil {
+		return ProductIngredient{}, err
+	}
+
+	return s.Repo.AddProductIngredient(ctx, organizationId, input.productIngredient(productId))
+}
+
+func (s *Service) UpdateProductIngredient(ctx context.Context, organizationId int, productId int, id int, input ProductIngredientInput) (ProductIngredient, error) {
+	if err := s.validateComponent(ctx, organizationId, input.component(), InvalidProductIngredientError); err != nil {
+		return ProductIngredient{}, err
+	}
+
+	productIngredient := input.productIngredient(productId)
+	productIngredient.Id = id
+
+	return s.Repo.UpdateProductIngredient(ctx, organizationId, productIngredient)
+}
+
+func (s *Service) RemoveProductIngredient(ctx context.Context, organizationId int, productId int, id int) error {
+	return s.Repo.RemoveProductIngredient(ctx, organizationId, productId, id)
+}
+
+func (s *Service) ListProductIngredients(ctx context.Context, organizationId int, productId int) ([]ProductIngredient, error) {
+	if _, err := s.Repo.GetProduct(ctx, organizationId, productId); err != nil {
+		return nil, err
+	}
+
+	return s.Repo.ListProductIngredients(ctx, organizationId, productId)
+}
+
+// Computes the cost breakdown of a product from the current cost of its
+// ingredients. Amounts used in a different unit than the ingredient is
+// purchased in are priced by converting the ingredient's cost per unit
+func (s *Service) Cost(ctx context.Context, organizationId int, productId int) (costing.Breakdown, error) {
+	return s.CostAsOf(ctx, organizationId, productId, time.Time{})
+}
+
+// Computes the cost breakdown of a product from the ingredient prices that
+// were in effect at asOf. Ingredients without a price at asOf use their
+// earliest price. A zero asOf uses the prices in effect now
+func (s *Service) CostAsOf(ctx context.Context, organizationId int, productId int, asOf time.Time) (costing.Breakdown, error) {
+	asOf = s.pricesAt(asOf)
+
+	product, err := s.Repo.GetProduct(ctx, organizationId, productId)
+
+	if err != nil {
+		return costing.Breakdown{}, err
+	}
+
+	costLines, err := s.Repo.CostLines(ctx, organizationId, productId, asOf)
+
+	if err != nil {
+		return costing.Breakdown{}, err
+	}
+
+	recipes, err := s.recipeCoster(ctx, organizationId, asOf)
+
+	if err != nil {
+		return costing.Breakdown{}, err
+	}
+
+	lines, err := recipes.lines(ctx, costLines)
+
+	if err != nil {
+		return costing.Breakdown{}, err
+	}
+
+	return costing.Cost(product.MenuPrice, product.Servings, lines), nil
+}
+
+// Resolves a zero asOf to now so current costs use the prices in effect now,
+// including prices recorded earlier with a future effective date
+func (s *Service) pricesAt(asOf time.Time) time.Time {
+	if asOf.IsZero() {
+		return s.Now.Now()
+	}
+
+	return asOf
+}
+
+// recipeCoster costs the recipes of an organization with the prices in effect
+// at asOf. Prep recipes are costed once and reused by every recipe using them
+type recipeCoster struct {
+	repo           Repo
+	organizationId int
+	asOf           time.Time
+	registry       *units.Registry
+	preps          map[int]PrepCost
+	visiting       map[int]bool
+}
+
+func (s *Service) recipeCoster(ctx context.Context, organizationId int, asOf time.Time) (*recipeCoster, error) {
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &recipeCoster{
+		repo:           s.Repo,
+		organizationId: organizationId,
+		asOf:           asOf,
+		registry:       registry,
+		preps:          map[int]PrepCost{},
+		visiting:       map[int]bool{},
+	}, nil
+}
+
+func (r *recipeCoster) lines(ctx context.Context, costLines []costLine) ([]costing.Line, error) {
+	lines := make([]costing.Line, len(costLines))
+
+	for i, costLine := range costLines {
+		var line costing.Line
+		var err error
+
+		if costLine.prepRecipeId != 0 {
+			line, err = r.prepLine(ctx, costLine)
+		} else {
+			line, err = costLine.line(r.registry)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines[i] = line
+	}
+
+	return lines, nil
+}
+
+func (r *recipeCoster) prepLine(ctx context.Context, c costLine) (costing.Line, error) {
+	prep, err := r.prep(ctx, c.prepRecipeId)
+
+	if err != nil {
+		return costing.Line{}, err
+	}
+
+	unit := c.unit
+
+	if unit == "" {
+		unit = prep.YieldUnit
+	}
+
+	// how many of the prep recipe's yield unit are in one of the line's unit
+	perUnit, err := r.registry.Convert(1, unit, prep.YieldUnit)
+
+	if err != nil {
+		return costing.Line{}, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	// a prep recipe is costed like an ingredient purchased at its as-purchased
+	// cost that yields the part of it left after its own trim and waste
+	line := costing.Line{
+		PrepRecipeId: c.prepRecipeId,
+		Name:         c.name,
+		Unit:         unit,
+		Amount:       c.amount,
+		CostPerUnit:  prep.AsPurchasedCost / prep.YieldAmount * perUnit,
+		WastePercent: c.wastePercent,
+	}
+
+	if prep.Cost > 0 {
+		line.YieldPercent = prep.AsPurchasedCost / prep.Cost * 100
+	}
+
+	return line, nil
+}
+
+// Returns PrepRecipeCycleError when the prep recipe uses itself
+func (r *recipeCoster) prep(ctx context.Context, id int) (PrepCost, error) {
+	if cost, ok := r.preps[id]; ok {
+		return cost, nil
+	}
+
+	if r.visiting[id] {
+		return PrepCost{}, PrepRecipeCycleError
+	}
+
+	r.visiting[id] = true
+	defer delete(r.visiting, id)
+
+	recipe, err := r.repo.GetPrepRecipe(ctx, r.organizationId, id)
+
+	if err != nil {
+		return PrepCost{}, err
+	}
+
+	costLines, err := r.repo.PrepCostLines(ctx, r.organizationId, id, r.asOf)
+
+	if err != nil {
+		return PrepCost{}, err
+	}
+
+	lines, err := r.lines(ctx, costLines)
+
+	if err != nil {
+		return PrepCost{}, err
+	}
+
+	batch := costing.Cost(0, 1, lines)
+
+	cost := PrepCost{
+		Lines:           batch.Lines,
+		AsPurchasedCost: batch.AsPurchasedCost,
+		Cost:            batch.Cost,
+		YieldAmount:     recipe.YieldAmount,
+		YieldUnit:       recipe.YieldUnit,
+		CostPerUnit:     batch.Cost / recipe.YieldAmount,
+	}
+
+	r.preps[id] = cost
+
+	return cost, nil
+}
+
+func (c costLine) line(registry *units.Registry) (costing.Line, error) {
+	unit := c.unit
+
+	if unit == "" {
+		unit = c.ingredientUnit
+	}
+
+	// how many of the ingredient's purchase unit are in one of the line's unit
+	perUnit, err := registry.Convert(1, unit, c.ingredientUnit)
+
+	if err != nil {
+		return costing.Line{}, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	return costing.Line{
+		IngredientId: c.ingredientId,
+		Name:         c.name,
+		Unit:         unit,
+		Amount:       c.amount,
+		CostPerUnit:  ingredient.UnitCost(c.purchasePrice, c.unitCount) * perUnit,
+		YieldPercent: c.yieldPercent,
+		WastePercent: c.wastePercent,
+	}, nil
+}
@@ -0,0 +1,725 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/tag"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+const productColumns = `id, organizationId, name, COALESCE(category, ''), menuPrice, servings, createdAt, updatedAt`
+
+func scanProduct(row common.Scanner) (Product, error) {
+	var product Product
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&product.Id,
+		&product.OrganizationId,
+		&product.Name,
+		&product.Category,
+		&product.MenuPrice,
+		&product.Servings,
+		&createdAt,
+		&updatedAt,
+	)
+
+	product.CreatedAt = time.UnixMilli(createdAt)
+	product.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return product, err
+}
+
+const createProductQuery = `
+INSERT INTO products (organizationId, name, category, menuPrice, servings, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateProduct(ctx context.Context, product Product) (Product, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		createProductQuery,
+		product.OrganizationId,
+		product.Name,
+		product.Category,
+		product.MenuPrice,
+		product.Servings,
+		product.CreatedAt.UnixMilli(),
+		product.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return product, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return product, err
+	}
+
+	product.Id = int(id)
+
+	return product, nil
+}
+
+const getProductQuery = `SELECT ` + productColumns + ` FROM products WHERE organizationId = ? AND id = ?`
+
+func (s *SQLiteRepo) GetProduct(ctx context.Context, organizationId int, id int) (Product, error) {
+	product, err := scanProduct(s.db.QueryRowContext(ctx, getProductQuery, organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return product, ProductNotFoundError
+	}
+
+	return product, err
+}
+
+const updateProductQuery = `
+UPDATE products
+SET name = ?, category = ?, menuPrice = ?, servings = ?, updatedAt = ?
+WHERE organizationId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdateProduct(ctx context.Context, product Product) (Product, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		updateProductQuery,
+		product.Name,
+		product.Category,
+		product.MenuPrice,
+		product.Servings,
+		product.UpdatedAt.UnixMilli(),
+		product.OrganizationId,
+		product.Id,
+	)
+
+	if err != nil {
+		return product, err
+	}
+
+	if err := common.ExpectAffected(res, ProductNotFoundError); err != nil {
+		return product, err
+	}
+
+	return s.GetProduct(ctx, product.OrganizationId, product.Id)
+}
+
+const productInUseQuery = `
+SELECT
+  EXISTS (SELECT 1 FROM sales WHERE productId = ?)
+`
+
+// Products with recorded sales can not be deleted
+func (s *SQLiteRepo) DeleteProduct(ctx context.Context, organizationId int, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM products WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		id,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProductNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var inUse bool
+
+	if err := tx.QueryRowContext(ctx, productInUseQuery, id).Scan(&inUse); err != nil {
+		return err
+	}
+
+	if inUse {
+		return ProductInUseError
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM products WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := common.ExpectAffected(res, ProductNotFoundError); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const listProductsQuery = `SELECT ` + productColumns + ` FROM products WHERE organizationId = ?`
+
+// Appends a condition to query for each field set on filter
+func (f Filter) where(query string, args []any) (string, []any) {
+	if len(f.TagIds) > 0 {
+		tags, tagArgs := common.InList(f.TagIds)
+		query += ` AND id IN (SELECT productId FROM product_tags WHERE tagId IN (` + tags + `))`
+		args = append(args, tagArgs...)
+	}
+
+	return query, args
+}
+
+func (s *SQLiteRepo) ListProducts(ctx context.Context, organizationId int, filter Filter) ([]Product, error) {
+	query, args := filter.where(listProductsQuery, []any{organizationId})
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY name ASC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	products := []Product{}
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// Replaces the product's locations. Every location must belong to the
+// organization
+func (s *SQLiteRepo) SetProductLocations(ctx context.Context, organizationId int, productId int, locationIds []int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM products WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		productId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProductNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_locations WHERE productId = ?`, productId); err != nil {
+		return err
+	}
+
+	for _, locationId := range locationIds {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT 1 FROM locations WHERE organizationId = ? AND id = ?`,
+			organizationId,
+			locationId,
+		).Scan(&exists)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return organization.LocationNotFoundError
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO product_locations (productId, locationId) VALUES (?, ?)`,
+			productId,
+			locationId,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const listProductLocationsQuery = `
+SELECT product_locations.locationId
+FROM product_locations
+JOIN products ON products.id = product_locations.productId
+WHERE products.organizationId = ? AND products.id = ?
+ORDER BY product_locations.locationId ASC
+`
+
+func (s *SQLiteRepo) ListProductLocations(ctx context.Context, organizationId int, productId int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, listProductLocationsQuery, organizationId, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	locationIds := []int{}
+
+	for rows.Next() {
+		var locationId int
+
+		if err := rows.Scan(&locationId); err != nil {
+			return nil, err
+		}
+
+		locationIds = append(locationIds, locationId)
+	}
+
+	return locationIds, rows.Err()
+}
+
+// Replaces the product's tags. Every tag must be a product tag of the
+// organization
+func (s *SQLiteRepo) SetProductTags(ctx context.Context, organizationId int, productId int, tagIds []int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var exists int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM products WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		productId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProductNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_tags WHERE productId = ?`, productId); err != nil {
+		return err
+	}
+
+	for _, tagId := range tagIds {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT 1 FROM org_tags WHERE organizationId = ? AND id = ? AND type = ?`,
+			organizationId,
+			tagId,
+			tag.TypeProduct,
+		).Scan(&exists)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return tag.TagNotFoundError
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT INTO product_tags (productId, tagId) VALUES (?, ?)`, productId, tagId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const listProductTagsQuery = `
+SELECT product_tags.tagId
+FROM product_tags
+JOIN products ON products.id = product_tags.productId
+WHERE products.organizationId = ? AND products.id = ?
+ORDER BY product_tags.tagId ASC
+`
+
+func (s *SQLiteRepo) ListProductTags(ctx context.Context, organizationId int, productId int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, listProductTagsQuery, organizationId, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tagIds := []int{}
+
+	for rows.Next() {
+		var tagId int
+
+		if err := rows.Scan(&tagId); err != nil {
+			return nil, err
+		}
+
+		tagIds = append(tagIds, tagId)
+	}
+
+	return tagIds, rows.Err()
+}
+
+// Stores ids of optional references as NULL when they are not set
+func nullId(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// Ensures the ingredient or prep recipe used by a recipe line belongs to the
+// organization
+func checkComponentTx(ctx context.Context, tx *sql.Tx, organizationId int, ingredientId int, prepRecipeId int) error {
+	var exists int
+
+	if prepRecipeId != 0 {
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT 1 FROM prep_recipes WHERE organizationId = ? AND id = ?`,
+			organizationId,
+			prepRecipeId,
+		).Scan(&exists)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return PrepRecipeNotFoundError
+		}
+
+		return err
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		ingredientId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ingredient.IngredientNotFoundError
+	}
+
+	return err
+}
+
+// Ensures both the product and the ingredient or prep recipe belong to the
+// organization
+func (s *SQLiteRepo) checkProductIngredientTx(ctx context.Context, tx *sql.Tx, organizationId int, productIngredient ProductIngredient) error {
+	var exists int
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM products WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		productIngredient.ProductId,
+	).Scan(&exists)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProductNotFoundError
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkComponentTx(ctx, tx, organizationId, productIngredient.IngredientId, productIngredient.PrepRecipeId)
+}
+
+const addProductIngredientQuery = `
+INSERT INTO product_ingredients (productId, ingredientId, prepRecipeId, amount, unit, wastePercent)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) AddProductIngredient(ctx context.Context, organizationId int, productIngredient ProductIngredient) (ProductIngredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return productIngredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.checkProductIngredientTx(ctx, tx, organizationId, productIngredient); err != nil {
+		return productIngredient, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		addProductIngredientQuery,
+		productIngredient.ProductId,
+		nullId(productIngredient.IngredientId),
+		nullId(productIngredient.PrepRecipeId),
+		productIngredient.Amount,
+		productIngredient.Unit,
+		productIngredient.WastePercent,
+	)
+
+	if err != nil {
+		return productIngredient, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return productIngredient, err
+	}
+
+	productIngredient.Id = int(id)
+
+	return productIngredient, tx.Commit()
+}
+
+const updateProductIngredientQuery = `
+UPDATE product_ingredients
+SET ingredientId = ?, prepRecipeId = ?, amount = ?, unit = ?, wastePercent = ?
+WHERE productId = ? AND id = ?
+`
+
+func (s *SQLiteRepo) UpdateProductIngredient(ctx context.Context, organizationId int, productIngredient ProductIngredient) (ProductIngredient, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return productIngredient, err
+	}
+
+	defer tx.Rollback()
+
+	if err := s.checkProductIngredientTx(ctx, tx, organizationId, productIngredient); err != nil {
+		return productIngredient, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		updateProductIngredientQuery,
+		nullId(productIngredient.IngredientId),
+		nullId(productIngredient.PrepRecipeId),
+		productIngredient.Amount,
+		productIngredient.Unit,
+		productIngredient.WastePercent,
+		productIngredient.ProductId,
+		productIngredient.Id,
+	)
+
+	if err != nil {
+		return productIngredient, err
+	}
+
+	if err := common.ExpectAffected(res, ProductIngredientNotFoundError); err != nil {
+		return productIngredient, err
+	}
+
+	return productIngredient, tx.Commit()
+}
+
+const removeProductIngredientQuery = `
+DELETE FROM product_ingredients
+WHERE id = ?
+AND productId = (SELECT id FROM products WHERE organizationId = ? AND id = ?)
+`
+
+func (s *SQLiteRepo) RemoveProductIngredient(ctx context.Context, organizationId int, productId int, id int) error {
+	res, err := s.db.ExecContext(ctx, removeProductIngredientQuery, id, organizationId, productId)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, ProductIngredientNotFoundError)
+}
+
+const listProductIngredientsQuery = `
+SELECT
+  product_ingredients.id,
+  product_ingredients.productId,
+  COALESCE(product_ingredients.ingredientId, 0),
+  COALESCE(product_ingredients.prepRecipeId, 0),
+  product_ingredients.amount,
+  COALESCE(product_ingredients.unit, ''),
+  product_ingredients.wastePercent
+FROM product_ingredients
+JOIN products ON products.id = product_ingredients.productId
+WHERE products.organizationId = ? AND products.id = ?
+ORDER BY product_ingredients.id ASC
+`
+
+func (s *SQLiteRepo) ListProductIngredients(ctx context.Context, organizationId int, productId int) ([]ProductIngredient, error) {
+	rows, err := s.db.QueryContext(ctx, listProductIngredientsQuery, organizationId, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	productIngredients := []ProductIngredient{}
+
+	for rows.Next() {
+		var productIngredient ProductIngredient
+
+		err := rows.Scan(
+			&productIngredient.Id,
+			&productIngredient.ProductId,
+			&productIngredient.IngredientId,
+			&productIngredient.PrepRecipeId,
+			&productIngredient.Amount,
+			&productIngredient.Unit,
+			&productIngredient.WastePercent,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		productIngredients = append(productIngredients, productIngredient)
+	}
+
+	return productIngredients, rows.Err()
+}
+
+func (s *SQLiteRepo) IngredientUnit(ctx context.Context, organizationId int, ingredientId int) (string, error) {
+	var unit string
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT unit FROM ingredients WHERE organizationId = ? AND id = ?`,
+		organizationId,
+		ingredientId,
+	).Scan(&unit)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return unit, ingredient.IngredientNotFoundError
+	}
+
+	return unit, err
+}
+
+// Prices each ingredient with the latest price in effect at asOf, falling back
+// to its earliest price when it had none yet
+const asOfPricesJoin = `
+LEFT JOIN ingredient_prices AS prices ON prices.id = COALESCE(
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id AND effectiveAt <= ?
+    ORDER BY effectiveAt DESC, id DESC
+    LIMIT 1
+  ),
+  (
+    SELECT id FROM ingredient_prices
+    WHERE ingredientId = ingredients.id
+    ORDER BY effectiveAt ASC, id ASC
+    LIMIT 1
+  )
+)`
+
+// Selects the lines of a recipe stored in table, owned by the row of owners
+// referenced by ownerColumn. Prep recipe lines have no purchase fields
+func costLinesQuery(table string, owners string, ownerColumn string, pricesJoin string) string {
+	return `
+SELECT
+  COALESCE(lines.ingredientId, 0),
+  COALESCE(lines.prepRecipeId, 0),
+  COALESCE(ingredients.name, preps.name),
+  COALESCE(prices.unit, ''),
+  COALESCE(prices.unitCount, 0),
+  COALESCE(prices.purchasePrice, 0),
+  COALESCE(ingredients.yieldPercent, 100),
+  lines.amount,
+  COALESCE(lines.unit, ''),
+  lines.wastePercent
+FROM ` + table + ` AS lines
+JOIN ` + owners + ` AS owners ON owners.id = lines.` + ownerColumn + `
+LEFT JOIN ingredients ON ingredients.id = lines.ingredientId
+LEFT JOIN prep_recipes AS preps ON preps.id = lines.prepRecipeId
+` + pricesJoin + `
+WHERE owners.organizationId = ? AND owners.id = ?
+ORDER BY lines.id ASC
+`
+}
+
+var (
+	productCostLinesQuery = costLinesQuery("product_ingredients", "products", "productId", asOfPricesJoin)
+	prepCostLinesQuery    = costLinesQuery("prep_recipe_ingredients", "prep_recipes", "recipeId", asOfPricesJoin)
+)
+
+func (s *SQLiteRepo) CostLines(ctx context.Context, organizationId int, productId int, asOf time.Time) ([]costLine, error) {
+	return s.costLines(ctx, productCostLinesQuery, asOf.UnixMilli(), organizationId, productId)
+}
+
+func (s *SQLiteRepo) PrepCostLines(ctx context.Context, organizationId int, recipeId int, asOf time.Time) ([]costLine, error) {
+	return s.costLines(ctx, prepCostLinesQuery, asOf.UnixMilli(), organizationId, recipeId)
+}
+
+func (s *SQLiteRepo) costLines(ctx context.Context, query string, args ...any) ([]costLine, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var lines []costLine
+
+	for rows.Next() {
+		var line costLine
+
+		err := rows.Scan(
+			&line.ingredientId,
+			&line.prepRecipeId,
+			&line.name,
+			&line.ingredientUnit,
+			&line.unitCount,
+			&line.purchasePrice,
+			&line.yieldPercent,
+			&line.amount,
+			&line.unit,
+			&line.wastePercent,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
@@ -0,0 +1,509 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"math"
+	"moon-cost/common"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/tag"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testFixture struct {
+	moontest.TestEnv
+	products    *Service
+	ingredients *ingredient.Service
+	locations   *organization.Service
+	tags        *tag.Service
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	env := moontest.NewTestEnv(t)
+
+	return testFixture{
+		TestEnv:     env,
+		products:    NewService(NewSQLiteRepo(env.DB), env.Logger),
+		ingredients: ingredient.NewService(ingredient.NewSQLiteRepo(env.DB), env.Logger),
+		locations:   organization.NewService(organization.NewSQLiteRepo(env.DB), env.Logger),
+		tags:        tag.NewService(tag.NewSQLiteRepo(env.DB), env.Logger),
+	}
+}
+
+func (f testFixture) ingredient(t *testing.T, orgId int, input ingredient.IngredientInput) ingredient.Ingredient {
+	t.Helper()
+
+	created, err := f.ingredients.CreateIngredient(context.Background(), orgId, input)
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func TestProductCost(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	// 1000g for $2.00 = 0.2 cents per gram
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	// 10 for $5.00 = 50 cents each
+	tomato := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Tomato", Unit: "each", UnitCount: 10, PurchasePrice: 500})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 500}); err != nil {
+		t.Fatalf("AddProductIngredient(flour) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: tomato.Id, Amount: 4}); err != nil {
+		t.Fatalf("AddProductIngredient(tomato) = _, %s. want nil", err)
+	}
+
+	breakdown, err := f.products.Cost(ctx, f.OrgId, pizza.Id)
+
+	if err != nil {
+		t.Fatalf("Cost() = _, %s. want nil", err)
+	}
+
+	if len(breakdown.Lines) != 2 {
+		t.Fatalf("len(Cost().Lines) = %d. want 2", len(breakdown.Lines))
+	}
+
+	if math.Abs(breakdown.CostPerServing-75) > 1e-9 {
+		t.Errorf("Cost().CostPerServing = %f. want %f", breakdown.CostPerServing, 75.0)
+	}
+
+	if math.Abs(breakdown.FoodCostPercent-6.25) > 1e-9 {
+		t.Errorf("Cost().FoodCostPercent = %f. want %f", breakdown.FoodCostPercent, 6.25)
+	}
+
+	if _, err := f.products.Cost(ctx, f.OtherOrgId, pizza.Id); !errors.Is(err, ProductNotFoundError) {
+		t.Errorf("Cost(other org) = _, %v. want %s", err, ProductNotFoundError)
+	}
+}
+
+func TestProductIngredientMustBelongToOrganization(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	otherFlour := f.ingredient(t, f.OtherOrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: otherFlour.Id, Amount: 1})
+
+	if !errors.Is(err, ingredient.IngredientNotFoundError) {
+		t.Errorf("AddProductIngredient(other org ingredient) = _, %v. want %s", err, ingredient.IngredientNotFoundError)
+	}
+
+	_, err = f.products.AddProductIngredient(ctx, f.OtherOrgId, pizza.Id, ProductIngredientInput{IngredientId: otherFlour.Id, Amount: 1})
+
+	if !errors.Is(err, ProductNotFoundError) {
+		t.Errorf("AddProductIngredient(other org product) = _, %v. want %s", err, ProductNotFoundError)
+	}
+
+	added, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.products.RemoveProductIngredient(ctx, f.OtherOrgId, pizza.Id, added.Id); !errors.Is(err, ProductIngredientNotFoundError) {
+		t.Errorf("RemoveProductIngredient(other org) = %v. want %s", err, ProductIngredientNotFoundError)
+	}
+
+	if err := f.products.RemoveProductIngredient(ctx, f.OrgId, pizza.Id, added.Id); err != nil {
+		t.Errorf("RemoveProductIngredient() = %s. want nil", err)
+	}
+}
+
+func TestDeleteIngredientUsedByProduct(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := f.products.AddProductIngredient(ctx, f.OrgId, pizza.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 500})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, flour.Id); !errors.Is(err, ingredient.IngredientInUseError) {
+		t.Fatalf("DeleteIngredient(used) = %v. want %s", err, ingredient.IngredientInUseError)
+	}
+
+	if breakdown, err := f.products.Cost(ctx, f.OrgId, pizza.Id); err != nil || len(breakdown.Lines) != 1 {
+		t.Errorf("Cost() = %v, %v. want the flour line kept", breakdown.Lines, err)
+	}
+
+	if err := f.products.RemoveProductIngredient(ctx, f.OrgId, pizza.Id, added.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.ingredients.DeleteIngredient(ctx, f.OrgId, flour.Id); err != nil {
+		t.Errorf("DeleteIngredient(unused) = %s. want nil", err)
+	}
+}
+
+func TestProductCostConvertsUnits(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	// $4.5359237 per pound is exactly 1 cent per gram
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "lb", UnitCount: 100, PurchasePrice: 45359})
+	eggs := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Eggs", Unit: "dozen", UnitCount: 1, PurchasePrice: 360})
+
+	cake, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Cake", MenuPrice: 1000, Servings: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, cake.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 50, Unit: "g"}); err != nil {
+		t.Fatalf("AddProductIngredient(50 g flour) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, cake.Id, ProductIngredientInput{IngredientId: eggs.Id, Amount: 2}); err != nil {
+		t.Fatalf("AddProductIngredient(2 dozen eggs) = _, %s. want nil", err)
+	}
+
+	_, err = f.products.AddProductIngredient(ctx, f.OrgId, cake.Id, ProductIngredientInput{IngredientId: eggs.Id, Amount: 2, Unit: "ml"})
+
+	if !errors.Is(err, InvalidProductIngredientError) {
+		t.Errorf("AddProductIngredient(2 ml eggs) = _, %v. want %s", err, InvalidProductIngredientError)
+	}
+
+	breakdown, err := f.products.Cost(ctx, f.OrgId, cake.Id)
+
+	if err != nil {
+		t.Fatalf("Cost() = _, %s. want nil", err)
+	}
+
+	flourLine := breakdown.Lines[0]
+
+	if flourLine.Unit != "g" || math.Abs(flourLine.Cost-50) > 0.01 {
+		t.Errorf("flour line = %f %s costing %f. want 50 g costing 50", flourLine.Amount, flourLine.Unit, flourLine.Cost)
+	}
+
+	eggsLine := breakdown.Lines[1]
+
+	if eggsLine.Unit != "dozen" || math.Abs(eggsLine.Cost-720) > 1e-9 {
+		t.Errorf("eggs line = %f %s costing %f. want 2 dozen costing 720", eggsLine.Amount, eggsLine.Unit, eggsLine.Cost)
+	}
+}
+
+func TestProductCostAsOf(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	f.ingredients.Now = common.TestNow{Time: january}
+
+	// 1000g for $2.00 = 0.2 cents per gram
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	f.ingredients.Now = common.TestNow{Time: march.AddDate(0, 1, 0)}
+
+	// 1kg for $4.00 = 0.4 cents per gram from March
+	_, err := f.ingredients.RecordPrice(ctx, f.OrgId, flour.Id, ingredient.PriceInput{PurchasePrice: 400, UnitCount: 1, Unit: "kg", EffectiveAt: march})
+
+	if err != nil {
+		t.Fatalf("RecordPrice() = _, %s. want nil", err)
+	}
+
+	bread, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Bread", MenuPrice: 500, Servings: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, bread.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 500, Unit: "g"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		test     string
+		asOf     time.Time
+		expected float64
+	}{
+		{test: "current", expected: 200},
+		{test: "before first price", asOf: january.AddDate(-1, 0, 0), expected: 100},
+		{test: "february", asOf: march.AddDate(0, 0, -1), expected: 100},
+		{test: "march", asOf: march, expected: 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			breakdown, err := f.products.CostAsOf(ctx, f.OrgId, bread.Id, test.asOf)
+
+			if err != nil {
+				t.Fatalf("CostAsOf() = _, %s. want nil", err)
+			}
+
+			if math.Abs(breakdown.Cost-test.expected) > 1e-9 {
+				t.Errorf("CostAsOf().Cost = %f. want %f", breakdown.Cost, test.expected)
+			}
+		})
+	}
+}
+
+func TestProductCostFuturePrice(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	f.ingredients.Now = common.TestNow{Time: january}
+
+	// 1000g for $2.00 = 0.2 cents per gram
+	flour := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200})
+
+	// recorded in January to take effect in March
+	_, err := f.ingredients.RecordPrice(ctx, f.OrgId, flour.Id, ingredient.PriceInput{PurchasePrice: 400, UnitCount: 1000, Unit: "g", EffectiveAt: march})
+
+	if err != nil {
+		t.Fatalf("RecordPrice() = _, %s. want nil", err)
+	}
+
+	bread, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Bread", MenuPrice: 500, Servings: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, bread.Id, ProductIngredientInput{IngredientId: flour.Id, Amount: 500, Unit: "g"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		test     string
+		now      time.Time
+		expected float64
+	}{
+		{test: "before it takes effect", now: march.AddDate(0, 0, -1), expected: 100},
+		{test: "after it takes effect", now: march.AddDate(0, 1, 0), expected: 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			f.products.Now = common.TestNow{Time: test.now}
+
+			breakdown, err := f.products.Cost(ctx, f.OrgId, bread.Id)
+
+			if err != nil {
+				t.Fatalf("Cost() = _, %s. want nil", err)
+			}
+
+			if math.Abs(breakdown.Cost-test.expected) > 1e-9 {
+				t.Errorf("Cost().Cost = %f. want %f", breakdown.Cost, test.expected)
+			}
+
+			asOf, err := f.products.CostAsOf(ctx, f.OrgId, bread.Id, test.now)
+
+			if err != nil {
+				t.Fatalf("CostAsOf() = _, %s. want nil", err)
+			}
+
+			if breakdown.Cost != asOf.Cost {
+				t.Errorf("Cost().Cost = %f. want CostAsOf(now).Cost %f", breakdown.Cost, asOf.Cost)
+			}
+		})
+	}
+}
+
+func TestProductCostWithYieldAndWaste(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	// 1000g for $2.00 and 80% usable after peeling
+	onion := f.ingredient(t, f.OrgId, ingredient.IngredientInput{Name: "Onion", Unit: "g", UnitCount: 1000, PurchasePrice: 200, YieldPercent: 80})
+
+	soup, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Onion Soup", MenuPrice: 1000, Servings: 2})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	// 400g of peeled onion with a fifth burnt in the pan
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, soup.Id, ProductIngredientInput{IngredientId: onion.Id, Amount: 400, WastePercent: 20}); err != nil {
+		t.Fatalf("AddProductIngredient() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, soup.Id, ProductIngredientInput{IngredientId: onion.Id, Amount: 1, WastePercent: 100}); !errors.Is(err, InvalidProductIngredientError) {
+		t.Errorf("AddProductIngredient(100%% waste) = _, %v. want %v", err, InvalidProductIngredientError)
+	}
+
+	breakdown, err := f.products.Cost(ctx, f.OrgId, soup.Id)
+
+	if err != nil {
+		t.Fatalf("Cost() = _, %s. want nil", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"AsPurchasedCost", breakdown.AsPurchasedCost, 80},
+		{"Cost", breakdown.Cost, 125},
+		{"AsPurchasedCostPerServing", breakdown.AsPurchasedCostPerServing, 40},
+		{"CostPerServing", breakdown.CostPerServing, 62.5},
+	}
+
+	for _, test := range tests {
+		if math.Abs(test.value-test.expected) > 1e-9 {
+			t.Errorf("Cost().%s = %f. want %f", test.name, test.value, test.expected)
+		}
+	}
+}
+
+func TestProductLocations(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	downtown, err := f.locations.CreateLocation(ctx, f.OrgId, organization.LocationInput{Address: "1 Main St"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	airport, err := f.locations.CreateLocation(ctx, f.OrgId, organization.LocationInput{Address: "2 Terminal Rd"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	other, err := f.locations.CreateLocation(ctx, f.OtherOrgId, organization.LocationInput{Address: "3 Elm St"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation(other org) = _, %s. want nil", err)
+	}
+
+	pizza, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", Category: " Pizza ", MenuPrice: 1200, Servings: 4})
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	if found, _ := f.products.GetProduct(ctx, f.OrgId, pizza.Id); found.Category != "Pizza" {
+		t.Errorf("GetProduct().Category = %q. want %q", found.Category, "Pizza")
+	}
+
+	set, err := f.products.SetProductLocations(ctx, f.OrgId, pizza.Id, []int{airport.Id, downtown.Id, airport.Id})
+
+	if err != nil {
+		t.Fatalf("SetProductLocations() = _, %s. want nil", err)
+	}
+
+	expected := []int{downtown.Id, airport.Id}
+
+	if !slices.Equal(set, expected) {
+		t.Errorf("SetProductLocations() = %v. want %v", set, expected)
+	}
+
+	if _, err := f.products.SetProductLocations(ctx, f.OrgId, pizza.Id, []int{other.Id}); !errors.Is(err, organization.LocationNotFoundError) {
+		t.Errorf("SetProductLocations(other org) = _, %v. want %v", err, organization.LocationNotFoundError)
+	}
+
+	listed, err := f.products.ListProductLocations(ctx, f.OrgId, pizza.Id)
+
+	if err != nil {
+		t.Fatalf("ListProductLocations() = _, %s. want nil", err)
+	}
+
+	if !slices.Equal(listed, expected) {
+		t.Errorf("ListProductLocations() = %v. want %v", listed, expected)
+	}
+
+	if _, err := f.products.ListProductLocations(ctx, f.OtherOrgId, pizza.Id); !errors.Is(err, ProductNotFoundError) {
+		t.Errorf("ListProductLocations(other org) = _, %v. want %v", err, ProductNotFoundError)
+	}
+}
+
+func TestProductTags(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	beverages, err := f.tags.CreateTag(ctx, f.OrgId, tag.TagInput{Name: "Beverages", Type: tag.TypeProduct})
+
+	if err != nil {
+		t.Fatalf("CreateTag() = _, %s. want nil", err)
+	}
+
+	dairy, err := f.tags.CreateTag(ctx, f.OrgId, tag.TagInput{Name: "Dairy", Type: tag.TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("CreateTag(ingredient) = _, %s. want nil", err)
+	}
+
+	latte, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Latte", MenuPrice: 500, Servings: 1})
+
+	if err != nil {
+		t.Fatalf("CreateProduct(latte) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.CreateProduct(ctx, f.OrgId, ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4}); err != nil {
+		t.Fatalf("CreateProduct(pizza) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.SetProductTags(ctx, f.OrgId, latte.Id, []int{beverages.Id}); err != nil {
+		t.Fatalf("SetProductTags() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.SetProductTags(ctx, f.OrgId, latte.Id, []int{dairy.Id}); !errors.Is(err, tag.TagNotFoundError) {
+		t.Errorf("SetProductTags(ingredient tag) = _, %v. want %v", err, tag.TagNotFoundError)
+	}
+
+	if _, err := f.products.SetProductTags(ctx, f.OtherOrgId, latte.Id, []int{beverages.Id}); !errors.Is(err, ProductNotFoundError) {
+		t.Errorf("SetProductTags(other org) = _, %v. want %v", err, ProductNotFoundError)
+	}
+
+	products, err := f.products.ListProducts(ctx, f.OrgId, Filter{TagIds: []int{beverages.Id}})
+
+	if err != nil {
+		t.Fatalf("ListProducts() = _, %s. want nil", err)
+	}
+
+	if len(products) != 1 || products[0].Id != latte.Id {
+		t.Errorf("ListProducts(beverages) = %v. want only Latte", products)
+	}
+
+	if all, _ := f.products.ListProducts(ctx, f.OrgId, Filter{}); len(all) != 2 {
+		t.Errorf("len(ListProducts()) = %d. want 2", len(all))
+	}
+
+	listed, err := f.products.ListProductTags(ctx, f.OrgId, latte.Id)
+
+	if err != nil {
+		t.Fatalf("ListProductTags() = _, %s. want nil", err)
+	}
+
+	if !slices.Equal(listed, []int{beverages.Id}) {
+		t.Errorf("ListProductTags() = %v. want [%d]", listed, beverages.Id)
+	}
+}
@@ -0,0 +1,61 @@
+package product
+
+import (
+	"context"
+	"time"
+)
+
+// The ingredient or prep recipe of a recipe along with the data needed to
+// cost it. Purchase and yield fields are only set for ingredients
+type costLine struct {
+	ingredientId   int
+	prepRecipeId   int
+	name           string
+	ingredientUnit string
+	unitCount      float64
+	purchasePrice  int64
+	yieldPercent   float64
+	amount         float64
+	unit           string
+	wastePercent   float64
+}
+
+type Repo interface {
+	CreateProduct(context.Context, Product) (Product, error)
+	GetProduct(context.Context, int, int) (Product, error)
+	UpdateProduct(context.Context, Product) (Product, error)
+	DeleteProduct(context.Context, int, int) error
+	ListProducts(context.Context, int, Filter) ([]Product, error)
+
+	SetProductLocations(context.Context, int, int, []int) error
+	ListProductLocations(context.Context, int, int) ([]int, error)
+
+	SetProductTags(context.Context, int, int, []int) error
+	ListProductTags(context.Context, int, int) ([]int, error)
+
+	AddProductIngredient(context.Context, int, ProductIngredient) (ProductIngredient, error)
+	UpdateProductIngredient(context.Context, int, ProductIngredient) (ProductIngredient, error)
+	RemoveProductIngredient(context.Context, int, int, int) error
+	ListProductIngredients(context.Context, int, int) ([]ProductIngredient, error)
+
+	IngredientUnit(context.Context, int, int) (string, error)
+	CostLines(context.Context, int, int, time.Time) ([]costLine, error)
+
+	CreatePrepRecipe(context.Context, PrepRecipe) (PrepRecipe, error)
+	GetPrepRecipe(context.Context, int, int) (PrepRecipe, error)
+	UpdatePrepRecipe(context.Context, PrepRecipe) (PrepRecipe, error)
+	DeletePrepRecipe(context.Context, int, int) error
+	ListPrepRecipes(context.Context, int) ([]PrepRecipe, error)
+	// Distinct units of the product and prep lines using a prep recipe. Lines
+	// in the yield unit have a blank unit
+	PrepRecipeUseUnits(context.Context, int) ([]string, error)
+
+	AddPrepIngredient(context.Context, int, PrepIngredient) (PrepIngredient, error)
+	UpdatePrepIngredient(context.Context, int, PrepIngredient) (PrepIngredient, error)
+	RemovePrepIngredient(context.Context, int, int, int) error
+	ListPrepIngredients(context.Context, int, int) ([]PrepIngredient, error)
+
+	// Prep recipes used by each of the organization's prep recipes
+	PrepEdges(context.Context, int) (map[int][]int, error)
+	PrepCostLines(context.Context, int, int, time.Time) ([]costLine, error)
+}
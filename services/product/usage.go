@@ -0,0 +1,202 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"moon-cost/services/costing"
+	"moon-cost/services/ingredient"
+	"moon-cost/units"
+	"time"
+)
+
+// Computes how much of each ingredient is used to make one serving of a
+// product, with the prices in effect at asOf. Prep recipes are expanded into
+// the ingredients they are made from. A zero asOf uses the prices in effect now
+func (s *Service) Usage(ctx context.Context, organizationId int, productId int, asOf time.Time) ([]IngredientUsage, error) {
+	asOf = s.pricesAt(asOf)
+
+	product, err := s.Repo.GetProduct(ctx, organizationId, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	costLines, err := s.Repo.CostLines(ctx, organizationId, productId, asOf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := s.Units.Registry(ctx, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := &recipeUsage{
+		repo:           s.Repo,
+		organizationId: organizationId,
+		asOf:           asOf,
+		registry:       registry,
+		preps:          map[int][]IngredientUsage{},
+		visiting:       map[int]bool{},
+	}
+
+	batch, err := recipes.lines(ctx, costLines)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return addUsage(nil, batch, 1/float64(product.Servings)), nil
+}
+
+// recipeUsage expands recipes into the ingredients they use. The usage of a
+// batch of each prep recipe is computed once and reused by every recipe
+// using it
+type recipeUsage struct {
+	repo           Repo
+	organizationId int
+	asOf           time.Time
+	registry       *units.Registry
+	preps          map[int][]IngredientUsage
+	visiting       map[int]bool
+}
+
+func (r *recipeUsage) lines(ctx context.Context, costLines []costLine) ([]IngredientUsage, error) {
+	var usage []IngredientUsage
+
+	for _, c := range costLines {
+		if c.prepRecipeId == 0 {
+			line, err := c.usage(r.registry)
+
+			if err != nil {
+				return nil, err
+			}
+
+			usage = addUsage(usage, []IngredientUsage{line}, 1)
+			continue
+		}
+
+		prep, batches, err := r.prepLine(ctx, c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		usage = addUsage(usage, prep, batches)
+	}
+
+	return usage, nil
+}
+
+// Returns the usage of a batch of the line's prep recipe and how many
+// batches the line uses, including its waste
+func (r *recipeUsage) prepLine(ctx context.Context, c costLine) ([]IngredientUsage, float64, error) {
+	recipe, err := r.repo.GetPrepRecipe(ctx, r.organizationId, c.prepRecipeId)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	usage, err := r.prep(ctx, recipe)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	unit := c.unit
+
+	if unit == "" {
+		unit = recipe.YieldUnit
+	}
+
+	amount, err := r.registry.Convert(c.amount, unit, recipe.YieldUnit)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	line := costing.Line{WastePercent: c.wastePercent}
+
+	return usage, amount / line.Usable() / recipe.YieldAmount, nil
+}
+
+// Returns PrepRecipeCycleError when the prep recipe uses itself
+func (r *recipeUsage) prep(ctx context.Context, recipe PrepRecipe) ([]IngredientUsage, error) {
+	if usage, ok := r.preps[recipe.Id]; ok {
+		return usage, nil
+	}
+
+	if r.visiting[recipe.Id] {
+		return nil, PrepRecipeCycleError
+	}
+
+	r.visiting[recipe.Id] = true
+	defer delete(r.visiting, recipe.Id)
+
+	costLines, err := r.repo.PrepCostLines(ctx, r.organizationId, recipe.Id, r.asOf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := r.lines(ctx, costLines)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.preps[recipe.Id] = usage
+
+	return usage, nil
+}
+
+// The as-purchased amount of the ingredient needed for the line, in the unit
+// the ingredient is purchased in
+func (c costLine) usage(registry *units.Registry) (IngredientUsage, error) {
+	unit := c.unit
+
+	if unit == "" {
+		unit = c.ingredientUnit
+	}
+
+	amount, err := registry.Convert(c.amount, unit, c.ingredientUnit)
+
+	if err != nil {
+		return IngredientUsage{}, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	line := costing.Line{YieldPercent: c.yieldPercent, WastePercent: c.wastePercent}
+
+	return IngredientUsage{
+		IngredientId: c.ingredientId,
+		Name:         c.name,
+		Unit:         c.ingredientUnit,
+		Amount:       amount / line.Usable(),
+		CostPerUnit:  ingredient.UnitCost(c.purchasePrice, c.unitCount),
+	}, nil
+}
+
+// Adds each of add, scaled by scale, to usage. Ingredients already in usage
+// have their amounts summed
+func addUsage(usage []IngredientUsage, add []IngredientUsage, scale float64) []IngredientUsage {
+	for _, line := range add {
+		line.Amount *= scale
+		found := false
+
+		for i := range usage {
+			if usage[i].IngredientId == line.IngredientId {
+				usage[i].Amount += line.Amount
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			usage = append(usage, line)
+		}
+	}
+
+	return usage
+}
@@ -0,0 +1,45 @@
+package report
+
+import "time"
+
+// Row is the profitability of one product. Money is in cents. Error is set,
+// and the costs left zero, when the product could not be costed
+type Row struct {
+	ProductId          int     `json:"productId"`
+	Name               string  `json:"name"`
+	Category           string  `json:"category"`
+	Servings           int     `json:"servings"`
+	MenuPrice          int64   `json:"menuPrice"`
+	CostPerServing     float64 `json:"costPerServing"`
+	FoodCostPercent    float64 `json:"foodCostPercent"`
+	GrossMargin        float64 `json:"grossMargin"`
+	GrossMarginPercent float64 `json:"grossMarginPercent"`
+	Error              string  `json:"error,omitempty"`
+}
+
+// Summary averages the rows that were costed and have a menu price
+type Summary struct {
+	Products               int     `json:"products"`
+	Errors                 int     `json:"errors"`
+	AverageFoodCostPercent float64 `json:"averageFoodCostPercent"`
+	AverageGrossMargin     float64 `json:"averageGrossMargin"`
+}
+
+// Group is the products of one category, location or tag
+type Group struct {
+	Name    string  `json:"name"`
+	Rows    []Row   `json:"rows"`
+	Summary Summary `json:"summary"`
+}
+
+// Menu is the menu profitability report of an organization. A product sold
+// at several locations or with several tags is listed in each of their
+// groups, so the overall Summary counts every product once
+type Menu struct {
+	OrganizationId int        `json:"organizationId"`
+	GroupBy        GroupBy    `json:"groupBy"`
+	AsOf           *time.Time `json:"asOf,omitempty"`
+	GeneratedAt    time.Time  `json:"generatedAt"`
+	Groups         []Group    `json:"groups"`
+	Summary        Summary    `json:"summary"`
+}
@@ -0,0 +1,207 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format is how a report is rendered
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatHTML Format = "html"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case "":
+		return FormatJSON, nil
+	case FormatCSV, FormatJSON, FormatHTML:
+		return Format(format), nil
+	}
+
+	return "", fmt.Errorf("%w: unknown format %q", InvalidReportError, format)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+
+	return "application/json"
+}
+
+// Writes the report to w in the given format
+func Render(w io.Writer, menu Menu, format Format) error {
+	switch format {
+	case FormatCSV:
+		return renderCSV(w, menu)
+	case FormatJSON:
+		return renderJSON(w, menu)
+	case FormatHTML:
+		return renderHTML(w, menu)
+	}
+
+	return fmt.Errorf("%w: unknown format %q", InvalidReportError, format)
+}
+
+var csvHeader = []string{
+	"group",
+	"product",
+	"category",
+	"servings",
+	"menu price",
+	"cost per serving",
+	"food cost %",
+	"gross margin",
+	"gross margin %",
+	"error",
+}
+
+// One line per product and group. Money is in dollars so the file opens
+// cleanly in a spreadsheet
+func renderCSV(w io.Writer, menu Menu) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, group := range menu.Groups {
+		for _, row := range group.Rows {
+			costs := []string{"", "", "", ""}
+
+			if row.Error == "" {
+				costs = []string{
+					dollars(row.CostPerServing),
+					percent(row.FoodCostPercent),
+					dollars(row.GrossMargin),
+					percent(row.GrossMarginPercent),
+				}
+			}
+
+			record := []string{group.Name, row.Name, row.Category, strconv.Itoa(row.Servings), dollars(float64(row.MenuPrice))}
+			record = append(record, costs...)
+			record = append(record, row.Error)
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func renderJSON(w io.Writer, menu Menu) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(menu)
+}
+
+func renderHTML(w io.Writer, menu Menu) error {
+	return htmlTemplate.Execute(w, menu)
+}
+
+func dollars(cents float64) string {
+	return strconv.FormatFloat(cents/100, 'f', 2, 64)
+}
+
+func percent(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+var htmlTemplate = template.Must(template.New("menu").Funcs(template.FuncMap{
+	"dollars": func(cents any) string {
+		switch cents := cents.(type) {
+		case int64:
+			return "$" + dollars(float64(cents))
+		case float64:
+			return "$" + dollars(cents)
+		}
+
+		return ""
+	},
+	"percent": func(value float64) string {
+		return percent(value) + "%"
+	},
+	"date": func(t time.Time) string {
+		return t.UTC().Format("Jan 2, 2006 15:04 MST")
+	},
+}).Parse(menuHTML))
+
+// Self contained so it can be emailed or printed without any other files
+const menuHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Menu profitability</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; margin: 2rem; }
+h1 { font-size: 1.5rem; margin-bottom: 0.25rem; }
+h2 { font-size: 1.15rem; margin: 2rem 0 0.5rem; }
+p.meta { color: #666; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.4rem 0.6rem; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f4f4f4; }
+tfoot td { font-weight: bold; border-top: 2px solid #999; }
+td.error { color: #b00020; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Menu profitability</h1>
+<p class="meta">
+Grouped by {{.GroupBy}}.
+{{if .AsOf}}Prices as of {{date .AsOf}}.{{else}}Current prices.{{end}}
+Generated {{date .GeneratedAt}}.
+</p>
+{{range .Groups}}
+<h2>{{.Name}}</h2>
+<table>
+<thead>
+<tr><th>Product</th><th>Servings</th><th>Menu price</th><th>Cost per serving</th><th>Food cost</th><th>Gross margin</th><th>Gross margin %</th></tr>
+</thead>
+<tbody>
+{{range .Rows}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{.Servings}}</td>
+<td>{{dollars .MenuPrice}}</td>
+{{if .Error}}<td class="error" colspan="4">{{.Error}}</td>{{else}}<td>{{dollars .CostPerServing}}</td>
+<td>{{percent .FoodCostPercent}}</td>
+<td>{{dollars .GrossMargin}}</td>
+<td>{{percent .GrossMarginPercent}}</td>{{end}}
+</tr>
+{{end}}
+</tbody>
+<tfoot>
+<tr><td colspan="4">Average of {{.Summary.Products}} products</td><td>{{percent .Summary.AverageFoodCostPercent}}</td><td>{{dollars .Summary.AverageGrossMargin}}</td><td></td></tr>
+</tfoot>
+</table>
+{{end}}
+<h2>All products</h2>
+<table>
+<tbody>
+<tr><td>Products</td><td>{{.Summary.Products}}</td></tr>
+<tr><td>Could not be costed</td><td>{{.Summary.Errors}}</td></tr>
+<tr><td>Average food cost</td><td>{{percent .Summary.AverageFoodCostPercent}}</td></tr>
+<tr><td>Average gross margin</td><td>{{dollars .Summary.AverageGrossMargin}}</td></tr>
+</tbody>
+</table>
+</body>
+</html>
+`
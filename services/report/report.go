@@ -0,0 +1,337 @@
+package report
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/services/costing"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/services/tag"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidReportError = errors.New("Invalid report")
+)
+
+// GroupBy is how the products of a report are grouped
+type GroupBy string
+
+const (
+	GroupByCategory GroupBy = "category"
+	GroupByLocation GroupBy = "location"
+	GroupByTag      GroupBy = "tag"
+)
+
+// Names of the groups of products without a category, location or tag
+const (
+	Uncategorized = "Uncategorized"
+	NoLocation    = "No location"
+	Untagged      = "Untagged"
+)
+
+// Products lists and costs the products of an organization
+type Products interface {
+	ListProducts(context.Context, int, product.Filter) ([]product.Product, error)
+	ListProductLocations(context.Context, int, int) ([]int, error)
+	ListProductTags(context.Context, int, int) ([]int, error)
+	CostAsOf(context.Context, int, int, time.Time) (costing.Breakdown, error)
+}
+
+// Locations lists the locations of an organization
+type Locations interface {
+	ListLocations(context.Context, int) ([]organization.Location, error)
+}
+
+// Tags lists the tags of an organization
+type Tags interface {
+	ListTags(context.Context, int, tag.Filter) ([]tag.Tag, error)
+}
+
+type Service struct {
+	Products  Products
+	Locations Locations
+	Tags      Tags
+	Logger    *slog.Logger
+	Now       common.Now
+}
+
+func NewService(products Products, locations Locations, tags Tags, logger *slog.Logger) *Service {
+	return &Service{
+		Products:  products,
+		Locations: locations,
+		Tags:      tags,
+		Logger:    logging.Logger(logger, slog.String("service", "report")),
+		Now:       common.TimeNow{},
+	}
+}
+
+// GroupBy defaults to GroupByCategory. A zero AsOf uses current prices.
+// TagIds only includes products with any of the tags
+type MenuInput struct {
+	GroupBy GroupBy
+	AsOf    time.Time
+	TagIds  []int
+}
+
+func (m MenuInput) groupBy() (GroupBy, error) {
+	switch m.GroupBy {
+	case "":
+		return GroupByCategory, nil
+	case GroupByCategory, GroupByLocation, GroupByTag:
+		return m.GroupBy, nil
+	}
+
+	return "", fmt.Errorf("%w: unknown group by %q", InvalidReportError, m.GroupBy)
+}
+
+// Builds the menu profitability report of an organization. Products that
+// can not be costed are listed with their error and left out of the summaries
+func (s *Service) Menu(ctx context.Context, organizationId int, input MenuInput) (Menu, error) {
+	groupBy, err := input.groupBy()
+
+	if err != nil {
+		return Menu{}, err
+	}
+
+	products, err := s.Products.ListProducts(ctx, organizationId, product.Filter{TagIds: input.TagIds})
+
+	if err != nil {
+		return Menu{}, err
+	}
+
+	rows := make([]Row, len(products))
+
+	for i, p := range products {
+		rows[i] = s.row(ctx, organizationId, p, input.AsOf)
+	}
+
+	var groups []Group
+
+	switch groupBy {
+	case GroupByLocation:
+		groups, err = s.locationGroups(ctx, organizationId, rows)
+	case GroupByTag:
+		groups, err = s.tagGroups(ctx, organizationId, rows)
+	default:
+		groups = categoryGroups(rows)
+	}
+
+	if err != nil {
+		return Menu{}, err
+	}
+
+	menu := Menu{
+		OrganizationId: organizationId,
+		GroupBy:        groupBy,
+		GeneratedAt:    s.Now.Now(),
+		Groups:         groups,
+		Summary:        summarize(rows),
+	}
+
+	if !input.AsOf.IsZero() {
+		menu.AsOf = &input.AsOf
+	}
+
+	return menu, nil
+}
+
+func (s *Service) row(ctx context.Context, organizationId int, p product.Product, asOf time.Time) Row {
+	row := Row{
+		ProductId: p.Id,
+		Name:      p.Name,
+		Category:  p.Category,
+		Servings:  p.Servings,
+		MenuPrice: p.MenuPrice,
+	}
+
+	breakdown, err := s.Products.CostAsOf(ctx, organizationId, p.Id, asOf)
+
+	if err != nil {
+		s.Logger.Warn("Could not cost product", "product", p.Id, "error", err)
+		row.Error = err.Error()
+
+		return row
+	}
+
+	row.CostPerServing = breakdown.CostPerServing
+	row.FoodCostPercent = breakdown.FoodCostPercent
+	row.GrossMargin = breakdown.GrossMargin
+	row.GrossMarginPercent = breakdown.GrossMarginPercent
+
+	return row
+}
+
+// Groups rows by category with uncategorized products last
+func categoryGroups(rows []Row) []Group {
+	byCategory := map[string][]Row{}
+
+	for _, row := range rows {
+		category := row.Category
+
+		if category == "" {
+			category = Uncategorized
+		}
+
+		byCategory[category] = append(byCategory[category], row)
+	}
+
+	return groups(byCategory, Uncategorized)
+}
+
+// Groups rows by each location selling the product with products not sold
+// at any location last
+func (s *Service) locationGroups(ctx context.Context, organizationId int, rows []Row) ([]Group, error) {
+	locations, err := s.Locations.ListLocations(ctx, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int]string{}
+
+	for _, location := range locations {
+		names[location.Id] = locationName(location)
+	}
+
+	byLocation := map[string][]Row{}
+
+	for _, row := range rows {
+		locationIds, err := s.Products.ListProductLocations(ctx, organizationId, row.ProductId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(locationIds) == 0 {
+			byLocation[NoLocation] = append(byLocation[NoLocation], row)
+		}
+
+		for _, locationId := range locationIds {
+			name := names[locationId]
+			byLocation[name] = append(byLocation[name], row)
+		}
+	}
+
+	return groups(byLocation, NoLocation), nil
+}
+
+// Groups rows by each of the product's tags with untagged products last
+func (s *Service) tagGroups(ctx context.Context, organizationId int, rows []Row) ([]Group, error) {
+	tags, err := s.Tags.ListTags(ctx, organizationId, tag.Filter{Type: tag.TypeProduct})
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int]string{}
+
+	for _, productTag := range tags {
+		names[productTag.Id] = productTag.Name
+	}
+
+	byTag := map[string][]Row{}
+
+	for _, row := range rows {
+		tagIds, err := s.Products.ListProductTags(ctx, organizationId, row.ProductId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tagIds) == 0 {
+			byTag[Untagged] = append(byTag[Untagged], row)
+		}
+
+		for _, tagId := range tagIds {
+			name := names[tagId]
+			byTag[name] = append(byTag[name], row)
+		}
+	}
+
+	return groups(byTag, Untagged), nil
+}
+
+// Locations have no name so they are named by their address
+func locationName(location organization.Location) string {
+	var parts []string
+
+	for _, part := range []string{location.Address, location.City, location.State} {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, strings.TrimSpace(part))
+		}
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("Location %d", location.Id)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Sorts groups by name with the last group, if any, at the end
+func groups(byName map[string][]Row, last string) []Group {
+	groups := make([]Group, 0, len(byName))
+
+	for name, rows := range byName {
+		slices.SortFunc(rows, func(a, b Row) int {
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+
+		groups = append(groups, Group{Name: name, Rows: rows, Summary: summarize(rows)})
+	}
+
+	slices.SortFunc(groups, func(a, b Group) int {
+		if a.Name == last || b.Name == last {
+			return cmp.Compare(boolInt(a.Name == last), boolInt(b.Name == last))
+		}
+
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return groups
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// Averages the rows that could be costed and have a menu price
+func summarize(rows []Row) Summary {
+	summary := Summary{Products: len(rows)}
+
+	var priced int
+
+	for _, row := range rows {
+		if row.Error != "" {
+			summary.Errors++
+			continue
+		}
+
+		if row.MenuPrice == 0 {
+			continue
+		}
+
+		priced++
+		summary.AverageFoodCostPercent += row.FoodCostPercent
+		summary.AverageGrossMargin += row.GrossMargin
+	}
+
+	if priced > 0 {
+		summary.AverageFoodCostPercent /= float64(priced)
+		summary.AverageGrossMargin /= float64(priced)
+	}
+
+	return summary
+}
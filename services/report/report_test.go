@@ -0,0 +1,355 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"math"
+	"moon-cost/common"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/services/tag"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testFixture struct {
+	moontest.TestEnv
+	reports     *Service
+	products    *product.Service
+	ingredients *ingredient.Service
+	locations   *organization.Service
+	tags        *tag.Service
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	env := moontest.NewTestEnv(t)
+
+	products := product.NewService(product.NewSQLiteRepo(env.DB), env.Logger)
+	locations := organization.NewService(organization.NewSQLiteRepo(env.DB), env.Logger)
+	tags := tag.NewService(tag.NewSQLiteRepo(env.DB), env.Logger)
+
+	reports := NewService(products, locations, tags, env.Logger)
+	reports.Now = common.TestNow{Time: time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	return testFixture{
+		TestEnv:     env,
+		reports:     reports,
+		products:    products,
+		ingredients: ingredient.NewService(ingredient.NewSQLiteRepo(env.DB), env.Logger),
+		locations:   locations,
+		tags:        tags,
+	}
+}
+
+// Creates a product that costs cost cents per serving
+func (f testFixture) product(t *testing.T, input product.ProductInput, cost int64) product.Product {
+	t.Helper()
+	ctx := context.Background()
+
+	created, err := f.products.CreateProduct(ctx, f.OrgId, input)
+
+	if err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	item, err := f.ingredients.CreateIngredient(ctx, f.OrgId, ingredient.IngredientInput{
+		Name:          input.Name + " mix",
+		Unit:          "each",
+		UnitCount:     1,
+		PurchasePrice: cost * int64(input.Servings),
+	})
+
+	if err != nil {
+		t.Fatalf("CreateIngredient() = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.AddProductIngredient(ctx, f.OrgId, created.Id, product.ProductIngredientInput{IngredientId: item.Id, Amount: 1}); err != nil {
+		t.Fatalf("AddProductIngredient() = _, %s. want nil", err)
+	}
+
+	return created
+}
+
+func groupNames(menu Menu) []string {
+	names := make([]string, len(menu.Groups))
+
+	for i, group := range menu.Groups {
+		names[i] = group.Name
+	}
+
+	return names
+}
+
+func TestMenuByCategory(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	f.product(t, product.ProductInput{Name: "Pepperoni", Category: "Pizza", MenuPrice: 1200, Servings: 4}, 300)
+	f.product(t, product.ProductInput{Name: "Cheese", Category: "Pizza", MenuPrice: 1000, Servings: 4}, 200)
+	f.product(t, product.ProductInput{Name: "Lemonade", Category: "Drinks", MenuPrice: 400, Servings: 1}, 40)
+	f.product(t, product.ProductInput{Name: "Special", MenuPrice: 0, Servings: 1}, 100)
+
+	menu, err := f.reports.Menu(ctx, f.OrgId, MenuInput{})
+
+	if err != nil {
+		t.Fatalf("Menu() = _, %s. want nil", err)
+	}
+
+	if menu.GroupBy != GroupByCategory || menu.AsOf != nil {
+		t.Errorf("Menu() grouped by %q as of %v. want %q and current prices", menu.GroupBy, menu.AsOf, GroupByCategory)
+	}
+
+	expected := []string{"Drinks", "Pizza", Uncategorized}
+
+	if names := groupNames(menu); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Menu().Groups = %v. want %v", names, expected)
+	}
+
+	pizza := menu.Groups[1]
+
+	if pizza.Rows[0].Name != "Cheese" || pizza.Rows[1].Name != "Pepperoni" {
+		t.Errorf("Menu().Groups[1].Rows = %s, %s. want Cheese, Pepperoni", pizza.Rows[0].Name, pizza.Rows[1].Name)
+	}
+
+	pepperoni := pizza.Rows[1]
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"CostPerServing", pepperoni.CostPerServing, 300},
+		{"FoodCostPercent", pepperoni.FoodCostPercent, 25},
+		{"GrossMargin", pepperoni.GrossMargin, 900},
+		{"GrossMarginPercent", pepperoni.GrossMarginPercent, 75},
+		{"Pizza AverageFoodCostPercent", pizza.Summary.AverageFoodCostPercent, 22.5},
+		{"Pizza AverageGrossMargin", pizza.Summary.AverageGrossMargin, 850},
+		// the unpriced special is left out of the averages
+		{"AverageFoodCostPercent", menu.Summary.AverageFoodCostPercent, 55.0 / 3},
+	}
+
+	for _, test := range tests {
+		if math.Abs(test.value-test.expected) > 1e-9 {
+			t.Errorf("Menu() %s = %f. want %f", test.name, test.value, test.expected)
+		}
+	}
+
+	if menu.Summary.Products != 4 {
+		t.Errorf("Menu().Summary.Products = %d. want 4", menu.Summary.Products)
+	}
+
+	if _, err := f.reports.Menu(ctx, f.OrgId, MenuInput{GroupBy: "color"}); !errors.Is(err, InvalidReportError) {
+		t.Errorf("Menu(unknown group by) = _, %v. want %v", err, InvalidReportError)
+	}
+}
+
+func TestMenuByLocation(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	downtown, err := f.locations.CreateLocation(ctx, f.OrgId, organization.LocationInput{Address: "1 Main St", City: "Omaha", State: "NE"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	airport, err := f.locations.CreateLocation(ctx, f.OrgId, organization.LocationInput{Address: "2 Airport Rd", City: "Omaha", State: "NE"})
+
+	if err != nil {
+		t.Fatalf("CreateLocation() = _, %s. want nil", err)
+	}
+
+	pizza := f.product(t, product.ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4}, 300)
+	coffee := f.product(t, product.ProductInput{Name: "Coffee", MenuPrice: 300, Servings: 1}, 30)
+	f.product(t, product.ProductInput{Name: "Special", MenuPrice: 500, Servings: 1}, 100)
+
+	if _, err := f.products.SetProductLocations(ctx, f.OrgId, pizza.Id, []int{downtown.Id, airport.Id}); err != nil {
+		t.Fatalf("SetProductLocations(pizza) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.SetProductLocations(ctx, f.OrgId, coffee.Id, []int{airport.Id}); err != nil {
+		t.Fatalf("SetProductLocations(coffee) = _, %s. want nil", err)
+	}
+
+	menu, err := f.reports.Menu(ctx, f.OrgId, MenuInput{GroupBy: GroupByLocation})
+
+	if err != nil {
+		t.Fatalf("Menu() = _, %s. want nil", err)
+	}
+
+	expected := []string{"1 Main St, Omaha, NE", "2 Airport Rd, Omaha, NE", NoLocation}
+
+	if names := groupNames(menu); strings.Join(names, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Menu().Groups = %v. want %v", names, expected)
+	}
+
+	if len(menu.Groups[1].Rows) != 2 {
+		t.Errorf("len(Menu().Groups[1].Rows) = %d. want 2", len(menu.Groups[1].Rows))
+	}
+
+	// pizza is in two groups but only counted once overall
+	if menu.Summary.Products != 3 {
+		t.Errorf("Menu().Summary.Products = %d. want 3", menu.Summary.Products)
+	}
+}
+
+func TestMenuByTag(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	beverages, err := f.tags.CreateTag(ctx, f.OrgId, tag.TagInput{Name: "Beverages", Type: tag.TypeProduct})
+
+	if err != nil {
+		t.Fatalf("CreateTag() = _, %s. want nil", err)
+	}
+
+	seasonal, err := f.tags.CreateTag(ctx, f.OrgId, tag.TagInput{Name: "Seasonal", Type: tag.TypeProduct})
+
+	if err != nil {
+		t.Fatalf("CreateTag() = _, %s. want nil", err)
+	}
+
+	cider := f.product(t, product.ProductInput{Name: "Cider", MenuPrice: 500, Servings: 1}, 100)
+	coffee := f.product(t, product.ProductInput{Name: "Coffee", MenuPrice: 300, Servings: 1}, 30)
+	f.product(t, product.ProductInput{Name: "Pizza", MenuPrice: 1200, Servings: 4}, 300)
+
+	if _, err := f.products.SetProductTags(ctx, f.OrgId, cider.Id, []int{beverages.Id, seasonal.Id}); err != nil {
+		t.Fatalf("SetProductTags(cider) = _, %s. want nil", err)
+	}
+
+	if _, err := f.products.SetProductTags(ctx, f.OrgId, coffee.Id, []int{beverages.Id}); err != nil {
+		t.Fatalf("SetProductTags(coffee) = _, %s. want nil", err)
+	}
+
+	menu, err := f.reports.Menu(ctx, f.OrgId, MenuInput{GroupBy: GroupByTag})
+
+	if err != nil {
+		t.Fatalf("Menu() = _, %s. want nil", err)
+	}
+
+	expected := []string{"Beverages", "Seasonal", Untagged}
+
+	if names := groupNames(menu); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Menu().Groups = %v. want %v", names, expected)
+	}
+
+	if len(menu.Groups[0].Rows) != 2 {
+		t.Errorf("len(Menu().Groups[0].Rows) = %d. want 2", len(menu.Groups[0].Rows))
+	}
+
+	filtered, err := f.reports.Menu(ctx, f.OrgId, MenuInput{TagIds: []int{seasonal.Id}})
+
+	if err != nil {
+		t.Fatalf("Menu(seasonal) = _, %s. want nil", err)
+	}
+
+	if filtered.Summary.Products != 1 || filtered.Groups[0].Rows[0].Name != "Cider" {
+		t.Errorf("Menu(seasonal) = %+v. want only Cider", filtered.Groups)
+	}
+}
+
+func TestSummarizeSkipsErrors(t *testing.T) {
+	rows := []Row{
+		{MenuPrice: 1000, FoodCostPercent: 30, GrossMargin: 700},
+		{MenuPrice: 1000, Error: "Unknown unit"},
+	}
+
+	summary := summarize(rows)
+
+	if summary.Products != 2 || summary.Errors != 1 || summary.AverageFoodCostPercent != 30 {
+		t.Errorf("summarize() = %+v. want 2 products, 1 error and 30%% food cost", summary)
+	}
+}
+
+func testMenu() Menu {
+	return Menu{
+		OrganizationId: 1,
+		GroupBy:        GroupByCategory,
+		GeneratedAt:    time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+		Groups: []Group{
+			{
+				Name: "Pizza",
+				Rows: []Row{
+					{ProductId: 1, Name: "Pepperoni", Category: "Pizza", Servings: 4, MenuPrice: 1200, CostPerServing: 300, FoodCostPercent: 25, GrossMargin: 900, GrossMarginPercent: 75},
+					{ProductId: 2, Name: "<Veggie>", Category: "Pizza", Servings: 4, MenuPrice: 1000, Error: "Unknown unit"},
+				},
+			},
+		},
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := Render(&out, testMenu(), FormatCSV); err != nil {
+		t.Fatalf("Render() = %s. want nil", err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+
+	if err != nil {
+		t.Fatalf("ReadAll() = _, %s. want nil", err)
+	}
+
+	expected := [][]string{
+		csvHeader,
+		{"Pizza", "Pepperoni", "Pizza", "4", "12.00", "3.00", "25.0", "9.00", "75.0", ""},
+		{"Pizza", "<Veggie>", "Pizza", "4", "10.00", "", "", "", "", "Unknown unit"},
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("len(records) = %d. want %d", len(records), len(expected))
+	}
+
+	for i, record := range records {
+		if strings.Join(record, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("records[%d] = %v. want %v", i, record, expected[i])
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := Render(&out, testMenu(), FormatHTML); err != nil {
+		t.Fatalf("Render() = %s. want nil", err)
+	}
+
+	html := out.String()
+
+	for _, expected := range []string{"<style>", "<h2>Pizza</h2>", "$12.00", "$3.00", "25.0%", "&lt;Veggie&gt;", "Unknown unit"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Render() does not contain %q", expected)
+		}
+	}
+
+	if strings.Contains(html, "<Veggie>") {
+		t.Errorf("Render() did not escape product names")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected Format
+		err      error
+	}{
+		{"", FormatJSON, nil},
+		{"csv", FormatCSV, nil},
+		{"html", FormatHTML, nil},
+		{"pdf", "", InvalidReportError},
+	}
+
+	for _, test := range tests {
+		format, err := ParseFormat(test.format)
+
+		if format != test.expected || !errors.Is(err, test.err) {
+			t.Errorf("ParseFormat(%q) = %q, %v. want %q, %v", test.format, format, err, test.expected, test.err)
+		}
+	}
+}
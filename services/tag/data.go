@@ -0,0 +1,27 @@
+package tag
+
+import "time"
+
+// Type is the kind of entity a tag can be assigned to
+type Type string
+
+const (
+	TypeIngredient Type = "ingredient"
+	TypeProduct    Type = "product"
+)
+
+// Tag categorizes the ingredients or products of an organization, like
+// "Dairy" or "Beverages". Names are unique per organization and type
+type Tag struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	Name           string    `json:"name"`
+	Type           Type      `json:"type"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Filter narrows the tags returned by ListTags. A zero Type matches every tag
+type Filter struct {
+	Type Type
+}
@@ -0,0 +1,11 @@
+package tag
+
+import "context"
+
+type Repo interface {
+	CreateTag(context.Context, Tag) (Tag, error)
+	GetTag(context.Context, int, int) (Tag, error)
+	UpdateTag(context.Context, Tag) (Tag, error)
+	DeleteTag(context.Context, int, int) error
+	ListTags(context.Context, int, Filter) ([]Tag, error)
+}
@@ -0,0 +1,103 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"strings"
+)
+
+var (
+	TagNotFoundError = errors.New("Tag not found")
+	TagExistsError   = errors.New("Tag already exists")
+	InvalidTagError  = errors.New("Invalid tag")
+)
+
+type Service struct {
+	Repo   Repo
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Logger: logging.Logger(logger, slog.String("service", "tag")),
+		Now:    common.TimeNow{},
+	}
+}
+
+func (t Type) validate() error {
+	switch t {
+	case TypeIngredient, TypeProduct:
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown type %q", InvalidTagError, t)
+}
+
+type TagInput struct {
+	Name string
+	Type Type
+}
+
+func (t TagInput) validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("%w: name is required", InvalidTagError)
+	}
+
+	return t.Type.validate()
+}
+
+func (s *Service) CreateTag(ctx context.Context, organizationId int, input TagInput) (Tag, error) {
+	if err := input.validate(); err != nil {
+		return Tag{}, err
+	}
+
+	now := s.Now.Now()
+
+	return s.Repo.CreateTag(ctx, Tag{
+		OrganizationId: organizationId,
+		Name:           strings.TrimSpace(input.Name),
+		Type:           input.Type,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+}
+
+func (s *Service) GetTag(ctx context.Context, organizationId int, id int) (Tag, error) {
+	return s.Repo.GetTag(ctx, organizationId, id)
+}
+
+// Renames a tag. The type of a tag can not change once it is assigned so only
+// the name is updated
+func (s *Service) UpdateTag(ctx context.Context, organizationId int, id int, name string) (Tag, error) {
+	if strings.TrimSpace(name) == "" {
+		return Tag{}, fmt.Errorf("%w: name is required", InvalidTagError)
+	}
+
+	return s.Repo.UpdateTag(ctx, Tag{
+		Id:             id,
+		OrganizationId: organizationId,
+		Name:           strings.TrimSpace(name),
+		UpdatedAt:      s.Now.Now(),
+	})
+}
+
+// Deletes a tag and removes it from every ingredient or product
+func (s *Service) DeleteTag(ctx context.Context, organizationId int, id int) error {
+	return s.Repo.DeleteTag(ctx, organizationId, id)
+}
+
+func (s *Service) ListTags(ctx context.Context, organizationId int, filter Filter) ([]Tag, error) {
+	if filter.Type != "" {
+		if err := filter.Type.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.Repo.ListTags(ctx, organizationId, filter)
+}
@@ -0,0 +1,214 @@
+package tag
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+const tagColumns = `id, organizationId, name, type, createdAt, updatedAt`
+
+func scanTag(row common.Scanner) (Tag, error) {
+	var tag Tag
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&tag.Id,
+		&tag.OrganizationId,
+		&tag.Name,
+		&tag.Type,
+		&createdAt,
+		&updatedAt,
+	)
+
+	tag.CreatedAt = time.UnixMilli(createdAt)
+	tag.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return tag, err
+}
+
+// Names are compared without case within the tags of the same organization and
+// type. id is excluded so a tag can keep its own name
+const nameTakenQuery = `
+SELECT COUNT(*) FROM org_tags
+WHERE organizationId = ? AND type = ? AND name = ? COLLATE NOCASE AND id != ?
+`
+
+func nameTaken(ctx context.Context, tx *sql.Tx, tag Tag) error {
+	var count int
+
+	err := tx.QueryRowContext(ctx, nameTakenQuery, tag.OrganizationId, tag.Type, tag.Name, tag.Id).Scan(&count)
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return TagExistsError
+	}
+
+	return nil
+}
+
+const createTagQuery = `
+INSERT INTO org_tags (organizationId, name, type, createdAt, updatedAt)
+VALUES (?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateTag(ctx context.Context, tag Tag) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return tag, err
+	}
+
+	defer tx.Rollback()
+
+	if err := nameTaken(ctx, tx, tag); err != nil {
+		return tag, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		createTagQuery,
+		tag.OrganizationId,
+		tag.Name,
+		tag.Type,
+		tag.CreatedAt.UnixMilli(),
+		tag.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return tag, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return tag, err
+	}
+
+	tag.Id = int(id)
+
+	return tag, tx.Commit()
+}
+
+const getTagQuery = `SELECT ` + tagColumns + ` FROM org_tags WHERE organizationId = ? AND id = ?`
+
+func (s *SQLiteRepo) GetTag(ctx context.Context, organizationId int, id int) (Tag, error) {
+	tag, err := scanTag(s.db.QueryRowContext(ctx, getTagQuery, organizationId, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, TagNotFoundError
+	}
+
+	return tag, err
+}
+
+// Only the name of a tag can change
+func (s *SQLiteRepo) UpdateTag(ctx context.Context, tag Tag) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return tag, err
+	}
+
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT type FROM org_tags WHERE organizationId = ? AND id = ?`,
+		tag.OrganizationId,
+		tag.Id,
+	).Scan(&tag.Type)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, TagNotFoundError
+	}
+
+	if err != nil {
+		return tag, err
+	}
+
+	if err := nameTaken(ctx, tx, tag); err != nil {
+		return tag, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE org_tags SET name = ?, updatedAt = ? WHERE organizationId = ? AND id = ?`,
+		tag.Name,
+		tag.UpdatedAt.UnixMilli(),
+		tag.OrganizationId,
+		tag.Id,
+	)
+
+	if err != nil {
+		return tag, err
+	}
+
+	if err := common.ExpectAffected(res, TagNotFoundError); err != nil {
+		return tag, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return tag, err
+	}
+
+	return s.GetTag(ctx, tag.OrganizationId, tag.Id)
+}
+
+func (s *SQLiteRepo) DeleteTag(ctx context.Context, organizationId int, id int) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM org_tags WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, TagNotFoundError)
+}
+
+const listTagsQuery = `SELECT ` + tagColumns + ` FROM org_tags WHERE organizationId = ?`
+
+func (s *SQLiteRepo) ListTags(ctx context.Context, organizationId int, filter Filter) ([]Tag, error) {
+	query, args := listTagsQuery, []any{organizationId}
+
+	if filter.Type != "" {
+		query += ` AND type = ?`
+		args = append(args, filter.Type)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query+` ORDER BY type ASC, name ASC`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []Tag{}
+
+	for rows.Next() {
+		tag, err := scanTag(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,101 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"moon-cost/moontest"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	db := moontest.LoadTestDB(t)
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	otherOrgId := moontest.InsertTestOrganization(t, db, "Other Cafe")
+
+	service := NewService(NewSQLiteRepo(db), slog.New(slog.DiscardHandler))
+
+	dairy, err := service.CreateTag(ctx, orgId, TagInput{Name: " Dairy ", Type: TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("CreateTag(dairy) = _, %s. want nil", err)
+	}
+
+	if dairy.Name != "Dairy" {
+		t.Errorf("CreateTag().Name = %q. want %q", dairy.Name, "Dairy")
+	}
+
+	beverages, err := service.CreateTag(ctx, orgId, TagInput{Name: "Beverages", Type: TypeProduct})
+
+	if err != nil {
+		t.Fatalf("CreateTag(beverages) = _, %s. want nil", err)
+	}
+
+	tests := []struct {
+		test     string
+		orgId    int
+		input    TagInput
+		expected error
+	}{
+		{"same name and type", orgId, TagInput{Name: "DAIRY", Type: TypeIngredient}, TagExistsError},
+		{"same name other type", orgId, TagInput{Name: "Dairy", Type: TypeProduct}, nil},
+		{"same name other org", otherOrgId, TagInput{Name: "Dairy", Type: TypeIngredient}, nil},
+		{"blank name", orgId, TagInput{Name: " ", Type: TypeIngredient}, InvalidTagError},
+		{"unknown type", orgId, TagInput{Name: "Local", Type: "vendor"}, InvalidTagError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := service.CreateTag(ctx, test.orgId, test.input)
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("CreateTag() = _, %v. want %v", err, test.expected)
+			}
+		})
+	}
+
+	ingredientTags, err := service.ListTags(ctx, orgId, Filter{Type: TypeIngredient})
+
+	if err != nil {
+		t.Fatalf("ListTags() = _, %s. want nil", err)
+	}
+
+	if len(ingredientTags) != 1 || ingredientTags[0].Id != dairy.Id {
+		t.Errorf("ListTags(ingredient) = %v. want only Dairy", ingredientTags)
+	}
+
+	if all, _ := service.ListTags(ctx, orgId, Filter{}); len(all) != 3 {
+		t.Errorf("len(ListTags()) = %d. want 3", len(all))
+	}
+
+	renamed, err := service.UpdateTag(ctx, orgId, beverages.Id, "Drinks")
+
+	if err != nil {
+		t.Fatalf("UpdateTag() = _, %s. want nil", err)
+	}
+
+	if renamed.Name != "Drinks" || renamed.Type != TypeProduct {
+		t.Errorf("UpdateTag() = %s %s. want Drinks product", renamed.Name, renamed.Type)
+	}
+
+	if _, err := service.UpdateTag(ctx, orgId, beverages.Id, "dairy"); !errors.Is(err, TagExistsError) {
+		t.Errorf("UpdateTag(taken) = _, %v. want %v", err, TagExistsError)
+	}
+
+	if _, err := service.UpdateTag(ctx, otherOrgId, beverages.Id, "Juice"); !errors.Is(err, TagNotFoundError) {
+		t.Errorf("UpdateTag(other org) = _, %v. want %v", err, TagNotFoundError)
+	}
+
+	if err := service.DeleteTag(ctx, otherOrgId, dairy.Id); !errors.Is(err, TagNotFoundError) {
+		t.Errorf("DeleteTag(other org) = %v. want %v", err, TagNotFoundError)
+	}
+
+	if err := service.DeleteTag(ctx, orgId, dairy.Id); err != nil {
+		t.Fatalf("DeleteTag() = %s. want nil", err)
+	}
+
+	if _, err := service.GetTag(ctx, orgId, dairy.Id); !errors.Is(err, TagNotFoundError) {
+		t.Errorf("GetTag(deleted) = _, %v. want %v", err, TagNotFoundError)
+	}
+}
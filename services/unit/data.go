@@ -0,0 +1,14 @@
+package unit
+
+import "time"
+
+// Unit is a pack unit defined by an organization as Amount of another unit,
+// e.g. a case is 6 gal
+type Unit struct {
+	Id             int       `json:"id"`
+	OrganizationId int       `json:"organizationId"`
+	Name           string    `json:"name"`
+	Amount         float64   `json:"amount"`
+	Unit           string    `json:"unit"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package unit
+
+import "context"
+
+type Repo interface {
+	CreateUnit(context.Context, Unit) (Unit, error)
+	DeleteUnit(context.Context, int, int) error
+	ListUnits(context.Context, int) ([]Unit, error)
+	// Names what uses a unit by name or is blank when nothing does
+	UnitUse(context.Context, int, string) (string, error)
+}
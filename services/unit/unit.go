@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"moon-cost/units"
+	"strings"
+)
+
+var (
+	UnitNotFoundError = errors.New("Unit not found")
+	UnitInUseError    = errors.New("Unit is in use")
+)
+
+type Service struct {
+	Repo   Repo
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Logger: logging.Logger(logger, slog.String("service", "unit")),
+		Now:    common.TimeNow{},
+	}
+}
+
+func customUnits(defined []Unit) []units.CustomUnit {
+	custom := make([]units.CustomUnit, len(defined))
+
+	for i, unit := range defined {
+		custom[i] = units.CustomUnit{
+			Name:   unit.Name,
+			Amount: unit.Amount,
+			Unit:   unit.Unit,
+		}
+	}
+
+	return custom
+}
+
+// Registry returns the standard units along with every unit defined by the
+// organization
+func (s *Service) Registry(ctx context.Context, organizationId int) (*units.Registry, error) {
+	defined, err := s.Repo.ListUnits(ctx, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return units.Default().With(customUnits(defined)...)
+}
+
+type UnitInput struct {
+	Name   string
+	Amount float64
+	Unit   string
+}
+
+// Defines a new unit for the organization. The unit it is defined in terms of
+// must already exist and the name must not conflict with any existing unit
+func (s *Service) CreateUnit(ctx context.Context, organizationId int, input UnitInput) (Unit, error) {
+	registry, err := s.Registry(ctx, organizationId)
+
+	if err != nil {
+		return Unit{}, err
+	}
+
+	custom := units.CustomUnit{
+		Name:   strings.TrimSpace(input.Name),
+		Amount: input.Amount,
+		Unit:   strings.TrimSpace(input.Unit),
+	}
+
+	if _, err := registry.With(custom); err != nil {
+		return Unit{}, err
+	}
+
+	return s.Repo.CreateUnit(ctx, Unit{
+		OrganizationId: organizationId,
+		Name:           custom.Name,
+		Amount:         custom.Amount,
+		Unit:           custom.Unit,
+		CreatedAt:      s.Now.Now(),
+	})
+}
+
+// Lists the units defined by the organization
+func (s *Service) ListUnits(ctx context.Context, organizationId int) ([]Unit, error) {
+	defined, err := s.Repo.ListUnits(ctx, organizationId)
+
+	if defined == nil {
+		defined = []Unit{}
+	}
+
+	return defined, err
+}
+
+// Deletes a unit unless another unit of the organization is defined in terms
+// of it or a recipe, ingredient, price or inventory record uses it
+func (s *Service) DeleteUnit(ctx context.Context, organizationId int, id int) error {
+	defined, err := s.Repo.ListUnits(ctx, organizationId)
+
+	if err != nil {
+		return err
+	}
+
+	var remaining []Unit
+	var deleted *Unit
+
+	for _, unit := range defined {
+		if unit.Id == id {
+			deleted = &unit
+			continue
+		}
+
+		remaining = append(remaining, unit)
+	}
+
+	if deleted == nil {
+		return UnitNotFoundError
+	}
+
+	if _, err := units.Default().With(customUnits(remaining)...); err != nil {
+		return fmt.Errorf("%w: %w", UnitInUseError, err)
+	}
+
+	use, err := s.Repo.UnitUse(ctx, organizationId, deleted.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if use != "" {
+		return fmt.Errorf("%w: %s uses %s", UnitInUseError, use, deleted.Name)
+	}
+
+	return s.Repo.DeleteUnit(ctx, organizationId, id)
+}
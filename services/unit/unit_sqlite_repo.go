@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+const createUnitQuery = `
+INSERT INTO units (organizationId, name, amount, unit, createdAt)
+VALUES (?, ?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateUnit(ctx context.Context, unit Unit) (Unit, error) {
+	res, err := s.db.ExecContext(
+		ctx,
+		createUnitQuery,
+		unit.OrganizationId,
+		unit.Name,
+		unit.Amount,
+		unit.Unit,
+		unit.CreatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return unit, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return unit, err
+	}
+
+	unit.Id = int(id)
+
+	return unit, nil
+}
+
+func (s *SQLiteRepo) DeleteUnit(ctx context.Context, organizationId int, id int) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM units WHERE organizationId = ? AND id = ?`, organizationId, id)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, UnitNotFoundError)
+}
+
+// Names the first kind of record of the organization that uses the unit by
+// name. Units are matched case insensitively
+const unitUseQuery = `
+SELECT use FROM (
+  SELECT 'ingredients' AS use FROM ingredients
+  WHERE organizationId = :org AND TRIM(unit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'ingredient prices' FROM ingredient_prices
+  JOIN ingredients ON ingredients.id = ingredient_prices.ingredientId
+  WHERE ingredients.organizationId = :org AND TRIM(ingredient_prices.unit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'products' FROM product_ingredients
+  JOIN products ON products.id = product_ingredients.productId
+  WHERE products.organizationId = :org AND TRIM(product_ingredients.unit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'prep recipes' FROM prep_recipes
+  WHERE organizationId = :org AND TRIM(yieldUnit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'prep recipes' FROM prep_recipe_ingredients
+  JOIN prep_recipes ON prep_recipes.id = prep_recipe_ingredients.recipeId
+  WHERE prep_recipes.organizationId = :org AND TRIM(prep_recipe_ingredients.unit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'inventory counts' FROM inventory_count_items
+  JOIN inventory_counts ON inventory_counts.id = inventory_count_items.countId
+  WHERE inventory_counts.organizationId = :org AND TRIM(inventory_count_items.unit) = :name COLLATE NOCASE
+  UNION ALL
+  SELECT 'purchases' FROM inventory_purchases
+  WHERE organizationId = :org AND TRIM(unit) = :name COLLATE NOCASE
+)
+LIMIT 1
+`
+
+func (s *SQLiteRepo) UnitUse(ctx context.Context, organizationId int, name string) (string, error) {
+	var use string
+
+	err := s.db.QueryRowContext(
+		ctx,
+		unitUseQuery,
+		sql.Named("org", organizationId),
+		sql.Named("name", name),
+	).Scan(&use)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return use, err
+}
+
+// Units are ordered by creation so units defined in terms of other custom
+// units resolve after them
+const listUnitsQuery = `
+SELECT id, organizationId, name, amount, unit, createdAt
+FROM units
+WHERE organizationId = ?
+ORDER BY id ASC
+`
+
+func (s *SQLiteRepo) ListUnits(ctx context.Context, organizationId int) ([]Unit, error) {
+	rows, err := s.db.QueryContext(ctx, listUnitsQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var defined []Unit
+
+	for rows.Next() {
+		var unit Unit
+		var createdAt int64
+
+		err := rows.Scan(
+			&unit.Id,
+			&unit.OrganizationId,
+			&unit.Name,
+			&unit.Amount,
+			&unit.Unit,
+			&createdAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		unit.CreatedAt = time.UnixMilli(createdAt)
+		defined = append(defined, unit)
+	}
+
+	return defined, rows.Err()
+}
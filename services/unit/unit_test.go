@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/product"
+	"moon-cost/units"
+	"testing"
+)
+
+func TestOrganizationUnits(t *testing.T) {
+	ctx := context.Background()
+	db := moontest.LoadTestDB(t)
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	otherOrgId := moontest.InsertTestOrganization(t, db, "Other Cafe")
+
+	service := NewService(NewSQLiteRepo(db), slog.New(slog.DiscardHandler))
+
+	sleeve, err := service.CreateUnit(ctx, orgId, UnitInput{Name: "sleeve", Amount: 50, Unit: "each"})
+
+	if err != nil {
+		t.Fatalf("CreateUnit(sleeve) = _, %s. want nil", err)
+	}
+
+	if _, err := service.CreateUnit(ctx, orgId, UnitInput{Name: "case", Amount: 20, Unit: "sleeve"}); err != nil {
+		t.Fatalf("CreateUnit(case) = _, %s. want nil", err)
+	}
+
+	if _, err := service.CreateUnit(ctx, orgId, UnitInput{Name: "SLEEVE", Amount: 10, Unit: "each"}); !errors.Is(err, units.InvalidUnitError) {
+		t.Errorf("CreateUnit(duplicate) = _, %v. want %s", err, units.InvalidUnitError)
+	}
+
+	registry, err := service.Registry(ctx, orgId)
+
+	if err != nil {
+		t.Fatalf("Registry() = _, %s. want nil", err)
+	}
+
+	converted, err := registry.Convert(1, "case", "each")
+
+	if err != nil || math.Abs(converted-1000) > 1e-9 {
+		t.Errorf("Convert(1, case, each) = %f, %v. want 1000, nil", converted, err)
+	}
+
+	otherRegistry, err := service.Registry(ctx, otherOrgId)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := otherRegistry.Lookup("case"); !errors.Is(err, units.UnknownUnitError) {
+		t.Errorf("other org Lookup(case) = _, %v. want %s", err, units.UnknownUnitError)
+	}
+
+	if err := service.DeleteUnit(ctx, orgId, sleeve.Id); !errors.Is(err, UnitInUseError) {
+		t.Errorf("DeleteUnit(sleeve) = %v. want %s", err, UnitInUseError)
+	}
+
+	if err := service.DeleteUnit(ctx, otherOrgId, sleeve.Id); !errors.Is(err, UnitNotFoundError) {
+		t.Errorf("DeleteUnit(other org) = %v. want %s", err, UnitNotFoundError)
+	}
+}
+
+func TestDeleteUnitInUse(t *testing.T) {
+	ctx := context.Background()
+	db := moontest.LoadTestDB(t)
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	logger := slog.New(slog.DiscardHandler)
+
+	service := NewService(NewSQLiteRepo(db), logger)
+	ingredients := ingredient.NewService(ingredient.NewSQLiteRepo(db), logger)
+	products := product.NewService(product.NewSQLiteRepo(db), logger)
+	products.Units = service
+
+	sleeve, err := service.CreateUnit(ctx, orgId, UnitInput{Name: "sleeve", Amount: 50, Unit: "each"})
+
+	if err != nil {
+		t.Fatalf("CreateUnit(sleeve) = _, %s. want nil", err)
+	}
+
+	cups, err := ingredients.CreateIngredient(ctx, orgId, ingredient.IngredientInput{Name: "Cups", Unit: "each", UnitCount: 1000, PurchasePrice: 5000})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latte, err := products.CreateProduct(ctx, orgId, product.ProductInput{Name: "Latte", MenuPrice: 500, Servings: 50})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := products.AddProductIngredient(ctx, orgId, latte.Id, product.ProductIngredientInput{IngredientId: cups.Id, Amount: 1, Unit: "Sleeve"})
+
+	if err != nil {
+		t.Fatalf("AddProductIngredient() = _, %s. want nil", err)
+	}
+
+	if err := service.DeleteUnit(ctx, orgId, sleeve.Id); !errors.Is(err, UnitInUseError) {
+		t.Errorf("DeleteUnit(used by a product) = %v. want %s", err, UnitInUseError)
+	}
+
+	if _, err := products.Cost(ctx, orgId, latte.Id); err != nil {
+		t.Errorf("Cost() after refused DeleteUnit() = _, %s. want nil", err)
+	}
+
+	if err := products.RemoveProductIngredient(ctx, orgId, latte.Id, line.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.DeleteUnit(ctx, orgId, sleeve.Id); err != nil {
+		t.Errorf("DeleteUnit(unused) = %s. want nil", err)
+	}
+}
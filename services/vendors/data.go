@@ -0,0 +1,56 @@
+package vendors
+
+import "time"
+
+// Vendor is a supplier shared by every organization. Details specific to an
+// organization's relationship with a vendor live on OrganizationVendor
+type Vendor struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Website   string    `json:"website"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Brand is a product brand shared by every organization
+type Brand struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// OrganizationVendor is an organization's account with a vendor. DeliveryDays
+// are lowercase weekday names in weekday order
+type OrganizationVendor struct {
+	OrganizationId int       `json:"organizationId"`
+	VendorId       int       `json:"vendorId"`
+	Name           string    `json:"name"`
+	AccountNumber  string    `json:"accountNumber"`
+	ContactName    string    `json:"contactName"`
+	ContactEmail   string    `json:"contactEmail"`
+	ContactPhone   string    `json:"contactPhone"`
+	DeliveryDays   []string  `json:"deliveryDays"`
+	Notes          string    `json:"notes"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// VendorSpend totals the prices an organization paid a vendor for the
+// purchases it recorded. Purchases are attributed to the vendor recorded on
+// each purchase and those without a vendor are totaled under a VendorId of 0.
+// Spend is in cents
+type VendorSpend struct {
+	VendorId    int    `json:"vendorId"`
+	Name        string `json:"name"`
+	Ingredients int    `json:"ingredients"`
+	Purchases   int    `json:"purchases"`
+	Spend       int64  `json:"spend"`
+}
+
+// SpendFilter narrows the purchases totaled by Spend. From and To are
+// inclusive. Zero values match everything
+type SpendFilter struct {
+	From time.Time
+	To   time.Time
+}
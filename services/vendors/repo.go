@@ -0,0 +1,21 @@
+package vendors
+
+import "context"
+
+type Repo interface {
+	CreateVendor(context.Context, Vendor) (Vendor, error)
+	GetVendor(context.Context, int) (Vendor, error)
+	ListVendors(context.Context) ([]Vendor, error)
+
+	CreateBrand(context.Context, Brand) (Brand, error)
+	GetBrand(context.Context, int) (Brand, error)
+	ListBrands(context.Context) ([]Brand, error)
+
+	SaveOrganizationVendor(context.Context, OrganizationVendor) (OrganizationVendor, error)
+	CreateOrganizationVendor(context.Context, Vendor, OrganizationVendor) (OrganizationVendor, error)
+	GetOrganizationVendor(context.Context, int, int) (OrganizationVendor, error)
+	RemoveOrganizationVendor(context.Context, int, int) error
+	ListOrganizationVendors(context.Context, int) ([]OrganizationVendor, error)
+
+	Spend(context.Context, int, SpendFilter) ([]VendorSpend, error)
+}
@@ -0,0 +1,228 @@
+package vendors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"moon-cost/common"
+	"moon-cost/logging"
+	"slices"
+	"strings"
+)
+
+var (
+	VendorNotFoundError             = errors.New("Vendor not found")
+	VendorExistsError               = errors.New("Vendor already exists")
+	InvalidVendorError              = errors.New("Invalid vendor")
+	BrandNotFoundError              = errors.New("Brand not found")
+	BrandExistsError                = errors.New("Brand already exists")
+	InvalidBrandError               = errors.New("Invalid brand")
+	OrganizationVendorNotFoundError = errors.New("Vendor is not linked to the organization")
+)
+
+// Weekdays in the order delivery days are stored
+var Weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+type Service struct {
+	Repo   Repo
+	Logger *slog.Logger
+	Now    common.Now
+}
+
+func NewService(repo Repo, logger *slog.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Logger: logging.Logger(logger, slog.String("service", "vendors")),
+		Now:    common.TimeNow{},
+	}
+}
+
+type VendorInput struct {
+	Name    string
+	Website string
+}
+
+func (v VendorInput) vendor() (Vendor, error) {
+	vendor := Vendor{
+		Name:    strings.TrimSpace(v.Name),
+		Website: strings.TrimSpace(v.Website),
+	}
+
+	if vendor.Name == "" {
+		return vendor, fmt.Errorf("%w: name is required", InvalidVendorError)
+	}
+
+	return vendor, nil
+}
+
+func (s *Service) CreateVendor(ctx context.Context, input VendorInput) (Vendor, error) {
+	vendor, err := input.vendor()
+
+	if err != nil {
+		return vendor, err
+	}
+
+	vendor.CreatedAt = s.Now.Now()
+	vendor.UpdatedAt = vendor.CreatedAt
+
+	return s.Repo.CreateVendor(ctx, vendor)
+}
+
+func (s *Service) GetVendor(ctx context.Context, id int) (Vendor, error) {
+	return s.Repo.GetVendor(ctx, id)
+}
+
+func (s *Service) ListVendors(ctx context.Context) ([]Vendor, error) {
+	return s.Repo.ListVendors(ctx)
+}
+
+type BrandInput struct {
+	Name string
+}
+
+func (b BrandInput) brand() (Brand, error) {
+	brand := Brand{
+		Name: strings.TrimSpace(b.Name),
+	}
+
+	if brand.Name == "" {
+		return brand, fmt.Errorf("%w: name is required", InvalidBrandError)
+	}
+
+	return brand, nil
+}
+
+func (s *Service) CreateBrand(ctx context.Context, input BrandInput) (Brand, error) {
+	brand, err := input.brand()
+
+	if err != nil {
+		return brand, err
+	}
+
+	brand.CreatedAt = s.Now.Now()
+	brand.UpdatedAt = brand.CreatedAt
+
+	return s.Repo.CreateBrand(ctx, brand)
+}
+
+func (s *Service) GetBrand(ctx context.Context, id int) (Brand, error) {
+	return s.Repo.GetBrand(ctx, id)
+}
+
+func (s *Service) ListBrands(ctx context.Context) ([]Brand, error) {
+	return s.Repo.ListBrands(ctx)
+}
+
+type OrganizationVendorInput struct {
+	AccountNumber string
+	ContactName   string
+	ContactEmail  string
+	ContactPhone  string
+	DeliveryDays  []string
+	Notes         string
+}
+
+// Returns the delivery days lowercased, deduplicated and in weekday order
+func deliveryDays(days []string) ([]string, error) {
+	sorted := []string{}
+
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+
+		if !slices.Contains(Weekdays, day) {
+			return nil, fmt.Errorf("%w: unknown delivery day %q", InvalidVendorError, day)
+		}
+
+		if !slices.Contains(sorted, day) {
+			sorted = append(sorted, day)
+		}
+	}
+
+	slices.SortFunc(sorted, func(a, b string) int {
+		return slices.Index(Weekdays, a) - slices.Index(Weekdays, b)
+	})
+
+	return sorted, nil
+}
+
+// Links a vendor to the organization or updates the existing link
+func (s *Service) SaveOrganizationVendor(ctx context.Context, organizationId int, vendorId int, input OrganizationVendorInput) (OrganizationVendor, error) {
+	days, err := deliveryDays(input.DeliveryDays)
+
+	if err != nil {
+		return OrganizationVendor{}, err
+	}
+
+	if _, err := s.Repo.GetVendor(ctx, vendorId); err != nil {
+		return OrganizationVendor{}, err
+	}
+
+	now := s.Now.Now()
+
+	return s.Repo.SaveOrganizationVendor(ctx, OrganizationVendor{
+		OrganizationId: organizationId,
+		VendorId:       vendorId,
+		AccountNumber:  strings.TrimSpace(input.AccountNumber),
+		ContactName:    strings.TrimSpace(input.ContactName),
+		ContactEmail:   strings.TrimSpace(input.ContactEmail),
+		ContactPhone:   strings.TrimSpace(input.ContactPhone),
+		DeliveryDays:   days,
+		Notes:          strings.TrimSpace(input.Notes),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+}
+
+// Creates a shared vendor and links it to the organization. Organizations add
+// vendors this way so no organization can edit a vendor others link to
+func (s *Service) CreateOrganizationVendor(ctx context.Context, organizationId int, vendorInput VendorInput, input OrganizationVendorInput) (OrganizationVendor, error) {
+	vendor, err := vendorInput.vendor()
+
+	if err != nil {
+		return OrganizationVendor{}, err
+	}
+
+	days, err := deliveryDays(input.DeliveryDays)
+
+	if err != nil {
+		return OrganizationVendor{}, err
+	}
+
+	now := s.Now.Now()
+
+	vendor.CreatedAt = now
+	vendor.UpdatedAt = now
+
+	return s.Repo.CreateOrganizationVendor(ctx, vendor, OrganizationVendor{
+		OrganizationId: organizationId,
+		AccountNumber:  strings.TrimSpace(input.AccountNumber),
+		ContactName:    strings.TrimSpace(input.ContactName),
+		ContactEmail:   strings.TrimSpace(input.ContactEmail),
+		ContactPhone:   strings.TrimSpace(input.ContactPhone),
+		DeliveryDays:   days,
+		Notes:          strings.TrimSpace(input.Notes),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+}
+
+func (s *Service) GetOrganizationVendor(ctx context.Context, organizationId int, vendorId int) (OrganizationVendor, error) {
+	return s.Repo.GetOrganizationVendor(ctx, organizationId, vendorId)
+}
+
+// Unlinks a vendor from the organization. Ingredients keep referencing the
+// vendor so their purchase history is not lost
+func (s *Service) RemoveOrganizationVendor(ctx context.Context, organizationId int, vendorId int) error {
+	return s.Repo.RemoveOrganizationVendor(ctx, organizationId, vendorId)
+}
+
+func (s *Service) ListOrganizationVendors(ctx context.Context, organizationId int) ([]OrganizationVendor, error) {
+	return s.Repo.ListOrganizationVendors(ctx, organizationId)
+}
+
+// Spend totals what the organization paid each vendor for the purchases it
+// recorded between the filter's times, with the largest spend first
+func (s *Service) Spend(ctx context.Context, organizationId int, filter SpendFilter) ([]VendorSpend, error) {
+	return s.Repo.Spend(ctx, organizationId, filter)
+}
@@ -0,0 +1,518 @@
+package vendors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moon-cost/common"
+	"strings"
+	"time"
+)
+
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{
+		db: db,
+	}
+}
+
+// Returns exists when a row in table already has name. Names are compared case
+// insensitively
+func nameTaken(ctx context.Context, tx *sql.Tx, table string, name string, exists error) error {
+	var count int
+
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM `+table+` WHERE name = ? COLLATE NOCASE`,
+		name,
+	).Scan(&count)
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return exists
+	}
+
+	return nil
+}
+
+const vendorColumns = `id, name, COALESCE(website, ''), createdAt, updatedAt`
+
+func scanVendor(row common.Scanner) (Vendor, error) {
+	var vendor Vendor
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&vendor.Id,
+		&vendor.Name,
+		&vendor.Website,
+		&createdAt,
+		&updatedAt,
+	)
+
+	vendor.CreatedAt = time.UnixMilli(createdAt)
+	vendor.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return vendor, err
+}
+
+const createVendorQuery = `
+INSERT INTO vendors (name, website, createdAt, updatedAt)
+VALUES (?, ?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateVendor(ctx context.Context, vendor Vendor) (Vendor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return vendor, err
+	}
+
+	defer tx.Rollback()
+
+	vendor, err = createVendorTx(ctx, tx, vendor)
+
+	if err != nil {
+		return vendor, err
+	}
+
+	return vendor, tx.Commit()
+}
+
+func createVendorTx(ctx context.Context, tx *sql.Tx, vendor Vendor) (Vendor, error) {
+	if err := nameTaken(ctx, tx, "vendors", vendor.Name, VendorExistsError); err != nil {
+		return vendor, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		createVendorQuery,
+		vendor.Name,
+		vendor.Website,
+		vendor.CreatedAt.UnixMilli(),
+		vendor.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return vendor, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return vendor, err
+	}
+
+	vendor.Id = int(id)
+
+	return vendor, nil
+}
+
+const getVendorQuery = `SELECT ` + vendorColumns + ` FROM vendors WHERE id = ?`
+
+func (s *SQLiteRepo) GetVendor(ctx context.Context, id int) (Vendor, error) {
+	vendor, err := scanVendor(s.db.QueryRowContext(ctx, getVendorQuery, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return vendor, VendorNotFoundError
+	}
+
+	return vendor, err
+}
+
+const listVendorsQuery = `SELECT ` + vendorColumns + ` FROM vendors ORDER BY name ASC`
+
+func (s *SQLiteRepo) ListVendors(ctx context.Context) ([]Vendor, error) {
+	rows, err := s.db.QueryContext(ctx, listVendorsQuery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	vendors := []Vendor{}
+
+	for rows.Next() {
+		vendor, err := scanVendor(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vendors = append(vendors, vendor)
+	}
+
+	return vendors, rows.Err()
+}
+
+const brandColumns = `id, name, createdAt, updatedAt`
+
+func scanBrand(row common.Scanner) (Brand, error) {
+	var brand Brand
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&brand.Id,
+		&brand.Name,
+		&createdAt,
+		&updatedAt,
+	)
+
+	brand.CreatedAt = time.UnixMilli(createdAt)
+	brand.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return brand, err
+}
+
+const createBrandQuery = `
+INSERT INTO brands (name, createdAt, updatedAt)
+VALUES (?, ?, ?)
+`
+
+func (s *SQLiteRepo) CreateBrand(ctx context.Context, brand Brand) (Brand, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return brand, err
+	}
+
+	defer tx.Rollback()
+
+	if err := nameTaken(ctx, tx, "brands", brand.Name, BrandExistsError); err != nil {
+		return brand, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		createBrandQuery,
+		brand.Name,
+		brand.CreatedAt.UnixMilli(),
+		brand.UpdatedAt.UnixMilli(),
+	)
+
+	if err != nil {
+		return brand, err
+	}
+
+	id, err := res.LastInsertId()
+
+	if err != nil {
+		return brand, err
+	}
+
+	brand.Id = int(id)
+
+	return brand, tx.Commit()
+}
+
+const getBrandQuery = `SELECT ` + brandColumns + ` FROM brands WHERE id = ?`
+
+func (s *SQLiteRepo) GetBrand(ctx context.Context, id int) (Brand, error) {
+	brand, err := scanBrand(s.db.QueryRowContext(ctx, getBrandQuery, id))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return brand, BrandNotFoundError
+	}
+
+	return brand, err
+}
+
+const listBrandsQuery = `SELECT ` + brandColumns + ` FROM brands ORDER BY name ASC`
+
+func (s *SQLiteRepo) ListBrands(ctx context.Context) ([]Brand, error) {
+	rows, err := s.db.QueryContext(ctx, listBrandsQuery)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	brands := []Brand{}
+
+	for rows.Next() {
+		brand, err := scanBrand(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		brands = append(brands, brand)
+	}
+
+	return brands, rows.Err()
+}
+
+const organizationVendorColumns = `
+  organization_vendors.organizationId,
+  organization_vendors.vendorId,
+  vendors.name,
+  COALESCE(organization_vendors.accountNumber, ''),
+  COALESCE(organization_vendors.contactName, ''),
+  COALESCE(organization_vendors.contactEmail, ''),
+  COALESCE(organization_vendors.contactPhone, ''),
+  COALESCE(organization_vendors.deliveryDays, ''),
+  COALESCE(organization_vendors.notes, ''),
+  organization_vendors.createdAt,
+  organization_vendors.updatedAt
+`
+
+func scanOrganizationVendor(row common.Scanner) (OrganizationVendor, error) {
+	var vendor OrganizationVendor
+	var deliveryDays string
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&vendor.OrganizationId,
+		&vendor.VendorId,
+		&vendor.Name,
+		&vendor.AccountNumber,
+		&vendor.ContactName,
+		&vendor.ContactEmail,
+		&vendor.ContactPhone,
+		&deliveryDays,
+		&vendor.Notes,
+		&createdAt,
+		&updatedAt,
+	)
+
+	vendor.DeliveryDays = []string{}
+
+	if deliveryDays != "" {
+		vendor.DeliveryDays = strings.Split(deliveryDays, ",")
+	}
+
+	vendor.CreatedAt = time.UnixMilli(createdAt)
+	vendor.UpdatedAt = time.UnixMilli(updatedAt)
+
+	return vendor, err
+}
+
+// Inserts the link or updates everything but createdAt when it exists
+const saveOrganizationVendorQuery = `
+INSERT INTO organization_vendors (
+  organizationId,
+  vendorId,
+  accountNumber,
+  contactName,
+  contactEmail,
+  contactPhone,
+  deliveryDays,
+  notes,
+  createdAt,
+  updatedAt
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (organizationId, vendorId) DO UPDATE SET
+  accountNumber = excluded.accountNumber,
+  contactName = excluded.contactName,
+  contactEmail = excluded.contactEmail,
+  contactPhone = excluded.contactPhone,
+  deliveryDays = excluded.deliveryDays,
+  notes = excluded.notes,
+  updatedAt = excluded.updatedAt
+`
+
+func (s *SQLiteRepo) SaveOrganizationVendor(ctx context.Context, vendor OrganizationVendor) (OrganizationVendor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return vendor, err
+	}
+
+	defer tx.Rollback()
+
+	if err := saveOrganizationVendorTx(ctx, tx, vendor); err != nil {
+		return vendor, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return vendor, err
+	}
+
+	return s.GetOrganizationVendor(ctx, vendor.OrganizationId, vendor.VendorId)
+}
+
+func saveOrganizationVendorTx(ctx context.Context, tx *sql.Tx, vendor OrganizationVendor) error {
+	_, err := tx.ExecContext(
+		ctx,
+		saveOrganizationVendorQuery,
+		vendor.OrganizationId,
+		vendor.VendorId,
+		vendor.AccountNumber,
+		vendor.ContactName,
+		vendor.ContactEmail,
+		vendor.ContactPhone,
+		strings.Join(vendor.DeliveryDays, ","),
+		vendor.Notes,
+		vendor.CreatedAt.UnixMilli(),
+		vendor.UpdatedAt.UnixMilli(),
+	)
+
+	return err
+}
+
+// Creates the vendor and links it in a single transaction so a failed link
+// does not leave an unlinked vendor behind
+func (s *SQLiteRepo) CreateOrganizationVendor(ctx context.Context, vendor Vendor, link OrganizationVendor) (OrganizationVendor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return link, err
+	}
+
+	defer tx.Rollback()
+
+	vendor, err = createVendorTx(ctx, tx, vendor)
+
+	if err != nil {
+		return link, err
+	}
+
+	link.VendorId = vendor.Id
+
+	if err := saveOrganizationVendorTx(ctx, tx, link); err != nil {
+		return link, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return link, err
+	}
+
+	return s.GetOrganizationVendor(ctx, link.OrganizationId, link.VendorId)
+}
+
+const getOrganizationVendorQuery = `
+SELECT ` + organizationVendorColumns + `
+FROM organization_vendors
+JOIN vendors ON vendors.id = organization_vendors.vendorId
+WHERE organization_vendors.organizationId = ? AND organization_vendors.vendorId = ?
+`
+
+func (s *SQLiteRepo) GetOrganizationVendor(ctx context.Context, organizationId int, vendorId int) (OrganizationVendor, error) {
+	vendor, err := scanOrganizationVendor(s.db.QueryRowContext(ctx, getOrganizationVendorQuery, organizationId, vendorId))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return vendor, OrganizationVendorNotFoundError
+	}
+
+	return vendor, err
+}
+
+const removeOrganizationVendorQuery = `
+DELETE FROM organization_vendors
+WHERE organizationId = ? AND vendorId = ?
+`
+
+func (s *SQLiteRepo) RemoveOrganizationVendor(ctx context.Context, organizationId int, vendorId int) error {
+	res, err := s.db.ExecContext(ctx, removeOrganizationVendorQuery, organizationId, vendorId)
+
+	if err != nil {
+		return err
+	}
+
+	return common.ExpectAffected(res, OrganizationVendorNotFoundError)
+}
+
+const listOrganizationVendorsQuery = `
+SELECT ` + organizationVendorColumns + `
+FROM organization_vendors
+JOIN vendors ON vendors.id = organization_vendors.vendorId
+WHERE organization_vendors.organizationId = ?
+ORDER BY vendors.name ASC
+`
+
+func (s *SQLiteRepo) ListOrganizationVendors(ctx context.Context, organizationId int) ([]OrganizationVendor, error) {
+	rows, err := s.db.QueryContext(ctx, listOrganizationVendorsQuery, organizationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	vendors := []OrganizationVendor{}
+
+	for rows.Next() {
+		vendor, err := scanOrganizationVendor(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vendors = append(vendors, vendor)
+	}
+
+	return vendors, rows.Err()
+}
+
+// Prices each purchase with the latest price of its ingredient in effect when
+// it was purchased, falling back to the ingredient's earliest price
+// Totals the price paid for each purchase under the vendor it was bought from
+const spendQuery = `
+SELECT
+  COALESCE(purchases.vendorId, 0),
+  COALESCE(vendors.name, ''),
+  COUNT(DISTINCT purchases.ingredientId),
+  COUNT(*),
+  SUM(purchases.price)
+FROM inventory_purchases AS purchases
+LEFT JOIN vendors ON vendors.id = purchases.vendorId
+WHERE purchases.organizationId = ?
+`
+
+func (s *SQLiteRepo) Spend(ctx context.Context, organizationId int, filter SpendFilter) ([]VendorSpend, error) {
+	query := spendQuery
+	args := []any{organizationId}
+
+	if !filter.From.IsZero() {
+		query += ` AND purchases.purchasedAt >= ?`
+		args = append(args, filter.From.UnixMilli())
+	}
+
+	if !filter.To.IsZero() {
+		query += ` AND purchases.purchasedAt <= ?`
+		args = append(args, filter.To.UnixMilli())
+	}
+
+	query += `
+GROUP BY COALESCE(purchases.vendorId, 0)
+ORDER BY SUM(purchases.price) DESC, COALESCE(vendors.name, '') ASC
+`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	spend := []VendorSpend{}
+
+	for rows.Next() {
+		var total VendorSpend
+
+		err := rows.Scan(
+			&total.VendorId,
+			&total.Name,
+			&total.Ingredients,
+			&total.Purchases,
+			&total.Spend,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		spend = append(spend, total)
+	}
+
+	return spend, rows.Err()
+}
@@ -0,0 +1,361 @@
+package vendors
+
+import (
+	"context"
+	"errors"
+	"moon-cost/common"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/services/inventory"
+	"moon-cost/services/organization"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testFixture struct {
+	moontest.TestEnv
+	vendors     *Service
+	ingredients *ingredient.Service
+	inventory   *inventory.Service
+	locations   *organization.Service
+}
+
+func newTestFixture(t *testing.T) testFixture {
+	env := moontest.NewTestEnv(t)
+
+	return testFixture{
+		TestEnv:     env,
+		vendors:     NewService(NewSQLiteRepo(env.DB), env.Logger),
+		ingredients: ingredient.NewService(ingredient.NewSQLiteRepo(env.DB), env.Logger),
+		inventory:   inventory.NewService(inventory.NewSQLiteRepo(env.DB), nil, env.Logger),
+		locations:   organization.NewService(organization.NewSQLiteRepo(env.DB), env.Logger),
+	}
+}
+
+func TestVendorsAndBrands(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	if _, err := f.vendors.CreateVendor(ctx, VendorInput{Name: " "}); !errors.Is(err, InvalidVendorError) {
+		t.Errorf("CreateVendor(blank) = _, %v. want %s", err, InvalidVendorError)
+	}
+
+	if _, err := f.vendors.CreateVendor(ctx, VendorInput{Name: "Sysco", Website: "https://sysco.com"}); err != nil {
+		t.Fatalf("CreateVendor() = _, %s. want nil", err)
+	}
+
+	if _, err := f.vendors.CreateVendor(ctx, VendorInput{Name: "sysco"}); !errors.Is(err, VendorExistsError) {
+		t.Errorf("CreateVendor(duplicate) = _, %v. want %s", err, VendorExistsError)
+	}
+
+	brand, err := f.vendors.CreateBrand(ctx, BrandInput{Name: "King Arthur"})
+
+	if err != nil {
+		t.Fatalf("CreateBrand() = _, %s. want nil", err)
+	}
+
+	if _, err := f.vendors.CreateBrand(ctx, BrandInput{Name: "KING ARTHUR"}); !errors.Is(err, BrandExistsError) {
+		t.Errorf("CreateBrand(duplicate) = _, %v. want %s", err, BrandExistsError)
+	}
+
+	brands, err := f.vendors.ListBrands(ctx)
+
+	if err != nil {
+		t.Fatalf("ListBrands() = _, %s. want nil", err)
+	}
+
+	if len(brands) != 1 || brands[0].Id != brand.Id {
+		t.Errorf("ListBrands() = %v. want [%d]", brands, brand.Id)
+	}
+}
+
+func TestOrganizationVendors(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	vendor, err := f.vendors.CreateVendor(ctx, VendorInput{Name: "Sysco"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.vendors.SaveOrganizationVendor(ctx, f.OrgId, 1000, OrganizationVendorInput{}); !errors.Is(err, VendorNotFoundError) {
+		t.Errorf("SaveOrganizationVendor(unknown vendor) = _, %v. want %s", err, VendorNotFoundError)
+	}
+
+	invalidDays := OrganizationVendorInput{DeliveryDays: []string{"Funday"}}
+
+	if _, err := f.vendors.SaveOrganizationVendor(ctx, f.OrgId, vendor.Id, invalidDays); !errors.Is(err, InvalidVendorError) {
+		t.Errorf("SaveOrganizationVendor(invalid days) = _, %v. want %s", err, InvalidVendorError)
+	}
+
+	linked, err := f.vendors.SaveOrganizationVendor(ctx, f.OrgId, vendor.Id, OrganizationVendorInput{
+		AccountNumber: "A-100",
+		ContactName:   "Sam",
+		DeliveryDays:  []string{"Friday", "monday", "friday"},
+	})
+
+	if err != nil {
+		t.Fatalf("SaveOrganizationVendor() = _, %s. want nil", err)
+	}
+
+	if want := []string{"monday", "friday"}; !slices.Equal(linked.DeliveryDays, want) {
+		t.Errorf("SaveOrganizationVendor().DeliveryDays = %v. want %v", linked.DeliveryDays, want)
+	}
+
+	if linked.Name != "Sysco" || linked.AccountNumber != "A-100" {
+		t.Errorf("SaveOrganizationVendor() = %v. want Sysco account A-100", linked)
+	}
+
+	relinked, err := f.vendors.SaveOrganizationVendor(ctx, f.OrgId, vendor.Id, OrganizationVendorInput{AccountNumber: "A-200"})
+
+	if err != nil {
+		t.Fatalf("SaveOrganizationVendor(existing) = _, %s. want nil", err)
+	}
+
+	if relinked.AccountNumber != "A-200" || len(relinked.DeliveryDays) != 0 {
+		t.Errorf("SaveOrganizationVendor(existing) = %v. want account A-200 and no delivery days", relinked)
+	}
+
+	if _, err := f.vendors.GetOrganizationVendor(ctx, f.OtherOrgId, vendor.Id); !errors.Is(err, OrganizationVendorNotFoundError) {
+		t.Errorf("GetOrganizationVendor(other org) = _, %v. want %s", err, OrganizationVendorNotFoundError)
+	}
+
+	vendors, err := f.vendors.ListOrganizationVendors(ctx, f.OrgId)
+
+	if err != nil {
+		t.Fatalf("ListOrganizationVendors() = _, %s. want nil", err)
+	}
+
+	if len(vendors) != 1 {
+		t.Errorf("len(ListOrganizationVendors()) = %d. want 1", len(vendors))
+	}
+
+	if err := f.vendors.RemoveOrganizationVendor(ctx, f.OrgId, vendor.Id); err != nil {
+		t.Errorf("RemoveOrganizationVendor() = %s. want nil", err)
+	}
+
+	if err := f.vendors.RemoveOrganizationVendor(ctx, f.OrgId, vendor.Id); !errors.Is(err, OrganizationVendorNotFoundError) {
+		t.Errorf("RemoveOrganizationVendor(removed) = %v. want %s", err, OrganizationVendorNotFoundError)
+	}
+}
+
+func TestCreateOrganizationVendor(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	invalidDays := OrganizationVendorInput{DeliveryDays: []string{"Funday"}}
+
+	if _, err := f.vendors.CreateOrganizationVendor(ctx, f.OrgId, VendorInput{Name: "Sysco"}, invalidDays); !errors.Is(err, InvalidVendorError) {
+		t.Errorf("CreateOrganizationVendor(invalid days) = _, %v. want %s", err, InvalidVendorError)
+	}
+
+	if vendors, _ := f.vendors.ListVendors(ctx); len(vendors) != 0 {
+		t.Errorf("CreateOrganizationVendor(invalid days) created %v. want no vendor", vendors)
+	}
+
+	// the vendor is rolled back when it cannot be linked
+	if _, err := f.vendors.CreateOrganizationVendor(ctx, 9999, VendorInput{Name: "Sysco"}, OrganizationVendorInput{}); err == nil {
+		t.Errorf("CreateOrganizationVendor(unknown organization) = _, nil. want error")
+	}
+
+	if vendors, _ := f.vendors.ListVendors(ctx); len(vendors) != 0 {
+		t.Errorf("CreateOrganizationVendor(unknown organization) created %v. want no vendor", vendors)
+	}
+
+	linked, err := f.vendors.CreateOrganizationVendor(ctx, f.OrgId, VendorInput{Name: "Sysco"}, OrganizationVendorInput{AccountNumber: "A-100"})
+
+	if err != nil {
+		t.Fatalf("CreateOrganizationVendor() = _, %s. want nil", err)
+	}
+
+	if linked.Name != "Sysco" || linked.OrganizationId != f.OrgId || linked.AccountNumber != "A-100" {
+		t.Errorf("CreateOrganizationVendor() = %v. want Sysco linked with account A-100", linked)
+	}
+
+	// another organization links the existing vendor instead of creating it
+	if _, err := f.vendors.CreateOrganizationVendor(ctx, f.OtherOrgId, VendorInput{Name: "sysco"}, OrganizationVendorInput{}); !errors.Is(err, VendorExistsError) {
+		t.Errorf("CreateOrganizationVendor(duplicate) = _, %v. want %s", err, VendorExistsError)
+	}
+}
+
+func TestIngredientVendors(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	sysco, err := f.vendors.CreateVendor(ctx, VendorInput{Name: "Sysco"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usFoods, err := f.vendors.CreateVendor(ctx, VendorInput{Name: "US Foods"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vendorId := range []int{sysco.Id, usFoods.Id} {
+		if _, err := f.vendors.SaveOrganizationVendor(ctx, f.OrgId, vendorId, OrganizationVendorInput{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unlinked := ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1, PurchasePrice: 100, VendorId: &sysco.Id}
+
+	if _, err := f.ingredients.CreateIngredient(ctx, f.OtherOrgId, unlinked); !errors.Is(err, ingredient.InvalidIngredientError) {
+		t.Errorf("CreateIngredient(unlinked vendor) = _, %v. want %s", err, ingredient.InvalidIngredientError)
+	}
+
+	unknownBrand := 1000
+	branded := ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1, PurchasePrice: 100, BrandId: &unknownBrand}
+
+	if _, err := f.ingredients.CreateIngredient(ctx, f.OrgId, branded); !errors.Is(err, ingredient.InvalidIngredientError) {
+		t.Errorf("CreateIngredient(unknown brand) = _, %v. want %s", err, ingredient.InvalidIngredientError)
+	}
+
+	inputs := []ingredient.IngredientInput{
+		{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200, VendorId: &sysco.Id},
+		{Name: "Sugar", Unit: "g", UnitCount: 1000, PurchasePrice: 300, VendorId: &sysco.Id},
+		{Name: "Milk", Unit: "l", UnitCount: 4, PurchasePrice: 1000, VendorId: &usFoods.Id},
+		{Name: "Eggs", Unit: "each", UnitCount: 12, PurchasePrice: 50},
+	}
+
+	for _, input := range inputs {
+		if _, err := f.ingredients.CreateIngredient(ctx, f.OrgId, input); err != nil {
+			t.Fatalf("CreateIngredient(%s) = _, %s. want nil", input.Name, err)
+		}
+	}
+
+	filtered, err := f.ingredients.ListIngredients(ctx, f.OrgId, ingredient.Filter{VendorId: sysco.Id})
+
+	if err != nil {
+		t.Fatalf("ListIngredients(vendor) = _, %s. want nil", err)
+	}
+
+	if len(filtered) != 2 || *filtered[0].VendorId != sysco.Id {
+		t.Errorf("ListIngredients(vendor) = %v. want Flour and Sugar", filtered)
+	}
+}
+
+func TestSpend(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFixture(t)
+
+	january := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	sysco, err := f.vendors.CreateOrganizationVendor(ctx, f.OrgId, VendorInput{Name: "Sysco"}, OrganizationVendorInput{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usFoods, err := f.vendors.CreateOrganizationVendor(ctx, f.OrgId, VendorInput{Name: "US Foods"}, OrganizationVendorInput{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := f.locations.CreateLocation(ctx, f.OrgId, organization.LocationInput{Address: "1 Main St"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.ingredients.Now = common.TestNow{Time: january}
+
+	created := map[string]int{}
+
+	for _, input := range []ingredient.IngredientInput{
+		{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 200, VendorId: &sysco.VendorId},
+		{Name: "Sugar", Unit: "g", UnitCount: 1000, PurchasePrice: 300, VendorId: &sysco.VendorId},
+		{Name: "Milk", Unit: "l", UnitCount: 4, PurchasePrice: 1000, VendorId: &usFoods.VendorId},
+		{Name: "Eggs", Unit: "each", UnitCount: 12, PurchasePrice: 50},
+	} {
+		ingredient, err := f.ingredients.CreateIngredient(ctx, f.OrgId, input)
+
+		if err != nil {
+			t.Fatalf("CreateIngredient(%s) = _, %s. want nil", input.Name, err)
+		}
+
+		created[input.Name] = ingredient.Id
+	}
+
+	// flour doubles in March
+	if _, err := f.ingredients.RecordPrice(ctx, f.OrgId, created["Flour"], ingredient.PriceInput{PurchasePrice: 400, EffectiveAt: march}); err != nil {
+		t.Fatal(err)
+	}
+
+	purchases := []inventory.PurchaseInput{
+		// $4.00 at February's price
+		{IngredientId: created["Flour"], Quantity: 2, Unit: "kg", PurchasedAt: february},
+		// $4.00 at April's price
+		{IngredientId: created["Flour"], Quantity: 1000, PurchasedAt: april},
+		{IngredientId: created["Sugar"], Quantity: 500, PurchasedAt: february},
+		{IngredientId: created["Milk"], Quantity: 8, PurchasedAt: february},
+		{IngredientId: created["Eggs"], Quantity: 24, PurchasedAt: february},
+		// a vendor and price paid given on the invoice
+		{IngredientId: created["Eggs"], VendorId: usFoods.VendorId, Quantity: 12, Price: 150, PurchasedAt: february},
+	}
+
+	for _, purchase := range purchases {
+		purchase.LocationId = location.Id
+
+		if _, err := f.inventory.RecordPurchase(ctx, f.OrgId, purchase); err != nil {
+			t.Fatalf("RecordPurchase() = _, %s. want nil", err)
+		}
+	}
+
+	// switching vendors leaves past purchases with Sysco
+	flour := ingredient.IngredientInput{Name: "Flour", Unit: "g", UnitCount: 1000, PurchasePrice: 400, VendorId: &usFoods.VendorId}
+
+	if _, err := f.ingredients.UpdateIngredient(ctx, f.OrgId, created["Flour"], flour); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		test     string
+		filter   SpendFilter
+		expected []VendorSpend
+	}{
+		{
+			test: "all purchases",
+			expected: []VendorSpend{
+				{VendorId: usFoods.VendorId, Name: "US Foods", Ingredients: 2, Purchases: 2, Spend: 2150},
+				{VendorId: sysco.VendorId, Name: "Sysco", Ingredients: 2, Purchases: 3, Spend: 950},
+				{VendorId: 0, Name: "", Ingredients: 1, Purchases: 1, Spend: 100},
+			},
+		},
+		{
+			test:   "from march",
+			filter: SpendFilter{From: march},
+			expected: []VendorSpend{
+				{VendorId: sysco.VendorId, Name: "Sysco", Ingredients: 1, Purchases: 1, Spend: 400},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			spend, err := f.vendors.Spend(ctx, f.OrgId, test.filter)
+
+			if err != nil {
+				t.Fatalf("Spend() = _, %s. want nil", err)
+			}
+
+			if !slices.Equal(spend, test.expected) {
+				t.Errorf("Spend() = %v. want %v", spend, test.expected)
+			}
+		})
+	}
+
+	spend, err := f.vendors.Spend(ctx, f.OtherOrgId, SpendFilter{})
+
+	if err != nil || len(spend) != 0 {
+		t.Errorf("Spend(other org) = %v, %v. want none", spend, err)
+	}
+}
@@ -0,0 +1,252 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	MissingKeyErr       = errors.New("required variable is not set")
+	InvalidValueErr     = errors.New("invalid value")
+	UnsupportedFieldErr = errors.New("unsupported field type")
+	InvalidTargetErr    = errors.New("decode target must be a non-nil pointer to a struct")
+)
+
+const (
+	envTag      = "env"
+	defaultTag  = "default"
+	requiredTag = "required"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldError describes why a single variable could not be decoded
+type FieldError struct {
+	Key   string
+	Field string
+	Err   error
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", f.Key, f.Field, f.Err)
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+// DecodeError collects every FieldError found while decoding so all missing
+// and invalid variables can be reported at once
+type DecodeError struct {
+	Errors []FieldError
+}
+
+func (d *DecodeError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("%d invalid environment variable(s):", len(d.Errors)))
+
+	for _, err := range d.Errors {
+		b.WriteString("\n  ")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+func (d *DecodeError) Unwrap() []error {
+	errs := make([]error, len(d.Errors))
+
+	for i, err := range d.Errors {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// Layer builds an Env from the files at paths followed by the process
+// environment. Later files take precedence over earlier ones and the process
+// environment takes precedence over every file. Files that do not exist are
+// skipped. Unlike Load, the process environment is not modified
+func Layer(paths ...string) (Env, error) {
+	env := Env{}
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return env, err
+		}
+
+		err = env.Read(file)
+		file.Close()
+
+		if err != nil {
+			return env, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := env.AddEnviron(os.Environ()); err != nil {
+		return env, err
+	}
+
+	return env, nil
+}
+
+// Decode populates the struct pointed to by v using its field tags.
+//
+//	Port int `env:"PORT" default:"8080" required:"true"`
+//
+// Supported field types are strings, ints, uints, floats, bools,
+// time.Duration and string slices (comma separated). Nested struct fields
+// without an env tag are decoded recursively. A variable set to an empty
+// value is set and decodes to the zero value, so it overrides the default
+// and any lower layer. Required variables must not be empty. Every missing
+// or invalid variable is returned in a single *DecodeError
+func (e Env) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return InvalidTargetErr
+	}
+
+	decodeErr := &DecodeError{}
+
+	e.decodeStruct(rv.Elem(), decodeErr)
+
+	if len(decodeErr.Errors) > 0 {
+		return decodeErr
+	}
+
+	return nil
+}
+
+func (e Env) decodeStruct(rv reflect.Value, decodeErr *DecodeError) {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		key, ok := field.Tag.Lookup(envTag)
+
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				e.decodeStruct(rv.Field(i), decodeErr)
+			}
+
+			continue
+		}
+
+		val, ok := e[key]
+
+		if !ok {
+			val, ok = field.Tag.Lookup(defaultTag)
+		}
+
+		if !ok || val == "" {
+			if field.Tag.Get(requiredTag) == "true" {
+				decodeErr.Errors = append(decodeErr.Errors, FieldError{Key: key, Field: field.Name, Err: MissingKeyErr})
+			} else if ok {
+				rv.Field(i).SetZero()
+			}
+
+			continue
+		}
+
+		if err := setField(rv.Field(i), val); err != nil {
+			decodeErr.Errors = append(decodeErr.Errors, FieldError{Key: key, Field: field.Name, Err: err})
+		}
+	}
+}
+
+func setField(field reflect.Value, val string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(val)
+
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a duration", InvalidValueErr, val)
+		}
+
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(val, 10, field.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an integer", InvalidValueErr, val)
+		}
+
+		field.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(val, 10, field.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an unsigned integer", InvalidValueErr, val)
+		}
+
+		field.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(val, field.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a number", InvalidValueErr, val)
+		}
+
+		field.SetFloat(parsed)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(val)
+
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a boolean", InvalidValueErr, val)
+		}
+
+		field.SetBool(parsed)
+
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", UnsupportedFieldErr, field.Type())
+		}
+
+		var values []string
+
+		for _, part := range strings.Split(val, ",") {
+			trimmed := strings.TrimSpace(part)
+
+			if trimmed == "" {
+				continue
+			}
+
+			values = append(values, trimmed)
+		}
+
+		field.Set(reflect.ValueOf(values).Convert(field.Type()))
+
+	default:
+		return fmt.Errorf("%w: %s", UnsupportedFieldErr, field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package env
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testDecodeNested struct {
+	Name string `env:"NESTED_NAME"`
+}
+
+type testDecodeConfig struct {
+	Port     int           `env:"PORT" default:"8080"`
+	Host     string        `env:"HOST" required:"true"`
+	Debug    bool          `env:"DEBUG"`
+	Ratio    float64       `env:"RATIO"`
+	Workers  uint8         `env:"WORKERS" default:"4"`
+	Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+	Origins  []string      `env:"ORIGINS"`
+	Ignored  string
+	Nested   testDecodeNested
+	internal string `env:"INTERNAL"`
+}
+
+func TestEnvDecode(t *testing.T) {
+	env := Env{
+		"HOST":        "localhost",
+		"DEBUG":       "true",
+		"RATIO":       "0.25",
+		"TIMEOUT":     "1m30s",
+		"ORIGINS":     "a.com, b.com,,c.com",
+		"NESTED_NAME": "nested",
+		"PORT":        "",
+		"WORKERS":     "",
+		"INTERNAL":    "internal",
+	}
+
+	var cfg testDecodeConfig
+
+	if err := env.Decode(&cfg); err != nil {
+		t.Fatalf("env.Decode() = %s. want nil", err)
+	}
+
+	// set but empty is not missing so the default is not used
+	if cfg.Port != 0 {
+		t.Errorf("cfg.Port = %d. want %d", cfg.Port, 0)
+	}
+
+	if cfg.Host != "localhost" {
+		t.Errorf("cfg.Host = %s. want %s", cfg.Host, "localhost")
+	}
+
+	if !cfg.Debug {
+		t.Errorf("cfg.Debug = false. want true")
+	}
+
+	if cfg.Ratio != 0.25 {
+		t.Errorf("cfg.Ratio = %f. want %f", cfg.Ratio, 0.25)
+	}
+
+	if cfg.Workers != 0 {
+		t.Errorf("cfg.Workers = %d. want %d", cfg.Workers, 0)
+	}
+
+	if cfg.Timeout != time.Second*90 {
+		t.Errorf("cfg.Timeout = %s. want %s", cfg.Timeout, time.Second*90)
+	}
+
+	origins := []string{"a.com", "b.com", "c.com"}
+
+	if !slices.Equal(cfg.Origins, origins) {
+		t.Errorf("cfg.Origins = %v. want %v", cfg.Origins, origins)
+	}
+
+	if cfg.Nested.Name != "nested" {
+		t.Errorf("cfg.Nested.Name = %s. want %s", cfg.Nested.Name, "nested")
+	}
+
+	if cfg.internal != "" {
+		t.Errorf("cfg.internal = %s. want unexported field to be ignored", cfg.internal)
+	}
+}
+
+func TestEnvDecodeReportsAllErrors(t *testing.T) {
+	env := Env{
+		"PORT":    "eighty",
+		"DEBUG":   "maybe",
+		"TIMEOUT": "5",
+	}
+
+	var cfg testDecodeConfig
+
+	err := env.Decode(&cfg)
+
+	var decodeErr *DecodeError
+
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("env.Decode() = %v. want *DecodeError", err)
+	}
+
+	keys := []string{}
+
+	for _, fieldErr := range decodeErr.Errors {
+		keys = append(keys, fieldErr.Key)
+	}
+
+	expected := []string{"PORT", "HOST", "DEBUG", "TIMEOUT"}
+
+	if !slices.Equal(keys, expected) {
+		t.Errorf("DecodeError keys = %v. want %v", keys, expected)
+	}
+
+	if !errors.Is(err, MissingKeyErr) {
+		t.Errorf("env.Decode() = %s. want error to wrap %s", err, MissingKeyErr)
+	}
+
+	if !errors.Is(err, InvalidValueErr) {
+		t.Errorf("env.Decode() = %s. want error to wrap %s", err, InvalidValueErr)
+	}
+}
+
+func TestEnvDecodeInvalidTarget(t *testing.T) {
+	env := Env{}
+
+	var cfg testDecodeConfig
+	var nilCfg *testDecodeConfig
+	str := ""
+
+	targets := []any{cfg, nilCfg, &str, nil}
+
+	for _, target := range targets {
+		if err := env.Decode(target); !errors.Is(err, InvalidTargetErr) {
+			t.Errorf("env.Decode(%T) = %v. want %s", target, err, InvalidTargetErr)
+		}
+	}
+}
+
+func TestEnvLayer(t *testing.T) {
+	t.Setenv("LAYER_PROCESS", "process")
+
+	env, err := Layer(
+		"./test-fixtures/layer.env",
+		"./test-fixtures/layer.local.env",
+		"./test-fixtures/does-not-exist.env",
+	)
+
+	if err != nil {
+		t.Fatalf("Layer() = _, %s. want nil", err)
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"LAYER_BASE", "base"},
+		{"LAYER_OVERRIDE", "local"},
+		{"LAYER_PROCESS", "process"},
+		{"LAYER_CLEARED", ""},
+	}
+
+	for _, test := range tests {
+		if env[test.key] != test.expected {
+			t.Errorf("env[%s] = %s. want %s", test.key, env[test.key], test.expected)
+		}
+	}
+
+	var cfg struct {
+		Cleared string `env:"LAYER_CLEARED" default:"default"`
+	}
+
+	if err := env.Decode(&cfg); err != nil {
+		t.Fatalf("env.Decode() = %s. want nil", err)
+	}
+
+	// emptied by a higher layer so neither the lower layer nor the default applies
+	if cfg.Cleared != "" {
+		t.Errorf("cfg.Cleared = %s. want it empty", cfg.Cleared)
+	}
+}
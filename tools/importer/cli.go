@@ -0,0 +1,188 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"moon-cost/services/ingredient"
+	"os"
+	"text/tabwriter"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// ImportCLI imports a CSV file of ingredients or invoice prices into an
+// organization
+//
+//	moon import -db moon.db -org 1 [-kind invoice] [-map name=Item] [-dry-run] file.csv
+type ImportCLI struct {
+	Out io.Writer
+
+	dbFilename string
+	org        int
+	kind       string
+	mapping    string
+	source     string
+	dryRun     bool
+	json       bool
+	file       string
+}
+
+const (
+	DBFlagDescription      = "SQLite file to import into"
+	OrgFlagDescription     = "Id of the organization to import into"
+	KindFlagDescription    = "Kind of file: catalog or invoice"
+	MapFlagDescription     = "Columns of each field, like name=Item,purchasePrice=Case Price"
+	SourceFlagDescription  = "Source recorded on imported prices, like an invoice number"
+	DryRunFlagDescription  = "Validates the file and prints what would be imported without saving"
+	JSONOutFlagDescription = "Prints the result as json"
+)
+
+func (i *ImportCLI) parse(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+
+	fs.StringVar(&i.dbFilename, "db", "", DBFlagDescription)
+	fs.IntVar(&i.org, "org", 0, OrgFlagDescription)
+	fs.StringVar(&i.kind, "kind", string(ingredient.ImportCatalog), KindFlagDescription)
+	fs.StringVar(&i.mapping, "map", "", MapFlagDescription)
+	fs.StringVar(&i.source, "source", "", SourceFlagDescription)
+	fs.BoolVar(&i.dryRun, "dry-run", false, DryRunFlagDescription)
+	fs.BoolVar(&i.json, "json", false, JSONOutFlagDescription)
+
+	fs.Parse(args)
+
+	if i.dbFilename == "" {
+		return fmt.Errorf("Error: db flag required")
+	}
+
+	if i.org == 0 {
+		return fmt.Errorf("Error: org flag required")
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("Error: one CSV file required, use - to read stdin")
+	}
+
+	i.file = fs.Arg(0)
+
+	return nil
+}
+
+func (i *ImportCLI) input() (ingredient.ImportInput, error) {
+	mapping, err := ingredient.ParseMapping(i.mapping)
+
+	if err != nil {
+		return ingredient.ImportInput{}, err
+	}
+
+	return ingredient.ImportInput{
+		Kind:    ingredient.ImportKind(i.kind),
+		Mapping: mapping,
+		Source:  i.source,
+		DryRun:  i.dryRun,
+	}, nil
+}
+
+func (i *ImportCLI) Command(ctx context.Context, args []string) error {
+	if err := i.parse(args); err != nil {
+		return err
+	}
+
+	if i.Out == nil {
+		i.Out = os.Stdout
+	}
+
+	input, err := i.input()
+
+	if err != nil {
+		return err
+	}
+
+	var file io.Reader = os.Stdin
+
+	if i.file != "-" {
+		opened, err := os.Open(i.file)
+
+		if err != nil {
+			return err
+		}
+
+		defer opened.Close()
+
+		file = opened
+	}
+
+	db, err := sql.Open("libsql", fmt.Sprintf("file:%s", i.dbFilename))
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	service := ingredient.NewService(ingredient.NewSQLiteRepo(db), slog.Default())
+
+	result, err := service.Import(ctx, i.org, input, file)
+
+	if err != nil && !errors.Is(err, ingredient.ImportRowsError) {
+		return err
+	}
+
+	if printErr := i.print(result); printErr != nil {
+		return printErr
+	}
+
+	return err
+}
+
+func (i *ImportCLI) print(result ingredient.ImportResult) error {
+	if i.json {
+		encoder := json.NewEncoder(i.Out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(result)
+	}
+
+	w := tabwriter.NewWriter(i.Out, 0, 0, 2, ' ', 0)
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(w, "LINE\tCOLUMN\tERROR")
+
+		for _, rowErr := range result.Errors {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", rowErr.Line, rowErr.Column, rowErr.Message)
+		}
+
+		fmt.Fprintf(w, "\n%d invalid rows, nothing imported\n", len(result.Errors))
+
+		return w.Flush()
+	}
+
+	fmt.Fprintln(w, "LINE\tACTION\tINGREDIENT\tPRICE")
+
+	for _, row := range result.Rows {
+		price := row.Ingredient.PurchasePrice
+		unitCount, unit := row.Ingredient.UnitCount, row.Ingredient.Unit
+
+		if row.Price != nil {
+			price = row.Price.PurchasePrice
+			unitCount, unit = row.Price.UnitCount, row.Price.Unit
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t$%.2f for %g %s\n", row.Line, row.Action, row.Ingredient.Name, float64(price)/100, unitCount, unit)
+	}
+
+	summary := fmt.Sprintf("%d created, %d updated, %d prices", result.Created, result.Updated, result.Prices)
+
+	if result.DryRun {
+		summary += " (dry run, nothing imported)"
+	}
+
+	fmt.Fprintf(w, "\n%s\n", summary)
+
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"moon-cost/moontest"
+	"moon-cost/services/ingredient"
+	"moon-cost/tools/migration"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Creates a migrated database file with one organization
+func newTestDB(t *testing.T) (string, int) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("libsql", "file:"+path)
+
+	if err != nil {
+		t.Fatalf("sql.Open() = _, %s. want nil", err)
+	}
+
+	defer db.Close()
+
+	manager := migration.Manager{Dir: filepath.Join("..", "..", "migrations"), DB: db}
+	manager.Init(migration.WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	return path, moontest.InsertTestOrganization(t, db, "Moon Cafe")
+}
+
+func writeCSV(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "import.csv")
+
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("os.WriteFile() = %s. want nil", err)
+	}
+
+	return path
+}
+
+func TestImportCLI(t *testing.T) {
+	ctx := context.Background()
+	db, orgId := newTestDB(t)
+	org := strconv.Itoa(orgId)
+
+	file := writeCSV(t, "Item,unit,unitCount,purchasePrice\nFlour,lb,50,$20.00\n")
+
+	var out bytes.Buffer
+	cli := ImportCLI{Out: &out}
+
+	err := cli.Command(ctx, []string{"-db", db, "-org", org, "-map", "name=Item", "-dry-run", file})
+
+	if err != nil {
+		t.Fatalf("Command(dry run) = %s. want nil", err)
+	}
+
+	if !strings.Contains(out.String(), "1 created, 0 updated, 0 prices (dry run, nothing imported)") {
+		t.Errorf("Command(dry run) printed %q. want dry run summary", out.String())
+	}
+
+	invalid := writeCSV(t, "name,unit,unitCount,purchasePrice\nSugar,lb,none,1\n")
+	out.Reset()
+
+	cli = ImportCLI{Out: &out}
+	err = cli.Command(ctx, []string{"-db", db, "-org", org, invalid})
+
+	if !errors.Is(err, ingredient.ImportRowsError) {
+		t.Errorf("Command(invalid) = %v. want %s", err, ingredient.ImportRowsError)
+	}
+
+	if !strings.Contains(out.String(), "unitCount") {
+		t.Errorf("Command(invalid) printed %q. want the row error", out.String())
+	}
+}
+
+func TestImportCLIErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		test string
+		args []string
+	}{
+		{"missing db", []string{"-org", "1", "file.csv"}},
+		{"missing org", []string{"-db", "test.db", "file.csv"}},
+		{"missing file", []string{"-db", "test.db", "-org", "1"}},
+		{"bad mapping", []string{"-db", "test.db", "-org", "1", "-map", "name", "file.csv"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			cli := ImportCLI{Out: &bytes.Buffer{}}
+
+			if err := cli.Command(ctx, test.args); err == nil {
+				t.Errorf("Command() = nil. want error")
+			}
+		})
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"moon-cost/common"
+	"os"
 )
 
 type MigrationCLI struct {
+	Out io.Writer
+
 	verbose  bool
 	suppress bool
 	logger   *slog.Logger
@@ -50,6 +54,15 @@ func (mcli *MigrationCLI) Command(ctx context.Context, args []string) error {
 	case "run":
 		return mcli.Run(ctx, flags)
 
+	case "rollback":
+		return mcli.Rollback(ctx, flags)
+
+	case "status":
+		return mcli.Status(ctx, flags)
+
+	case "repair":
+		return mcli.Repair(ctx, flags)
+
 	default:
 		return fmt.Errorf("Invalid command: %s", command)
 	}
@@ -79,6 +92,51 @@ func (mcli *MigrationCLI) Run(ctx context.Context, args []string) error {
 	return runCli.Command(ctx)
 }
 
+func (mcli *MigrationCLI) Rollback(ctx context.Context, args []string) error {
+	rollbackCli := rollbackCli{cli: mcli}
+
+	if err := rollbackCli.Init(args); err != nil {
+		return err
+	}
+
+	mcli.init()
+
+	return rollbackCli.Command(ctx)
+}
+
+func (mcli *MigrationCLI) Status(ctx context.Context, args []string) error {
+	statusCli := statusCli{cli: mcli}
+
+	if err := statusCli.Init(args); err != nil {
+		return err
+	}
+
+	mcli.init()
+
+	return statusCli.Command(ctx)
+}
+
+func (mcli *MigrationCLI) Repair(ctx context.Context, args []string) error {
+	repairCli := repairCli{cli: mcli}
+
+	if err := repairCli.Init(args); err != nil {
+		return err
+	}
+
+	mcli.init()
+
+	return repairCli.Command(ctx)
+}
+
+// Returns where command output is written, stdout unless Out is set
+func (mcli *MigrationCLI) out() io.Writer {
+	if mcli.Out == nil {
+		return os.Stdout
+	}
+
+	return mcli.Out
+}
+
 func (mcli *MigrationCLI) parseUniversalFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&mcli.verbose, "v", false, "Verbose")
 
@@ -2,6 +2,7 @@ package migration
 
 import (
 	"context"
+	"log/slog"
 	"moon-cost/common"
 	"os"
 	"path/filepath"
@@ -47,6 +48,12 @@ func TestCreateCLI(t *testing.T) {
 		if statName != fileName {
 			t.Errorf("created filename is %s. want %s", statName, fileName)
 		}
+
+		contents, _ := os.ReadFile(path)
+
+		if string(contents) != migrationTemplate {
+			t.Errorf("created file contents = %q. want %q", contents, migrationTemplate)
+		}
 	}
 }
 
@@ -79,3 +86,37 @@ func TestCreateCliErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestRollbackCLI(t *testing.T) {
+	ctx := context.Background()
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+
+	manager := Manager{Dir: writeTestFiles(t, rollbackTestFiles), DB: openTestDB(t, dbFile)}
+	manager.Init(WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	cli := MigrationCLI{suppress: true}
+
+	if err := cli.Command(ctx, []string{"rollback", "--db", dbFile, "--steps", "1", "--to", "1000"}); err == nil {
+		t.Errorf("rollback with steps and to = nil. want error")
+	}
+
+	if err := cli.Command(ctx, []string{"rollback", "--db", dbFile}); err != nil {
+		t.Fatalf("rollback = %s. want nil", err)
+	}
+
+	if tableExists(t, manager.DB, "third") {
+		t.Errorf("rollback should drop third")
+	}
+
+	if err := cli.Command(ctx, []string{"rollback", "--db", dbFile, "--to", "1000"}); err != nil {
+		t.Fatalf("rollback --to 1000 = %s. want nil", err)
+	}
+
+	if !tableExists(t, manager.DB, "first") || tableExists(t, manager.DB, "second") {
+		t.Errorf("rollback --to 1000 should keep only first")
+	}
+}
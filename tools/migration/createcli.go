@@ -14,6 +14,9 @@ type createCli struct {
 	cli  *MigrationCLI
 }
 
+// Written to new migration files so the down section is not forgotten
+const migrationTemplate = UpMarker + "\n\n" + DownMarker + "\n"
+
 const (
 	MigrationNameDescription = "Name of migration"
 	MigrationDirDescription  = "Directory to store migration in"
@@ -65,7 +68,7 @@ func (c *createCli) run() error {
 
 	c.cli.logger.Debug("Creating migration file", "path", path)
 
-	_, err = os.Create(path)
+	err = os.WriteFile(path, []byte(migrationTemplate), 0644)
 
 	if err != nil {
 		return fmt.Errorf("Error creating file %w", err)
@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ChecksumMismatchError = errors.New("Migration file changed after it was applied")
+
+// Returns ChecksumMismatchError with a diff of the applied instruction and the
+// file when the file no longer matches what was applied
+func checkChecksum(applied, file Migration) error {
+	if applied.Checksum == file.Checksum {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: %s. Run repair to accept the change\n--- applied\n+++ %s\n%s",
+		ChecksumMismatchError,
+		file.Filename,
+		file.Filename,
+		diffLines(applied.Instruction, file.Instruction),
+	)
+}
+
+// Lists the lines removed from a with a - and the lines added in b with a +,
+// in order. Lines in both are left out
+func diffLines(a, b string) string {
+	before := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	after := strings.Split(strings.TrimRight(b, "\n"), "\n")
+
+	// common[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			fmt.Fprintf(&diff, "-%s\n", before[i])
+			i++
+		default:
+			fmt.Fprintf(&diff, "+%s\n", after[j])
+			j++
+		}
+	}
+
+	return strings.TrimRight(diff.String(), "\n")
+}
+
+const repairMigrationQuery = `UPDATE %s SET instruction = ?, checksum = ? WHERE id = ?;`
+
+// Accepts changes to the files of applied migrations by storing their current
+// instruction and checksum. Nothing is run. Returns the repaired migrations
+func (m *Manager) Repair(ctx context.Context) ([]Migration, error) {
+	applied, err := m.appliedMigrations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := m.inspectDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	byCreated := map[int64]Migration{}
+
+	for _, file := range files {
+		byCreated[file.Created.UnixMilli()] = file
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var repaired []Migration
+
+	for _, a := range applied {
+		file, ok := byCreated[a.Created.UnixMilli()]
+
+		if !ok || file.Name != a.Name || file.Checksum == a.Checksum {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, m.formatQuery(repairMigrationQuery), file.Instruction, file.Checksum, a.Id); err != nil {
+			return nil, fmt.Errorf("Error repairing migration %s: %w", file.Filename, err)
+		}
+
+		m.logger.Info("Migration repaired", "file", file.Filename)
+
+		a.Instruction = file.Instruction
+		a.Checksum = file.Checksum
+		repaired = append(repaired, a)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return repaired, nil
+}
@@ -0,0 +1,97 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		test     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{"changed line", "a\nb\nc", "a\nB\nc", "-b\n+B"},
+		{"added line", "a\nc", "a\nb\nc", "+b"},
+		{"removed line", "a\nb\nc", "a\nc", "-b"},
+		{"trailing newline ignored", "a\n", "a", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			if actual := diffLines(test.a, test.b); actual != test.expected {
+				t.Errorf("diffLines() = %q. want %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestChecksumDrift(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, rollbackTestFiles)
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	changed := strings.Replace(rollbackTestFiles["2000.second.sql"], "DROP TABLE second;", "DROP TABLE IF EXISTS second;", 1)
+
+	if err := os.WriteFile(filepath.Join(manager.Dir, "2000.second.sql"), []byte(changed), 0644); err != nil {
+		t.Fatalf("os.WriteFile() = %s. want nil", err)
+	}
+
+	err := manager.Run(ctx)
+
+	if !errors.Is(err, ChecksumMismatchError) {
+		t.Fatalf("Run(changed file) = %v. want %s", err, ChecksumMismatchError)
+	}
+
+	if !strings.Contains(err.Error(), "-DROP TABLE second;\n+DROP TABLE IF EXISTS second;") {
+		t.Errorf("Run(changed file) error does not show the diff:\n%s", err)
+	}
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status() = _, %s. want nil", err)
+	}
+
+	if statuses[1].State != StateChanged {
+		t.Errorf("Status()[1].State = %s. want %s", statuses[1].State, StateChanged)
+	}
+
+	repaired, err := manager.Repair(ctx)
+
+	if err != nil {
+		t.Fatalf("Repair() = _, %s. want nil", err)
+	}
+
+	if len(repaired) != 1 || repaired[0].Name != "second" {
+		t.Errorf("Repair() = %v. want only second", repaired)
+	}
+
+	if err := manager.Run(ctx); err != nil {
+		t.Errorf("Run(repaired) = %s. want nil", err)
+	}
+
+	// rolling back uses the repaired down section
+	applied, err := manager.getAllMigrations(ctx)
+
+	if err != nil {
+		t.Fatalf("getAllMigrations() = _, %s. want nil", err)
+	}
+
+	if down := applied[1].Down(); down != "DROP TABLE IF EXISTS second;" {
+		t.Errorf("repaired Down() = %q. want %q", down, "DROP TABLE IF EXISTS second;")
+	}
+
+	if repaired, _ := manager.Repair(ctx); len(repaired) != 0 {
+		t.Errorf("Repair(again) = %v. want none", repaired)
+	}
+}
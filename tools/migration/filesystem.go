@@ -1,15 +1,17 @@
 package migration
 
 import (
-	"os"
-	"path/filepath"
+	"io/fs"
 	"sort"
 )
 
+// The go file embedding the migrations lives next to them
+const embedFilename = "embed.go"
+
 func (m *Manager) inspectDir() ([]Migration, error) {
 	m.logger.Debug("Inspecting dir for migration files", "dir", m.Dir)
 
-	entries, err := os.ReadDir(m.Dir)
+	entries, err := fs.ReadDir(m.FS, ".")
 
 	if err != nil {
 		return nil, err
@@ -36,7 +38,7 @@ func (m *Manager) inspectDir() ([]Migration, error) {
 	return migrations, nil
 }
 
-func (m *Manager) inspectFile(entry os.DirEntry) (Migration, bool, error) {
+func (m *Manager) inspectFile(entry fs.DirEntry) (Migration, bool, error) {
 	var migration Migration
 	filename := entry.Name()
 
@@ -45,6 +47,11 @@ func (m *Manager) inspectFile(entry os.DirEntry) (Migration, bool, error) {
 		return migration, false, nil
 	}
 
+	if filename == embedFilename {
+		m.logger.Debug("Ignoring embed file", "file", filename)
+		return migration, false, nil
+	}
+
 	parsed, err := parseMigrationName(filename)
 
 	if err != nil {
@@ -64,36 +71,23 @@ func (m *Manager) inspectFile(entry os.DirEntry) (Migration, bool, error) {
 	}
 
 	migration.Instruction = contents
+	migration.Checksum = checksum(contents)
 
 	return migration, true, nil
 }
 
-func (m *Manager) readFile(entry os.DirEntry) (string, error) {
+func (m *Manager) readFile(entry fs.DirEntry) (string, error) {
 	filename := entry.Name()
-	file, err := os.Open(filepath.Join(m.Dir, entry.Name()))
-
-	if err != nil {
-		return "", err
-	}
-
-	info, err := entry.Info()
-
-	if err != nil {
-		return "", err
-	}
-
-	size := info.Size()
-	bytes := make([]byte, size)
 
 	m.logger.Debug("Reading migration file", "file", filename)
 
-	n, err := file.Read(bytes)
+	contents, err := fs.ReadFile(m.FS, filename)
 
 	if err != nil {
 		return "", err
 	}
 
-	m.logger.Debug("Read migration file", "file", filename, "bytes", n)
+	m.logger.Debug("Read migration file", "file", filename, "bytes", len(contents))
 
-	return string(bytes), nil
+	return string(contents), nil
 }
@@ -0,0 +1,103 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInspectFS(t *testing.T) {
+	files := fstest.MapFS{
+		"embed.go":          {Data: []byte("package migrations")},
+		"nested/5000.x.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	for name, contents := range rollbackTestFiles {
+		files[name] = &fstest.MapFile{Data: []byte(contents)}
+	}
+
+	manager := Manager{FS: files}
+	manager.Init(WithLogger(slog.New(slog.DiscardHandler)))
+
+	migrations, err := manager.inspectDir()
+
+	if err != nil {
+		t.Fatalf("inspectDir() = _, %s. want nil", err)
+	}
+
+	expected := []string{"1000.first.sql", "2000.second.sql", "3000.third.sql"}
+
+	if len(migrations) != len(expected) {
+		t.Fatalf("len(inspectDir()) = %d. want %d", len(migrations), len(expected))
+	}
+
+	for i, filename := range expected {
+		migration := migrations[i]
+
+		if migration.Filename != filename {
+			t.Errorf("inspectDir()[%d].Filename = %s. want %s", i, migration.Filename, filename)
+		}
+
+		if migration.Instruction != rollbackTestFiles[filename] {
+			t.Errorf("inspectDir()[%d].Instruction = %q. want %q", i, migration.Instruction, rollbackTestFiles[filename])
+		}
+
+		if migration.Checksum != checksum(rollbackTestFiles[filename]) {
+			t.Errorf("inspectDir()[%d].Checksum = %s. want the checksum of the file", i, migration.Checksum)
+		}
+	}
+
+	files["notes.txt"] = &fstest.MapFile{Data: []byte("not a migration")}
+
+	if _, err := manager.inspectDir(); !errors.Is(err, InvalidFileTypeError) {
+		t.Errorf("inspectDir(notes.txt) = _, %v. want %s", err, InvalidFileTypeError)
+	}
+
+	delete(files, "notes.txt")
+	files["4000.fourth.go"] = &fstest.MapFile{Data: []byte("package migrations")}
+
+	if _, err := manager.inspectDir(); !errors.Is(err, InvalidFileTypeError) {
+		t.Errorf("inspectDir(4000.fourth.go) = _, %v. want %s", err, InvalidFileTypeError)
+	}
+}
+
+func TestRunFS(t *testing.T) {
+	ctx := context.Background()
+	files := fstest.MapFS{}
+
+	for name, contents := range rollbackTestFiles {
+		files[name] = &fstest.MapFile{Data: []byte(contents)}
+	}
+
+	manager := Manager{
+		FS: files,
+		DB: openTestDB(t, filepath.Join(t.TempDir(), "test.db")),
+	}
+
+	manager.Init(WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	for _, table := range []string{"first", "second", "third", "third_items"} {
+		if !tableExists(t, manager.DB, table) {
+			t.Errorf("Run() did not create table %s", table)
+		}
+	}
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status() = _, %s. want nil", err)
+	}
+
+	for i, status := range statuses {
+		if status.State != StateApplied {
+			t.Errorf("Status()[%d].State = %s. want %s", i, status.State, StateApplied)
+		}
+	}
+}
@@ -4,18 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"moon-cost/assert"
+	"moon-cost/common"
+	"os"
 )
 
 const DEFAULT_TABLE_NAME = "migrations"
 
 type Manager struct {
-	Dir   string
+	// Directory of migration files. Only used when FS is nil
+	Dir string
+	// Migration files at the root of FS, such as an embed.FS. Defaults to Dir
+	FS    fs.FS
 	Table string
 	DB    *sql.DB
 
 	logger *slog.Logger
+	now    common.Now
 }
 
 type MigrationOption func(m *Manager)
@@ -62,9 +69,17 @@ func WithLogger(logger *slog.Logger) MigrationOption {
 	}
 }
 
+func WithNow(now common.Now) MigrationOption {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
 func (m *Manager) init() {
 	m.ensureLogger()
 	m.ensureTableName()
+	m.ensureNow()
+	m.ensureFS()
 }
 
 func (m *Manager) ensureLogger() {
@@ -75,6 +90,22 @@ func (m *Manager) ensureLogger() {
 	m.logger = slog.Default()
 }
 
+func (m *Manager) ensureNow() {
+	if m.now != nil {
+		return
+	}
+
+	m.now = common.TimeNow{}
+}
+
+func (m *Manager) ensureFS() {
+	if m.FS != nil {
+		return
+	}
+
+	m.FS = os.DirFS(m.Dir)
+}
+
 func (m *Manager) ensureTableName() {
 	if m.Table != "" {
 		return
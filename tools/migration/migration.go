@@ -1,6 +1,8 @@
 package migration
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -15,6 +17,63 @@ type Migration struct {
 	Name        string
 	Filename    string
 	Instruction string
+	// When the migration was applied. Zero for files not applied yet and for
+	// migrations applied before it was recorded
+	AppliedAt time.Time
+	// SHA-256 of Instruction in hex
+	Checksum string
+}
+
+func checksum(instruction string) string {
+	sum := sha256.Sum256([]byte(instruction))
+	return hex.EncodeToString(sum[:])
+}
+
+// Markers splitting a migration file into the statements that apply it and
+// the statements that revert it. A file without markers is all up
+const (
+	UpMarker   = "-- +up"
+	DownMarker = "-- +down"
+)
+
+// Up returns the statements that apply the migration
+func (m Migration) Up() string {
+	up, _ := splitSections(m.Instruction)
+	return up
+}
+
+// Down returns the statements that revert the migration. It is blank when the
+// migration can not be reverted
+func (m Migration) Down() string {
+	_, down := splitSections(m.Instruction)
+	return down
+}
+
+// Statements returns the statements of the up section in the order they run
+func (m Migration) Statements() []string {
+	return splitQueries(m.Up())
+}
+
+// Splits a migration file on its markers. Lines before any marker are part of
+// the up section
+func splitSections(instruction string) (string, string) {
+	var up, down strings.Builder
+	section := &up
+
+	for _, line := range strings.SplitAfter(instruction, "\n") {
+		switch strings.TrimSpace(line) {
+		case UpMarker:
+			section = &up
+			continue
+		case DownMarker:
+			section = &down
+			continue
+		}
+
+		section.WriteString(line)
+	}
+
+	return strings.TrimSpace(up.String()), strings.TrimSpace(down.String())
 }
 
 type MigrationByCreated []Migration
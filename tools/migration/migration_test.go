@@ -74,3 +74,32 @@ func TestParsedMalformedFileName(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrationSections(t *testing.T) {
+	tests := []struct {
+		test        string
+		instruction string
+		up          string
+		down        string
+	}{
+		{"no markers", "CREATE TABLE a (id INTEGER);\n", "CREATE TABLE a (id INTEGER);", ""},
+		{"up and down", "-- +up\nCREATE TABLE a (id INTEGER);\n\n-- +down\nDROP TABLE a;\n", "CREATE TABLE a (id INTEGER);", "DROP TABLE a;"},
+		{"down first", "-- +down\nDROP TABLE a;\n-- +up\nCREATE TABLE a (id INTEGER);", "CREATE TABLE a (id INTEGER);", "DROP TABLE a;"},
+		{"indented markers", "  -- +up  \nSELECT 1;\n\t-- +down\nSELECT 2;", "SELECT 1;", "SELECT 2;"},
+		{"empty template", migrationTemplate, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			migration := Migration{Instruction: test.instruction}
+
+			if up := migration.Up(); up != test.up {
+				t.Errorf("Up() = %q. want %q", up, test.up)
+			}
+
+			if down := migration.Down(); down != test.down {
+				t.Errorf("Down() = %q. want %q", down, test.down)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Checks the migration files against the applied migrations like Run does and
+// returns the migrations Run would apply, in order. Nothing is changed, not
+// even the migrations table
+func (m *Manager) Plan(ctx context.Context) ([]Migration, error) {
+	applied, err := m.readMigrations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := m.inspectDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pendingMigrations(applied, files)
+}
+
+// Runs the pending migrations like Run does but in a transaction that is
+// always rolled back, proving their statements are valid against the
+// database. Creating or upgrading the migrations table is rolled back with
+// them. Returns the migrations that were checked
+func (m *Manager) Verify(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Plan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		m.logger.Info("No new migrations to verify")
+		return nil, nil
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	if err := m.ensureMigrationsTableIn(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if err := m.applyMigrations(ctx, tx, pending); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Migrations verified and rolled back", "count", len(pending))
+
+	return pending, nil
+}
+
+// Writes each migration's file followed by its statements in the order they
+// run
+func writePlan(w io.Writer, migrations []Migration) error {
+	for i, migration := range migrations {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "-- %s\n", migration.Filename); err != nil {
+			return err
+		}
+
+		for _, statement := range migration.Statements() {
+			if _, err := fmt.Fprintln(w, statement); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
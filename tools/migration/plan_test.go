@@ -0,0 +1,147 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, map[string]string{"1000.first.sql": rollbackTestFiles["1000.first.sql"]})
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	for _, name := range []string{"2000.second.sql", "3000.third.sql"} {
+		if err := os.WriteFile(filepath.Join(manager.Dir, name), []byte(rollbackTestFiles[name]), 0644); err != nil {
+			t.Fatalf("os.WriteFile() = %s. want nil", err)
+		}
+	}
+
+	pending, err := manager.Plan(ctx)
+
+	if err != nil {
+		t.Fatalf("Plan() = _, %s. want nil", err)
+	}
+
+	if len(pending) != 2 || pending[0].Name != "second" || pending[1].Name != "third" {
+		t.Fatalf("Plan() = %v. want second and third", pending)
+	}
+
+	expected := []string{
+		"CREATE TABLE third (id INTEGER PRIMARY KEY);",
+		"CREATE TABLE third_items (id INTEGER PRIMARY KEY);",
+	}
+
+	if statements := pending[1].Statements(); len(statements) != len(expected) || statements[0] != expected[0] || statements[1] != expected[1] {
+		t.Errorf("Plan()[1].Statements() = %q. want %q", statements, expected)
+	}
+
+	if tableExists(t, manager.DB, "second") {
+		t.Errorf("Plan() created table second. want nothing run")
+	}
+
+	fresh := newTestManager(t, rollbackTestFiles)
+
+	if pending, err := fresh.Plan(ctx); err != nil || len(pending) != 3 {
+		t.Errorf("Plan(no table) = %v, %v. want 3 pending", pending, err)
+	}
+
+	if tableExists(t, fresh.DB, fresh.Table) {
+		t.Errorf("Plan() created the migrations table. want nothing changed")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("valid", func(t *testing.T) {
+		manager := newTestManager(t, rollbackTestFiles)
+
+		verified, err := manager.Verify(ctx)
+
+		if err != nil {
+			t.Fatalf("Verify() = _, %s. want nil", err)
+		}
+
+		if len(verified) != 3 {
+			t.Errorf("len(Verify()) = %d. want 3", len(verified))
+		}
+
+		// the migrations table is rolled back too
+		for _, table := range []string{"first", "second", "third", "third_items", manager.Table} {
+			if tableExists(t, manager.DB, table) {
+				t.Errorf("Verify() kept table %s. want it rolled back", table)
+			}
+		}
+
+		if err := manager.Run(ctx); err != nil {
+			t.Errorf("Run() after Verify() = %s. want nil", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		manager := newTestManager(t, map[string]string{
+			"1000.first.sql":  rollbackTestFiles["1000.first.sql"],
+			"2000.broken.sql": "CREATE TABLE broken (id INTEGER PRIMARY KEY);\nCREATE TABLE first (id INTEGER PRIMARY KEY);",
+		})
+
+		if _, err := manager.Verify(ctx); err == nil {
+			t.Fatalf("Verify() = _, nil. want an error")
+		}
+
+		if tableExists(t, manager.DB, "first") || tableExists(t, manager.DB, "broken") {
+			t.Errorf("Verify() kept tables after failing. want them rolled back")
+		}
+	})
+}
+
+func TestRunCLIDryRun(t *testing.T) {
+	ctx := context.Background()
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	dir := writeTestFiles(t, map[string]string{
+		"1000.first.sql":  rollbackTestFiles["1000.first.sql"],
+		"2000.second.sql": rollbackTestFiles["2000.second.sql"],
+	})
+
+	tests := []struct {
+		flag     string
+		expected string
+	}{
+		{
+			"--dry-run",
+			"-- 1000.first.sql\nCREATE TABLE first (id INTEGER PRIMARY KEY);\n\n-- 2000.second.sql\nCREATE TABLE second (id INTEGER PRIMARY KEY);\n",
+		},
+		{
+			"--verify",
+			"-- 1000.first.sql\nCREATE TABLE first (id INTEGER PRIMARY KEY);\n\n-- 2000.second.sql\nCREATE TABLE second (id INTEGER PRIMARY KEY);\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.flag, func(t *testing.T) {
+			var out bytes.Buffer
+			cli := MigrationCLI{Out: &out, suppress: true}
+
+			if err := cli.Command(ctx, []string{"run", "--db", dbFile, "--dir", dir, test.flag}); err != nil {
+				t.Fatalf("run %s = %s. want nil", test.flag, err)
+			}
+
+			if out.String() != test.expected {
+				t.Errorf("run %s printed %q. want %q", test.flag, out.String(), test.expected)
+			}
+
+			db := openTestDB(t, dbFile)
+
+			for _, table := range []string{"first", DEFAULT_TABLE_NAME} {
+				if tableExists(t, db, table) {
+					t.Errorf("run %s created table %s. want nothing changed", test.flag, table)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"moon-cost/assert"
 	"strings"
 	"time"
 )
@@ -14,35 +15,200 @@ CREATE TABLE IF NOT EXISTS %s (
   name TEXT NOT NULL,
   filename TEXT NOT NULL,
   created INTEGER NOT NULL,
-  instruction TEXT NOT NULL
+  instruction TEXT NOT NULL,
+  appliedAt INTEGER,
+  checksum TEXT
 )
 `
 
+// Columns added after the table was first created. Tables created before
+// them are upgraded in place. Rows applied before a column existed are NULL
+var migrationsTableUpgrades = []struct {
+	column     string
+	definition string
+}{
+	{"appliedAt", "appliedAt INTEGER"},
+	{"checksum", "checksum TEXT"},
+}
+
+// querier is a *sql.DB or a *sql.Tx
+type querier interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
 func (m *Manager) ensureMigrationsTable(ctx context.Context) error {
+	return m.ensureMigrationsTableIn(ctx, m.DB)
+}
+
+// Creates or upgrades the migrations table through q, so a transaction can
+// undo it
+func (m *Manager) ensureMigrationsTableIn(ctx context.Context, q querier) error {
 	m.logger.Debug("Ensuring migrations table exists", "table", m.Table)
 
-	_, err := m.DB.ExecContext(ctx, m.formatQuery(ensureMigrationsTableQuery))
+	_, err := q.ExecContext(ctx, m.formatQuery(ensureMigrationsTableQuery))
 
 	if err != nil {
 		return fmt.Errorf("Error ensuring migrations table %s: %w", m.Table, err)
 	}
 
+	columns, err := m.migrationsTableColumns(ctx, q)
+
+	if err != nil {
+		return err
+	}
+
+	for _, upgrade := range migrationsTableUpgrades {
+		if columns[upgrade.column] {
+			continue
+		}
+
+		m.logger.Info("Upgrading migrations table", "table", m.Table, "column", upgrade.column)
+
+		if _, err := q.ExecContext(ctx, m.formatQuery("ALTER TABLE %s ADD COLUMN "+upgrade.definition)); err != nil {
+			return fmt.Errorf("Error upgrading migrations table %s: %w", m.Table, err)
+		}
+	}
+
+	return m.backfillChecksums(ctx, q)
+}
+
+const missingChecksumsQuery = `SELECT id, instruction FROM %s WHERE checksum IS NULL;`
+
+const setChecksumQuery = `UPDATE %s SET checksum = ? WHERE id = ?;`
+
+// Migrations applied before checksums were stored get the checksum of the
+// instruction they were applied with
+func (m *Manager) backfillChecksums(ctx context.Context, q querier) error {
+	rows, err := q.QueryContext(ctx, m.formatQuery(missingChecksumsQuery))
+
+	if err != nil {
+		return fmt.Errorf("Error querying for migrations without checksums: %w", err)
+	}
+
+	checksums := map[int]string{}
+
+	for rows.Next() {
+		var id int
+		var instruction string
+
+		if err := rows.Scan(&id, &instruction); err != nil {
+			rows.Close()
+			return err
+		}
+
+		checksums[id] = checksum(instruction)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, sum := range checksums {
+		m.logger.Debug("Storing checksum of applied migration", "id", id, "checksum", sum)
+
+		if _, err := q.ExecContext(ctx, m.formatQuery(setChecksumQuery), sum, id); err != nil {
+			return fmt.Errorf("Error storing migration checksum: %w", err)
+		}
+	}
+
 	return nil
 }
 
-const getAllMigrationsQuery = `
+func (m *Manager) migrationsTableColumns(ctx context.Context, q querier) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, m.Table)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error inspecting migrations table %s: %w", m.Table, err)
+	}
+
+	defer rows.Close()
+
+	columns := map[string]bool{}
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// Selects every migration with the appliedAt and checksum expressions given
+const selectMigrationsQuery = `
 SELECT
   id,
   name,
   filename,
   created,
-  instruction
+  instruction,
+  %s,
+  %s
 FROM %s
 ORDER BY created ASC;
 `
 
 func (m *Manager) getAllMigrations(ctx context.Context) ([]Migration, error) {
-	rows, err := m.DB.QueryContext(ctx, m.formatQuery(getAllMigrationsQuery))
+	return m.selectMigrations(ctx, "appliedAt", "COALESCE(checksum, '')")
+}
+
+// Reads the applied migrations without changing the migrations table. There
+// are none when the table does not exist. Columns the table has not been
+// upgraded with yet read as they would after the upgrade
+func (m *Manager) readMigrations(ctx context.Context) ([]Migration, error) {
+	assert.Ensure(m.logger, "Manager logger is nil")
+	assert.Ensure(m.DB, "Manager db is nil")
+	assert.Ok(m.Table != "", "Manager Table is blank")
+
+	columns, err := m.migrationsTableColumns(ctx, m.DB)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	appliedAt := "NULL"
+
+	if columns["appliedAt"] {
+		appliedAt = "appliedAt"
+	}
+
+	checksumColumn := "''"
+
+	if columns["checksum"] {
+		checksumColumn = "COALESCE(checksum, '')"
+	}
+
+	migrations, err := m.selectMigrations(ctx, appliedAt, checksumColumn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the same checksum the upgrade would store
+	for i, migration := range migrations {
+		if migration.Checksum == "" {
+			migrations[i].Checksum = checksum(migration.Instruction)
+		}
+	}
+
+	return migrations, nil
+}
+
+func (m *Manager) selectMigrations(ctx context.Context, appliedAt string, checksum string) ([]Migration, error) {
+	query := fmt.Sprintf(selectMigrationsQuery, appliedAt, checksum, m.Table)
+
+	rows, err := m.DB.QueryContext(ctx, query)
 
 	if err != nil {
 		return nil, fmt.Errorf("Error querying for migrations: %w", err)
@@ -55,20 +221,32 @@ func (m *Manager) getAllMigrations(ctx context.Context) ([]Migration, error) {
 	for rows.Next() {
 		var migration Migration
 		var createdInt int64
+		var appliedAt sql.NullInt64
 
-		rows.Scan(
+		err := rows.Scan(
 			&migration.Id,
 			&migration.Name,
 			&migration.Filename,
 			&createdInt,
 			&migration.Instruction,
+			&appliedAt,
+			&migration.Checksum,
 		)
 
+		if err != nil {
+			return nil, fmt.Errorf("Error reading migrations: %w", err)
+		}
+
 		migration.Created = time.UnixMilli(createdInt)
+
+		if appliedAt.Valid {
+			migration.AppliedAt = time.UnixMilli(appliedAt.Int64)
+		}
+
 		migrations = append(migrations, migration)
 	}
 
-	return migrations, nil
+	return migrations, rows.Err()
 }
 
 const createMigrationQuery = `
@@ -76,9 +254,11 @@ INSERT INTO %s (
   name,
   filename,
   created,
-  instruction
+  instruction,
+  appliedAt,
+  checksum
 )
-VALUES (?, ?, ?, ?);
+VALUES (?, ?, ?, ?, ?, ?);
 `
 
 func (m *Manager) createMigrations(ctx context.Context, migrations []Migration) error {
@@ -88,22 +268,37 @@ func (m *Manager) createMigrations(ctx context.Context, migrations []Migration)
 		return err
 	}
 
-	stmt, err := tx.Prepare(m.formatQuery(createMigrationQuery))
-	defer stmt.Close()
+	if err := m.applyMigrations(ctx, tx, migrations); err != nil {
+		if rbError := tx.Rollback(); rbError != nil {
+			m.logger.Error("error running rolling back migration", "error", err)
+			return rbError
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		m.logger.Error("Error committing migration creation transaction", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Runs each migration and records it in tx. The caller commits or rolls back
+func (m *Manager) applyMigrations(ctx context.Context, tx *sql.Tx, migrations []Migration) error {
+	stmt, err := tx.PrepareContext(ctx, m.formatQuery(createMigrationQuery))
 
 	if err != nil {
 		return err
 	}
 
+	defer stmt.Close()
+
 	for _, migration := range migrations {
 		m.logger.Debug("Running migration", "name", migration.Name, "file", migration.Filename, "query", migration.Instruction)
 
 		if err := m.runMigration(ctx, tx, migration); err != nil {
-			if rbError := tx.Rollback(); rbError != nil {
-				m.logger.Error("error running rolling back migration", "error", err)
-				return rbError
-			}
-
 			return fmt.Errorf("Error running migration %w", err)
 		}
 
@@ -117,13 +312,11 @@ func (m *Manager) createMigrations(ctx context.Context, migrations []Migration)
 			migration.Filename,
 			migration.Created.UnixMilli(),
 			migration.Instruction,
+			m.now.Now().UnixMilli(),
+			migration.Checksum,
 		)
 
 		if err != nil {
-			if rbError := tx.Rollback(); rbError != nil {
-				return rbError
-			}
-
 			return err
 		}
 
@@ -133,18 +326,13 @@ func (m *Manager) createMigrations(ctx context.Context, migrations []Migration)
 		m.logger.Debug("created migration in transaction", "affected", affected, "id", id)
 	}
 
-	if err := tx.Commit(); err != nil {
-		m.logger.Error("Error committing migration creation transaction", "error", err)
-		return err
-	}
-
 	return nil
 }
 
-func (m *Manager) runMigration(ctx context.Context, tx *sql.Tx, migration Migration) error {
-	queries := splitQueries(migration.Instruction)
+const deleteMigrationQuery = `DELETE FROM %s WHERE id = ?;`
 
-	for _, q := range queries {
+func (m *Manager) runMigration(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	for _, q := range migration.Statements() {
 		_, err := tx.ExecContext(ctx, q)
 
 		if err != nil {
@@ -0,0 +1,67 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type repairCli struct {
+	dir        string
+	table      string
+	dbFilename string
+	cli        *MigrationCLI
+}
+
+func (r *repairCli) Init(args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+
+	fs.StringVar(&r.dir, "dir", "migrations", "Directory to find migration files")
+	fs.StringVar(&r.table, "table", "migrations", "Database table that migration data is stored in")
+	fs.StringVar(&r.dbFilename, "db", "", "SQLite File to repair migrations in")
+	r.cli.parseUniversalFlags(fs)
+
+	fs.Parse(args)
+
+	if r.dbFilename == "" {
+		return fmt.Errorf("Error: db flag required")
+	}
+
+	return nil
+}
+
+// Accepts the current contents of changed migration files
+func (r *repairCli) Command(ctx context.Context) error {
+	dbName := fmt.Sprintf("file:%s", r.dbFilename)
+
+	db, err := sql.Open("libsql", dbName)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	manager := Manager{
+		Dir:   r.dir,
+		Table: r.table,
+		DB:    db,
+	}
+
+	manager.Init(WithLogger(r.cli.logger), WithNow(r.cli.now))
+
+	repaired, err := manager.Repair(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(repaired) == 0 {
+		r.cli.logger.Info("No changed migrations to repair")
+	}
+
+	return nil
+}
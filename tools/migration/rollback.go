@@ -0,0 +1,106 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"moon-cost/assert"
+	"time"
+)
+
+var IrreversibleMigrationError = errors.New("Migration has no down section")
+
+// Reverts the last steps applied migrations, latest first
+func (m *Manager) Rollback(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("Rollback steps must be at least 1. Got %d", steps)
+	}
+
+	applied, err := m.appliedMigrations(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if steps > len(applied) {
+		return fmt.Errorf("Can not roll back %d migrations. Only %d applied", steps, len(applied))
+	}
+
+	return m.rollback(ctx, applied[len(applied)-steps:])
+}
+
+// Reverts every applied migration created after to, latest first. The
+// migration created at to is kept
+func (m *Manager) RollbackTo(ctx context.Context, to time.Time) error {
+	applied, err := m.appliedMigrations(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var revert []Migration
+
+	for _, migration := range applied {
+		if migration.Created.After(to) {
+			revert = append(revert, migration)
+		}
+	}
+
+	return m.rollback(ctx, revert)
+}
+
+func (m *Manager) appliedMigrations(ctx context.Context) ([]Migration, error) {
+	assert.Ensure(m.logger, "Manager logger is nil")
+	assert.Ensure(m.DB, "Manager db is nil")
+	assert.Ok(m.Table != "", "Manager Table is blank")
+
+	if err := m.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.getAllMigrations(ctx)
+}
+
+// Runs the down section of each migration from the last to the first and
+// deletes its row, all in one transaction. Nothing is reverted when any of the
+// migrations has no down section
+func (m *Manager) rollback(ctx context.Context, migrations []Migration) error {
+	if len(migrations) == 0 {
+		m.logger.Info("No migrations to roll back")
+		return nil
+	}
+
+	for _, migration := range migrations {
+		if migration.Down() == "" {
+			return fmt.Errorf("%w: %s", IrreversibleMigrationError, migration.Filename)
+		}
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+
+		m.logger.Debug("Rolling back migration", "name", migration.Name, "file", migration.Filename, "query", migration.Down())
+
+		for _, query := range splitQueries(migration.Down()) {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("Error rolling back migration %s: %w", migration.Filename, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, m.formatQuery(deleteMigrationQuery), migration.Id); err != nil {
+			return err
+		}
+
+		m.logger.Info("Migration rolled back successfully", "file", migration.Filename)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,231 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// Writes each file into a temp dir and returns the dir
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("os.WriteFile() = %s. want nil", err)
+		}
+	}
+
+	return dir
+}
+
+// Opens the SQLite database at path. It is closed on cleanup
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("libsql", "file:"+path)
+
+	if err != nil {
+		t.Fatalf("sql.Open() = _, %s. want nil", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// Returns a manager for a temp dir of files with a fresh database
+func newTestManager(t *testing.T, files map[string]string) *Manager {
+	t.Helper()
+
+	manager := &Manager{
+		Dir: writeTestFiles(t, files),
+		DB:  openTestDB(t, filepath.Join(t.TempDir(), "test.db")),
+	}
+
+	manager.Init(WithLogger(slog.New(slog.DiscardHandler)))
+
+	return manager
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+
+	if err != nil {
+		t.Fatalf("tableExists(%s) = %s. want nil", table, err)
+	}
+
+	return count > 0
+}
+
+var rollbackTestFiles = map[string]string{
+	"1000.first.sql": `
+-- +up
+CREATE TABLE first (id INTEGER PRIMARY KEY);
+
+-- +down
+DROP TABLE first;
+`,
+	"2000.second.sql": `
+-- +up
+CREATE TABLE second (id INTEGER PRIMARY KEY);
+-- +down
+DROP TABLE second;
+`,
+	"3000.third.sql": `
+-- +up
+CREATE TABLE third (id INTEGER PRIMARY KEY);
+CREATE TABLE third_items (id INTEGER PRIMARY KEY);
+
+-- +down
+DROP TABLE third_items;
+DROP TABLE third;
+`,
+}
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, rollbackTestFiles)
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	if err := manager.Rollback(ctx, 2); err != nil {
+		t.Fatalf("Rollback(2) = %s. want nil", err)
+	}
+
+	expected := map[string]bool{"first": true, "second": false, "third": false, "third_items": false}
+
+	for table, exists := range expected {
+		if actual := tableExists(t, manager.DB, table); actual != exists {
+			t.Errorf("table %s exists = %t. want %t", table, actual, exists)
+		}
+	}
+
+	applied, err := manager.getAllMigrations(ctx)
+
+	if err != nil {
+		t.Fatalf("getAllMigrations() = _, %s. want nil", err)
+	}
+
+	if len(applied) != 1 || applied[0].Name != "first" {
+		t.Errorf("applied migrations = %v. want only first", applied)
+	}
+
+	// the rolled back migrations run again
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run(again) = %s. want nil", err)
+	}
+
+	if !tableExists(t, manager.DB, "third") {
+		t.Errorf("table third exists = false after running again. want true")
+	}
+
+	if err := manager.Rollback(ctx, 4); err == nil {
+		t.Errorf("Rollback(4) = nil. want error for more steps than applied")
+	}
+
+	if err := manager.Rollback(ctx, 0); err == nil {
+		t.Errorf("Rollback(0) = nil. want error")
+	}
+}
+
+func TestRollbackTo(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, rollbackTestFiles)
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	if err := manager.RollbackTo(ctx, time.UnixMilli(1000)); err != nil {
+		t.Fatalf("RollbackTo(1000) = %s. want nil", err)
+	}
+
+	if !tableExists(t, manager.DB, "first") || tableExists(t, manager.DB, "second") {
+		t.Errorf("RollbackTo(1000) should keep first and drop second")
+	}
+
+	if err := manager.RollbackTo(ctx, time.UnixMilli(1000)); err != nil {
+		t.Errorf("RollbackTo(1000) again = %s. want nil", err)
+	}
+}
+
+func TestRollbackIrreversible(t *testing.T) {
+	ctx := context.Background()
+
+	files := map[string]string{
+		"1000.first.sql":  rollbackTestFiles["1000.first.sql"],
+		"2000.legacy.sql": `CREATE TABLE legacy (id INTEGER PRIMARY KEY);`,
+		"3000.third.sql":  rollbackTestFiles["3000.third.sql"],
+	}
+
+	manager := newTestManager(t, files)
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	if err := manager.Rollback(ctx, 2); !errors.Is(err, IrreversibleMigrationError) {
+		t.Fatalf("Rollback(2) = %v. want %s", err, IrreversibleMigrationError)
+	}
+
+	// nothing is reverted when any migration can not be
+	if !tableExists(t, manager.DB, "third") {
+		t.Errorf("table third exists = false. want true")
+	}
+
+	if err := manager.Rollback(ctx, 1); err != nil {
+		t.Errorf("Rollback(1) = %s. want nil", err)
+	}
+}
+
+func TestRollbackFailureKeepsMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	files := map[string]string{
+		"1000.first.sql": rollbackTestFiles["1000.first.sql"],
+		"2000.broken.sql": `
+-- +up
+CREATE TABLE broken (id INTEGER PRIMARY KEY);
+-- +down
+DROP TABLE not_a_table;
+`,
+	}
+
+	manager := newTestManager(t, files)
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	if err := manager.RollbackTo(ctx, time.UnixMilli(0)); err == nil {
+		t.Fatalf("RollbackTo(0) = nil. want error")
+	}
+
+	applied, err := manager.getAllMigrations(ctx)
+
+	if err != nil {
+		t.Fatalf("getAllMigrations() = _, %s. want nil", err)
+	}
+
+	if len(applied) != 2 || !tableExists(t, manager.DB, "first") {
+		t.Errorf("failed rollback should keep every migration. got %d applied", len(applied))
+	}
+}
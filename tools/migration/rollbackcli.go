@@ -0,0 +1,70 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type rollbackCli struct {
+	table      string
+	dbFilename string
+	steps      int
+	to         int64
+	cli        *MigrationCLI
+}
+
+func (r *rollbackCli) Init(args []string) error {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+
+	fs.StringVar(&r.table, "table", "migrations", "Database table that migration data is stored in")
+	fs.StringVar(&r.dbFilename, "db", "", "SQLite File to roll back migrations in")
+	fs.IntVar(&r.steps, "steps", 0, "Number of migrations to roll back (default 1)")
+	fs.Int64Var(&r.to, "to", 0, "Roll back every migration after this timestamp, like the one in a migration filename")
+	r.cli.parseUniversalFlags(fs)
+
+	fs.Parse(args)
+
+	if r.dbFilename == "" {
+		return fmt.Errorf("Error: db flag required")
+	}
+
+	if r.steps != 0 && r.to != 0 {
+		return fmt.Errorf("Error: steps and to flags can not be used together")
+	}
+
+	if r.steps == 0 && r.to == 0 {
+		r.steps = 1
+	}
+
+	return nil
+}
+
+func (r *rollbackCli) Command(ctx context.Context) error {
+	dbName := fmt.Sprintf("file:%s", r.dbFilename)
+
+	db, err := sql.Open("libsql", dbName)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	manager := Manager{
+		Table: r.table,
+		DB:    db,
+	}
+
+	manager.Init(WithLogger(r.cli.logger), WithNow(r.cli.now))
+
+	if r.to != 0 {
+		return manager.RollbackTo(ctx, time.UnixMilli(r.to))
+	}
+
+	return manager.Rollback(ctx, r.steps)
+}
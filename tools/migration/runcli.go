@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
-	"log/slog"
 
 	_ "github.com/tursodatabase/go-libsql"
 )
@@ -14,6 +13,8 @@ type runCli struct {
 	dir        string
 	table      string
 	dbFilename string
+	dryRun     bool
+	verify     bool
 	cli        *MigrationCLI
 }
 
@@ -23,6 +24,8 @@ func (r *runCli) Init(args []string) error {
 	fs.StringVar(&r.dir, "dir", "migrations", "Directory to find migration files")
 	fs.StringVar(&r.table, "table", "migrations", "Database table that migration data is stored in")
 	fs.StringVar(&r.dbFilename, "db", "", "SQLite File to run migrations against")
+	fs.BoolVar(&r.dryRun, "dry-run", false, "Print the pending migrations and their statements without running them")
+	fs.BoolVar(&r.verify, "verify", false, "Print the pending migrations and run them in a transaction that is rolled back")
 	r.cli.parseUniversalFlags(fs)
 
 	fs.Parse(args)
@@ -45,14 +48,44 @@ func (r *runCli) Command(ctx context.Context) error {
 
 	defer db.Close()
 
-	logger := slog.Default()
-
 	manager := Manager{
-		Dir:    r.dir,
-		Table:  r.table,
-		DB:     db,
-		logger: logger,
+		Dir:   r.dir,
+		Table: r.table,
+		DB:    db,
+	}
+
+	manager.Init(WithLogger(r.cli.logger), WithNow(r.cli.now))
+
+	if r.dryRun || r.verify {
+		return r.plan(ctx, &manager)
 	}
 
 	return manager.Run(ctx)
 }
+
+// Prints the pending migrations. With verify they are also run and rolled
+// back so invalid statements fail here instead of in the real run
+func (r *runCli) plan(ctx context.Context, manager *Manager) error {
+	pending, err := manager.Plan(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		r.cli.logger.Info("No new migrations to run")
+		return nil
+	}
+
+	if err := writePlan(r.cli.out(), pending); err != nil {
+		return err
+	}
+
+	if !r.verify {
+		return nil
+	}
+
+	_, err = manager.Verify(ctx)
+
+	return err
+}
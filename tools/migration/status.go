@@ -0,0 +1,126 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var PendingMigrationsError = errors.New("Migrations are pending")
+
+// State is how a migration file compares to the migrations table
+type State string
+
+const (
+	StateApplied State = "applied"
+	StatePending State = "pending"
+	// Not applied but older than an applied migration, which running the
+	// migrations refuses
+	StateOutOfOrder State = "out of order"
+	// Applied but its file is gone
+	StateMissing State = "missing file"
+	// Applied under another name than its file has now
+	StateRenamed State = "renamed"
+	// Its file was changed after it was applied
+	StateChanged State = "changed"
+)
+
+// MigrationStatus is the state of one migration, matched between the table
+// and the dir by its timestamp. AppliedName is the name it was applied under
+// when it was renamed
+type MigrationStatus struct {
+	Created     time.Time  `json:"created"`
+	Name        string     `json:"name"`
+	Filename    string     `json:"filename"`
+	State       State      `json:"state"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+	AppliedName string     `json:"appliedName,omitempty"`
+}
+
+// Compares the applied migrations with the migration files without changing
+// anything, not even creating or upgrading the migrations table. Migrations are
+// ordered by their timestamp
+func (m *Manager) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.readMigrations(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := m.inspectDir()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compareMigrations(applied, files), nil
+}
+
+func compareMigrations(applied, files []Migration) []MigrationStatus {
+	byCreated := map[int64]Migration{}
+
+	for _, file := range files {
+		byCreated[file.Created.UnixMilli()] = file
+	}
+
+	var statuses []MigrationStatus
+	var lastApplied time.Time
+
+	for _, a := range applied {
+		if a.Created.After(lastApplied) {
+			lastApplied = a.Created
+		}
+
+		status := MigrationStatus{
+			Created:  a.Created,
+			Name:     a.Name,
+			Filename: a.Filename,
+			State:    StateApplied,
+		}
+
+		if !a.AppliedAt.IsZero() {
+			appliedAt := a.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		file, ok := byCreated[a.Created.UnixMilli()]
+
+		switch {
+		case !ok:
+			status.State = StateMissing
+		case file.Name != a.Name:
+			status.State = StateRenamed
+			status.Name = file.Name
+			status.Filename = file.Filename
+			status.AppliedName = a.Name
+		case file.Checksum != a.Checksum:
+			status.State = StateChanged
+		}
+
+		delete(byCreated, a.Created.UnixMilli())
+		statuses = append(statuses, status)
+	}
+
+	for _, file := range byCreated {
+		state := StatePending
+
+		// files are run in order after the applied ones, like syncMigrations
+		if file.Created.Before(lastApplied) {
+			state = StateOutOfOrder
+		}
+
+		statuses = append(statuses, MigrationStatus{
+			Created:  file.Created,
+			Name:     file.Name,
+			Filename: file.Filename,
+			State:    state,
+		})
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Created.Before(statuses[j].Created)
+	})
+
+	return statuses
+}
@@ -0,0 +1,250 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"moon-cost/common"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	appliedAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	manager := newTestManager(t, rollbackTestFiles)
+	manager.now = common.TestNow{Time: appliedAt}
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	// rename one applied file, delete another and add one between the applied
+	// files and one after them
+	rename := func(from, to string) {
+		if err := os.Rename(filepath.Join(manager.Dir, from), filepath.Join(manager.Dir, to)); err != nil {
+			t.Fatalf("os.Rename() = %s. want nil", err)
+		}
+	}
+
+	rename("2000.second.sql", "2000.seconds.sql")
+
+	if err := os.Remove(filepath.Join(manager.Dir, "3000.third.sql")); err != nil {
+		t.Fatalf("os.Remove() = %s. want nil", err)
+	}
+
+	for _, filename := range []string{"2500.between.sql", "4000.fourth.sql"} {
+		if err := os.WriteFile(filepath.Join(manager.Dir, filename), []byte("SELECT 1;"), 0644); err != nil {
+			t.Fatalf("os.WriteFile() = %s. want nil", err)
+		}
+	}
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status() = _, %s. want nil", err)
+	}
+
+	expected := []struct {
+		name    string
+		state   State
+		applied bool
+	}{
+		{"first", StateApplied, true},
+		{"seconds", StateRenamed, true},
+		{"between", StateOutOfOrder, false},
+		{"third", StateMissing, true},
+		{"fourth", StatePending, false},
+	}
+
+	if len(statuses) != len(expected) {
+		t.Fatalf("len(Status()) = %d. want %d", len(statuses), len(expected))
+	}
+
+	for i, want := range expected {
+		status := statuses[i]
+
+		if status.Name != want.name || status.State != want.state {
+			t.Errorf("Status()[%d] = %s %s. want %s %s", i, status.Name, status.State, want.name, want.state)
+		}
+
+		if (status.AppliedAt != nil) != want.applied {
+			t.Errorf("Status()[%d].AppliedAt = %v. want applied %t", i, status.AppliedAt, want.applied)
+		}
+
+		if status.AppliedAt != nil && !status.AppliedAt.Equal(appliedAt) {
+			t.Errorf("Status()[%d].AppliedAt = %s. want %s", i, status.AppliedAt, appliedAt)
+		}
+	}
+
+	if statuses[1].AppliedName != "second" {
+		t.Errorf("Status()[1].AppliedName = %q. want %q", statuses[1].AppliedName, "second")
+	}
+}
+
+func TestMigrationsTableUpgrade(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, rollbackTestFiles)
+
+	// the table as it was before appliedAt and checksums were recorded
+	oldTable := []struct {
+		query string
+		args  []any
+	}{
+		{`CREATE TABLE migrations (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  filename TEXT NOT NULL,
+  created INTEGER NOT NULL,
+  instruction TEXT NOT NULL
+)`, nil},
+		{
+			`INSERT INTO migrations (name, filename, created, instruction) VALUES ('first', '1000.first.sql', 1000, ?)`,
+			[]any{rollbackTestFiles["1000.first.sql"]},
+		},
+		{`CREATE TABLE first (id INTEGER PRIMARY KEY)`, nil},
+	}
+
+	for _, old := range oldTable {
+		if _, err := manager.DB.Exec(old.query, old.args...); err != nil {
+			t.Fatalf("creating old migrations table = %s. want nil", err)
+		}
+	}
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status() = _, %s. want nil", err)
+	}
+
+	if len(statuses) != 3 {
+		t.Fatalf("len(Status()) = %d. want 3", len(statuses))
+	}
+
+	if statuses[0].AppliedAt != nil {
+		t.Errorf("Status()[0].AppliedAt = %s. want nil for a migration applied before the upgrade", statuses[0].AppliedAt)
+	}
+
+	if statuses[2].State != StateApplied || statuses[2].AppliedAt == nil {
+		t.Errorf("Status()[2] = %s %v. want applied with a time", statuses[2].State, statuses[2].AppliedAt)
+	}
+}
+
+func TestStatusCLI(t *testing.T) {
+	ctx := context.Background()
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	dir := writeTestFiles(t, rollbackTestFiles)
+
+	manager := Manager{Dir: dir, DB: openTestDB(t, dbFile)}
+	manager.Init(WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	var out bytes.Buffer
+	cli := MigrationCLI{Out: &out, suppress: true}
+
+	if err := cli.Command(ctx, []string{"status", "--db", dbFile, "--dir", dir}); err != nil {
+		t.Fatalf("status = %s. want nil", err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 4 {
+		t.Errorf("status printed %d lines. want a header and 3 migrations:\n%s", len(lines), out.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "4000.fourth.sql"), []byte("SELECT 1;"), 0644); err != nil {
+		t.Fatalf("os.WriteFile() = %s. want nil", err)
+	}
+
+	out.Reset()
+
+	err := cli.Command(ctx, []string{"status", "--db", dbFile, "--dir", dir, "--json"})
+
+	if !errors.Is(err, PendingMigrationsError) {
+		t.Errorf("status with pending = %v. want %s", err, PendingMigrationsError)
+	}
+
+	var statuses []MigrationStatus
+
+	if err := json.Unmarshal(out.Bytes(), &statuses); err != nil {
+		t.Fatalf("json.Unmarshal() = %s. want nil", err)
+	}
+
+	if len(statuses) != 4 || statuses[3].State != StatePending {
+		t.Errorf("status --json = %+v. want fourth pending", statuses)
+	}
+}
+
+func TestStatusReadOnly(t *testing.T) {
+	ctx := context.Background()
+	manager := newTestManager(t, rollbackTestFiles)
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status(no table) = _, %s. want nil", err)
+	}
+
+	if len(statuses) != 3 || statuses[0].State != StatePending {
+		t.Errorf("Status(no table) = %+v. want 3 pending", statuses)
+	}
+
+	if tableExists(t, manager.DB, manager.Table) {
+		t.Fatalf("Status() created the migrations table. want it left alone")
+	}
+
+	// the table as it was before appliedAt and checksums were recorded
+	oldTable := []struct {
+		query string
+		args  []any
+	}{
+		{`CREATE TABLE migrations (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  filename TEXT NOT NULL,
+  created INTEGER NOT NULL,
+  instruction TEXT NOT NULL
+)`, nil},
+		{
+			`INSERT INTO migrations (name, filename, created, instruction) VALUES ('first', '1000.first.sql', 1000, ?)`,
+			[]any{rollbackTestFiles["1000.first.sql"]},
+		},
+	}
+
+	for _, old := range oldTable {
+		if _, err := manager.DB.Exec(old.query, old.args...); err != nil {
+			t.Fatalf("creating old migrations table = %s. want nil", err)
+		}
+	}
+
+	statuses, err = manager.Status(ctx)
+
+	if err != nil {
+		t.Fatalf("Status(old table) = _, %s. want nil", err)
+	}
+
+	if len(statuses) != 3 || statuses[0].State != StateApplied || statuses[1].State != StatePending {
+		t.Errorf("Status(old table) = %+v. want first applied and the rest pending", statuses)
+	}
+
+	columns, err := manager.migrationsTableColumns(ctx, manager.DB)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if columns["appliedAt"] || columns["checksum"] {
+		t.Errorf("Status() upgraded the migrations table to %v. want it left alone", columns)
+	}
+}
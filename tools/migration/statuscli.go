@@ -0,0 +1,122 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+type statusCli struct {
+	dir        string
+	table      string
+	dbFilename string
+	json       bool
+	cli        *MigrationCLI
+}
+
+func (s *statusCli) Init(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+
+	fs.StringVar(&s.dir, "dir", "migrations", "Directory to find migration files")
+	fs.StringVar(&s.table, "table", "migrations", "Database table that migration data is stored in")
+	fs.StringVar(&s.dbFilename, "db", "", "SQLite File to check migrations against")
+	fs.BoolVar(&s.json, "json", false, "Print the status as json")
+	s.cli.parseUniversalFlags(fs)
+
+	fs.Parse(args)
+
+	if s.dbFilename == "" {
+		return fmt.Errorf("Error: db flag required")
+	}
+
+	return nil
+}
+
+// Prints the status of every migration. Returns PendingMigrationsError, after
+// printing, when any migration is pending or out of order
+func (s *statusCli) Command(ctx context.Context) error {
+	dbName := fmt.Sprintf("file:%s", s.dbFilename)
+
+	db, err := sql.Open("libsql", dbName)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	manager := Manager{
+		Dir:   s.dir,
+		Table: s.table,
+		DB:    db,
+	}
+
+	manager.Init(WithLogger(s.cli.logger), WithNow(s.cli.now))
+
+	statuses, err := manager.Status(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.print(statuses); err != nil {
+		return err
+	}
+
+	pending := 0
+
+	for _, status := range statuses {
+		if status.State == StatePending || status.State == StateOutOfOrder {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("%w: %d", PendingMigrationsError, pending)
+	}
+
+	return nil
+}
+
+func (s *statusCli) print(statuses []MigrationStatus) error {
+	out := s.cli.out()
+
+	if s.json {
+		if statuses == nil {
+			statuses = []MigrationStatus{}
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(statuses)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "TIMESTAMP\tNAME\tSTATE\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "-"
+
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		state := string(status.State)
+
+		if status.State == StateRenamed {
+			state = fmt.Sprintf("%s from %s", status.State, status.AppliedName)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Created.UnixMilli(), status.Name, state, appliedAt)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,162 @@
+package reporter
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"moon-cost/services/organization"
+	"moon-cost/services/product"
+	"moon-cost/services/report"
+	"moon-cost/services/tag"
+	"moon-cost/services/unit"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/tursodatabase/go-libsql"
+)
+
+// ReportCLI writes the menu profitability report of an organization
+//
+//	moon report -db moon.db -org 1 [-format html] [-group-by location] [-as-of 2025-01-31] [-tags 1,2] [-o report.html]
+type ReportCLI struct {
+	Out io.Writer
+
+	dbFilename string
+	org        int
+	format     string
+	groupBy    string
+	asOf       string
+	tags       string
+	output     string
+}
+
+const (
+	DBFlagDescription      = "SQLite file to report on"
+	OrgFlagDescription     = "Id of the organization to report on"
+	FormatFlagDescription  = "Format of the report: csv, json or html"
+	GroupByFlagDescription = "Groups products by category, location or tag"
+	AsOfFlagDescription    = "Costs products with the prices in effect at the end of this date, like 2025-01-31"
+	TagsFlagDescription    = "Only reports products with any of these tag ids, like 1,2"
+	OutputFlagDescription  = "File to write the report to instead of stdout"
+)
+
+func (c *ReportCLI) parse(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+
+	fs.StringVar(&c.dbFilename, "db", "", DBFlagDescription)
+	fs.IntVar(&c.org, "org", 0, OrgFlagDescription)
+	fs.StringVar(&c.format, "format", string(report.FormatCSV), FormatFlagDescription)
+	fs.StringVar(&c.groupBy, "group-by", string(report.GroupByCategory), GroupByFlagDescription)
+	fs.StringVar(&c.asOf, "as-of", "", AsOfFlagDescription)
+	fs.StringVar(&c.tags, "tags", "", TagsFlagDescription)
+	fs.StringVar(&c.output, "o", "", OutputFlagDescription)
+
+	fs.Parse(args)
+
+	if c.dbFilename == "" {
+		return fmt.Errorf("Error: db flag required")
+	}
+
+	if c.org == 0 {
+		return fmt.Errorf("Error: org flag required")
+	}
+
+	return nil
+}
+
+func (c *ReportCLI) input() (report.MenuInput, error) {
+	input := report.MenuInput{GroupBy: report.GroupBy(c.groupBy)}
+
+	for _, id := range strings.Split(c.tags, ",") {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+
+		tagId, err := strconv.Atoi(strings.TrimSpace(id))
+
+		if err != nil {
+			return input, fmt.Errorf("Error: invalid tag id %q", id)
+		}
+
+		input.TagIds = append(input.TagIds, tagId)
+	}
+
+	if c.asOf == "" {
+		return input, nil
+	}
+
+	asOf, err := time.Parse(time.DateOnly, c.asOf)
+
+	if err != nil {
+		return input, fmt.Errorf("Error: invalid as-of date %q", c.asOf)
+	}
+
+	input.AsOf = asOf.AddDate(0, 0, 1).Add(-time.Millisecond)
+
+	return input, nil
+}
+
+func (c *ReportCLI) Command(ctx context.Context, args []string) error {
+	if err := c.parse(args); err != nil {
+		return err
+	}
+
+	if c.Out == nil {
+		c.Out = os.Stdout
+	}
+
+	format, err := report.ParseFormat(c.format)
+
+	if err != nil {
+		return err
+	}
+
+	input, err := c.input()
+
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("libsql", fmt.Sprintf("file:%s", c.dbFilename))
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	logger := slog.New(slog.DiscardHandler)
+
+	products := product.NewService(product.NewSQLiteRepo(db), logger)
+	products.Units = unit.NewService(unit.NewSQLiteRepo(db), logger)
+	locations := organization.NewService(organization.NewSQLiteRepo(db), logger)
+	tags := tag.NewService(tag.NewSQLiteRepo(db), logger)
+
+	menu, err := report.NewService(products, locations, tags, logger).Menu(ctx, c.org, input)
+
+	if err != nil {
+		return err
+	}
+
+	if c.output == "" {
+		return report.Render(c.Out, menu, format)
+	}
+
+	file, err := os.Create(c.output)
+
+	if err != nil {
+		return err
+	}
+
+	if err := report.Render(file, menu, format); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
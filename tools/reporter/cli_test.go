@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"moon-cost/moontest"
+	"moon-cost/services/product"
+	"moon-cost/tools/migration"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Creates a migrated database file with one organization and product
+func newTestDB(t *testing.T) (string, int) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("libsql", "file:"+path)
+
+	if err != nil {
+		t.Fatalf("sql.Open() = _, %s. want nil", err)
+	}
+
+	defer db.Close()
+
+	manager := migration.Manager{Dir: filepath.Join("..", "..", "migrations"), DB: db}
+	manager.Init(migration.WithLogger(slog.New(slog.DiscardHandler)))
+
+	if err := manager.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %s. want nil", err)
+	}
+
+	orgId := moontest.InsertTestOrganization(t, db, "Moon Cafe")
+	products := product.NewService(product.NewSQLiteRepo(db), slog.New(slog.DiscardHandler))
+
+	if _, err := products.CreateProduct(context.Background(), orgId, product.ProductInput{Name: "Pizza", Category: "Pizza", MenuPrice: 1200, Servings: 4}); err != nil {
+		t.Fatalf("CreateProduct() = _, %s. want nil", err)
+	}
+
+	return path, orgId
+}
+
+func TestReportCLI(t *testing.T) {
+	ctx := context.Background()
+	db, orgId := newTestDB(t)
+	org := strconv.Itoa(orgId)
+
+	var out bytes.Buffer
+	cli := ReportCLI{Out: &out}
+
+	if err := cli.Command(ctx, []string{"-db", db, "-org", org}); err != nil {
+		t.Fatalf("Command() = %s. want nil", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "group,product,") || !strings.Contains(out.String(), "Pizza,Pizza,Pizza,4,12.00") {
+		t.Errorf("Command() printed %q. want csv report", out.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "report.html")
+	cli = ReportCLI{Out: &bytes.Buffer{}}
+
+	if err := cli.Command(ctx, []string{"-db", db, "-org", org, "-format", "html", "-as-of", "2025-01-31", "-o", file}); err != nil {
+		t.Fatalf("Command(html) = %s. want nil", err)
+	}
+
+	html, err := os.ReadFile(file)
+
+	if err != nil {
+		t.Fatalf("os.ReadFile() = _, %s. want nil", err)
+	}
+
+	if !strings.Contains(string(html), "Prices as of Jan 31, 2025") {
+		t.Errorf("Command(html) wrote %q. want the as of date", html)
+	}
+}
+
+func TestReportCLIErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		test string
+		args []string
+	}{
+		{"missing db", []string{"-org", "1"}},
+		{"missing org", []string{"-db", "test.db"}},
+		{"bad format", []string{"-db", "test.db", "-org", "1", "-format", "pdf"}},
+		{"bad as of", []string{"-db", "test.db", "-org", "1", "-as-of", "yesterday"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			cli := ReportCLI{Out: &bytes.Buffer{}}
+
+			if err := cli.Command(ctx, test.args); err == nil {
+				t.Errorf("Command() = nil. want error")
+			}
+		})
+	}
+}
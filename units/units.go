@@ -0,0 +1,221 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var (
+	UnknownUnitError      = errors.New("Unknown unit")
+	IncompatibleUnitError = errors.New("Incompatible units")
+	InvalidUnitError      = errors.New("Invalid unit")
+)
+
+// Dimension is the kind of quantity a unit measures. Only units of the same
+// dimension can be converted between each other
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Count  Dimension = "count"
+)
+
+// Base unit of each dimension that every other unit is defined in terms of
+var BaseUnits = map[Dimension]string{
+	Mass:   "g",
+	Volume: "ml",
+	Count:  "each",
+}
+
+// Unit is a named amount of a dimension. Factor is how many of the
+// dimension's base unit make up one of this unit
+type Unit struct {
+	Name      string    `json:"name"`
+	Dimension Dimension `json:"dimension"`
+	Factor    float64   `json:"factor"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Custom    bool      `json:"custom"`
+}
+
+// Standard metric and US units
+var Standard = []Unit{
+	{Name: "mg", Dimension: Mass, Factor: 0.001, Aliases: []string{"milligram", "milligrams"}},
+	{Name: "g", Dimension: Mass, Factor: 1, Aliases: []string{"gram", "grams"}},
+	{Name: "kg", Dimension: Mass, Factor: 1000, Aliases: []string{"kilogram", "kilograms"}},
+	{Name: "oz", Dimension: Mass, Factor: 28.349523125, Aliases: []string{"ounce", "ounces"}},
+	{Name: "lb", Dimension: Mass, Factor: 453.59237, Aliases: []string{"lbs", "pound", "pounds"}},
+
+	{Name: "ml", Dimension: Volume, Factor: 1, Aliases: []string{"milliliter", "milliliters", "millilitre", "millilitres"}},
+	{Name: "l", Dimension: Volume, Factor: 1000, Aliases: []string{"liter", "liters", "litre", "litres"}},
+	{Name: "tsp", Dimension: Volume, Factor: 4.92892159375, Aliases: []string{"teaspoon", "teaspoons"}},
+	{Name: "tbsp", Dimension: Volume, Factor: 14.78676478125, Aliases: []string{"tablespoon", "tablespoons"}},
+	{Name: "floz", Dimension: Volume, Factor: 29.5735295625, Aliases: []string{"fl oz", "fluid ounce", "fluid ounces"}},
+	{Name: "cup", Dimension: Volume, Factor: 236.5882365, Aliases: []string{"cups"}},
+	{Name: "pt", Dimension: Volume, Factor: 473.176473, Aliases: []string{"pint", "pints"}},
+	{Name: "qt", Dimension: Volume, Factor: 946.352946, Aliases: []string{"quart", "quarts"}},
+	{Name: "gal", Dimension: Volume, Factor: 3785.411784, Aliases: []string{"gallon", "gallons"}},
+
+	{Name: "each", Dimension: Count, Factor: 1, Aliases: []string{"ea", "unit", "units", "piece", "pieces", "pc"}},
+	{Name: "dozen", Dimension: Count, Factor: 12, Aliases: []string{"dz"}},
+}
+
+// CustomUnit defines a pack unit in terms of another unit, e.g. a case is
+// 6 gal or a bundle is 5 each
+type CustomUnit struct {
+	Name   string
+	Amount float64
+	Unit   string
+}
+
+// Registry looks up units by name or alias, case insensitively
+type Registry struct {
+	units  []Unit
+	lookup map[string]Unit
+}
+
+func NewRegistry(units ...Unit) (*Registry, error) {
+	registry := &Registry{
+		lookup: map[string]Unit{},
+	}
+
+	for _, unit := range units {
+		if err := registry.add(unit); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+var defaultRegistry, _ = NewRegistry(Standard...)
+
+// Returns a registry containing the Standard units
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func (r *Registry) add(unit Unit) error {
+	if strings.TrimSpace(unit.Name) == "" {
+		return fmt.Errorf("%w: name is required", InvalidUnitError)
+	}
+
+	if unit.Factor <= 0 {
+		return fmt.Errorf("%w: %s must be greater than 0", InvalidUnitError, unit.Name)
+	}
+
+	names := append([]string{unit.Name}, unit.Aliases...)
+
+	for _, name := range names {
+		if _, ok := r.lookup[normalize(name)]; ok {
+			return fmt.Errorf("%w: %s is already defined", InvalidUnitError, name)
+		}
+	}
+
+	for _, name := range names {
+		r.lookup[normalize(name)] = unit
+	}
+
+	r.units = append(r.units, unit)
+
+	return nil
+}
+
+// With returns a copy of the registry that also contains custom units. Custom
+// units are resolved in order so they may be defined in terms of each other
+func (r *Registry) With(custom ...CustomUnit) (*Registry, error) {
+	registry := &Registry{
+		units:  slices.Clone(r.units),
+		lookup: maps.Clone(r.lookup),
+	}
+
+	for _, c := range custom {
+		unit, err := registry.Resolve(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := registry.add(unit); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Resolve converts a custom unit definition into a Unit of the dimension it
+// is defined in
+func (r *Registry) Resolve(custom CustomUnit) (Unit, error) {
+	if custom.Amount <= 0 {
+		return Unit{}, fmt.Errorf("%w: %s amount must be greater than 0", InvalidUnitError, custom.Name)
+	}
+
+	of, err := r.Lookup(custom.Unit)
+
+	if err != nil {
+		return Unit{}, err
+	}
+
+	return Unit{
+		Name:      strings.TrimSpace(custom.Name),
+		Dimension: of.Dimension,
+		Factor:    custom.Amount * of.Factor,
+		Custom:    true,
+	}, nil
+}
+
+func (r *Registry) Lookup(name string) (Unit, error) {
+	unit, ok := r.lookup[normalize(name)]
+
+	if !ok {
+		return unit, fmt.Errorf("%w: %s", UnknownUnitError, name)
+	}
+
+	return unit, nil
+}
+
+// Units returns every unit in the order they were added
+func (r *Registry) Units() []Unit {
+	return slices.Clone(r.units)
+}
+
+// Converts amount of the from unit into the to unit. Units with the same name
+// convert to themselves even when they are not in the registry
+func (r *Registry) Convert(amount float64, from string, to string) (float64, error) {
+	if normalize(from) == normalize(to) {
+		return amount, nil
+	}
+
+	fromUnit, err := r.Lookup(from)
+
+	if err != nil {
+		return 0, err
+	}
+
+	toUnit, err := r.Lookup(to)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if fromUnit.Dimension != toUnit.Dimension {
+		return 0, fmt.Errorf(
+			"%w: can not convert %s (%s) to %s (%s)",
+			IncompatibleUnitError,
+			fromUnit.Name,
+			fromUnit.Dimension,
+			toUnit.Name,
+			toUnit.Dimension,
+		)
+	}
+
+	return amount * fromUnit.Factor / toUnit.Factor, nil
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,123 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestConvert(t *testing.T) {
+	registry := Default()
+
+	tests := []struct {
+		amount   float64
+		from     string
+		to       string
+		expected float64
+	}{
+		{1, "kg", "g", 1000},
+		{1, "lb", "oz", 16},
+		{453.59237, "g", "lb", 1},
+		{1, "gal", "cup", 16},
+		{1, "tbsp", "tsp", 3},
+		{1, "L", "ml", 1000},
+		{2, "dozen", "each", 24},
+		{5, "Pounds", "lbs", 5},
+		{3, "pinch", "pinch", 3},
+	}
+
+	for _, test := range tests {
+		converted, err := registry.Convert(test.amount, test.from, test.to)
+
+		if err != nil {
+			t.Errorf("Convert(%f, %s, %s) = _, %s. want nil", test.amount, test.from, test.to, err)
+			continue
+		}
+
+		if !almostEqual(converted, test.expected) {
+			t.Errorf("Convert(%f, %s, %s) = %f. want %f", test.amount, test.from, test.to, converted, test.expected)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	registry := Default()
+
+	tests := []struct {
+		from string
+		to   string
+		err  error
+	}{
+		{"g", "ml", IncompatibleUnitError},
+		{"each", "lb", IncompatibleUnitError},
+		{"pinch", "g", UnknownUnitError},
+		{"g", "smidge", UnknownUnitError},
+	}
+
+	for _, test := range tests {
+		_, err := registry.Convert(1, test.from, test.to)
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("Convert(1, %s, %s) = _, %v. want %s", test.from, test.to, err, test.err)
+		}
+	}
+}
+
+func TestCustomUnits(t *testing.T) {
+	registry, err := Default().With(
+		CustomUnit{Name: "case", Amount: 6, Unit: "gal"},
+		CustomUnit{Name: "sleeve", Amount: 50, Unit: "each"},
+		CustomUnit{Name: "box", Amount: 20, Unit: "sleeve"},
+	)
+
+	if err != nil {
+		t.Fatalf("With() = _, %s. want nil", err)
+	}
+
+	converted, err := registry.Convert(1, "box", "each")
+
+	if err != nil || !almostEqual(converted, 1000) {
+		t.Errorf("Convert(1, box, each) = %f, %v. want 1000, nil", converted, err)
+	}
+
+	converted, err = registry.Convert(1, "case", "gal")
+
+	if err != nil || !almostEqual(converted, 6) {
+		t.Errorf("Convert(1, case, gal) = %f, %v. want 6, nil", converted, err)
+	}
+
+	if _, err := registry.Convert(1, "case", "each"); !errors.Is(err, IncompatibleUnitError) {
+		t.Errorf("Convert(1, case, each) = _, %v. want %s", err, IncompatibleUnitError)
+	}
+
+	if _, err := Default().Lookup("case"); !errors.Is(err, UnknownUnitError) {
+		t.Errorf("Default().Lookup(case) = _, %v. custom units should not modify the original registry", err)
+	}
+}
+
+func TestCustomUnitErrors(t *testing.T) {
+	tests := []struct {
+		test   string
+		custom CustomUnit
+		err    error
+	}{
+		{test: "conflicts with standard unit", custom: CustomUnit{Name: "Cup", Amount: 1, Unit: "ml"}, err: InvalidUnitError},
+		{test: "unknown unit", custom: CustomUnit{Name: "case", Amount: 1, Unit: "crate"}, err: UnknownUnitError},
+		{test: "zero amount", custom: CustomUnit{Name: "case", Amount: 0, Unit: "each"}, err: InvalidUnitError},
+		{test: "blank name", custom: CustomUnit{Name: " ", Amount: 1, Unit: "each"}, err: InvalidUnitError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test, func(t *testing.T) {
+			_, err := Default().With(test.custom)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("With() = _, %v. want %s", err, test.err)
+			}
+		})
+	}
+}